@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/parser"
+	"github.com/firmannf/recon/internal/service"
+)
+
+// Config is the optional JSON configuration file passed with -config.
+// Command line flags take precedence over values from the file.
+type Config struct {
+	Passes            []string                 `json:"passes"`             // Match pass specs in order, e.g. ["reference", "exact", "window:1"]
+	ReferencePatterns []string                 `json:"reference_patterns"` // Regexes used by reference passes
+	FeeRules          []FeeRuleConfig          `json:"fee_rules"`          // Fee rules used by fee passes
+	Rules             []RuleConfig             `json:"rules"`              // Declarative match rules, used as the passes when no passes are given
+	Plugins           []PluginConfig           `json:"plugins"`            // External matchers used by plugin:<name> passes
+	BankPasses        map[string][]string      `json:"bank_passes"`        // Match pass specs per bank name, replacing the passes for that bank's lines
+	Balances          map[string]BalanceConfig `json:"balances"`           // Opening and closing balances per bank name, checked with the lines
+	StatementFiles    map[string]string        `json:"statement_files"`    // MT940 or camt.053 statement per bank name, its balances replace the configured ones
+}
+
+// BalanceConfig holds the opening and closing balances of a bank statement, both optional
+type BalanceConfig struct {
+	Opening *decimal.Decimal `json:"opening"`
+	Closing *decimal.Decimal `json:"closing"`
+}
+
+// PluginConfig is an external matcher as written in the config file
+type PluginConfig struct {
+	Name        string   `json:"name"`
+	Command     string   `json:"command"`
+	Args        []string `json:"args"`
+	Timeout     string   `json:"timeout"`      // Go duration, e.g. "2s"
+	MaxRestarts *int     `json:"max_restarts"` // Defaults to 3, 0 never restarts
+}
+
+// FeeRuleConfig is a fee rule as written in the config file, bank and channel are optional
+type FeeRuleConfig struct {
+	Bank    string          `json:"bank"`
+	Channel string          `json:"channel"`
+	Percent decimal.Decimal `json:"percent"`
+	Fixed   decimal.Decimal `json:"fixed"`
+}
+
+// RuleConfig is a match rule as written in the config file
+type RuleConfig struct {
+	Name             string           `json:"name"`
+	KeyFields        []string         `json:"key_fields"`
+	WindowDays       *int             `json:"window_days"`
+	Tolerance        *decimal.Decimal `json:"tolerance"`
+	ReferencePattern string           `json:"reference_pattern"`
+	Banks            []string         `json:"banks"`
+	Condition        string           `json:"condition"`
+	Priority         int              `json:"priority"`
+}
+
+// matchRules converts the configured match rules for the matcher
+func (c *Config) matchRules() []service.MatchRule {
+	rules := make([]service.MatchRule, len(c.Rules))
+	for i, rule := range c.Rules {
+		rules[i] = service.MatchRule{
+			Name:             rule.Name,
+			KeyFields:        rule.KeyFields,
+			WindowDays:       rule.WindowDays,
+			Tolerance:        rule.Tolerance,
+			ReferencePattern: rule.ReferencePattern,
+			Banks:            rule.Banks,
+			Condition:        rule.Condition,
+			Priority:         rule.Priority,
+		}
+	}
+	return rules
+}
+
+// feeRules converts the configured fee rules for the matcher
+func (c *Config) feeRules() []service.FeeRule {
+	rules := make([]service.FeeRule, len(c.FeeRules))
+	for i, rule := range c.FeeRules {
+		rules[i] = service.FeeRule{
+			BankName: rule.Bank,
+			Channel:  rule.Channel,
+			Percent:  rule.Percent,
+			Fixed:    rule.Fixed,
+		}
+	}
+	return rules
+}
+
+// statementBalances converts the configured balances for the balance check, the balances read
+// from the statement file of a bank replacing its configured ones
+func (c *Config) statementBalances(statementFiles map[string]string) (map[string]service.StatementBalances, error) {
+	statements := make(map[string]service.StatementBalances, len(c.Balances)+len(statementFiles))
+	for bankName, balances := range c.Balances {
+		statements[bankName] = service.StatementBalances{Opening: balances.Opening, Closing: balances.Closing}
+	}
+	for bankName, path := range statementFiles {
+		balances, err := parser.ParseStatementBalances(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid statement file %s for bank %s: %w", path, bankName, err)
+		}
+		statements[bankName] = balances
+	}
+	return statements, nil
+}
+
+// parseStatementFiles merges the statement files per bank from the config file with the
+// -statement values given as bank=path, the values taking precedence for their bank
+func parseStatementFiles(configured map[string]string, values []string) (map[string]string, error) {
+	statementFiles := make(map[string]string, len(configured))
+	for bank, path := range configured {
+		statementFiles[bank] = path
+	}
+	for _, value := range values {
+		bank, path, ok := strings.Cut(value, "=")
+		if !ok || bank == "" || path == "" {
+			return nil, fmt.Errorf("invalid statement: %q, expected bank=path, e.g. bank_bca=bca.sta", value)
+		}
+		statementFiles[bank] = path
+	}
+	return statementFiles, nil
+}
+
+// plugins converts the configured plugins for the matcher, keyed by name
+func (c *Config) plugins() (map[string]service.PluginConfig, error) {
+	plugins := make(map[string]service.PluginConfig, len(c.Plugins))
+	for i, plugin := range c.Plugins {
+		if plugin.Name == "" || plugin.Command == "" {
+			return nil, fmt.Errorf("plugin %d: name and command are required", i+1)
+		}
+		if _, exists := plugins[plugin.Name]; exists {
+			return nil, fmt.Errorf("duplicate plugin name %q", plugin.Name)
+		}
+
+		var timeout time.Duration
+		if plugin.Timeout != "" {
+			var err error
+			timeout, err = time.ParseDuration(plugin.Timeout)
+			if err != nil || timeout <= 0 {
+				return nil, fmt.Errorf("plugin %q: invalid timeout %q, expected a duration such as 2s", plugin.Name, plugin.Timeout)
+			}
+		}
+		if plugin.MaxRestarts != nil && *plugin.MaxRestarts < 0 {
+			return nil, fmt.Errorf("plugin %q: max_restarts must be >= 0, got %d", plugin.Name, *plugin.MaxRestarts)
+		}
+
+		plugins[plugin.Name] = service.PluginConfig{
+			Command:     plugin.Command,
+			Args:        plugin.Args,
+			Timeout:     timeout,
+			MaxRestarts: plugin.MaxRestarts,
+		}
+	}
+	return plugins, nil
+}
+
+// loadConfig reads and decodes a configuration file, rejecting unknown fields
+func loadConfig(path string) (*Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	var cfg Config
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return &cfg, nil
+}
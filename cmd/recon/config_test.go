@@ -0,0 +1,214 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expectedError string
+		check         func(t *testing.T, cfg *Config)
+	}{
+		{
+			name: "all sections",
+			content: `{
+  "passes": ["reference", "exact"],
+  "reference_patterns": ["INV-[0-9]+"],
+  "fee_rules": [{"bank": "bank_bca", "percent": "0.7", "fixed": "500"}],
+  "rules": [{"name": "t+2", "key_fields": ["type", "amount"], "window_days": 2}],
+  "plugins": [{"name": "scorer", "command": "python3", "max_restarts": 0}],
+  "bank_passes": {"bank_va": ["exact", "window:1"]},
+  "balances": {"bank_bca": {"opening": "1000", "closing": "2500"}},
+  "statement_files": {"bank_mandiri": "mandiri.sta"}
+}`,
+			check: func(t *testing.T, cfg *Config) {
+				if len(cfg.Passes) != 2 || cfg.Passes[0] != "reference" {
+					t.Errorf("Expected passes [reference exact], got %v", cfg.Passes)
+				}
+				if len(cfg.FeeRules) != 1 || cfg.FeeRules[0].Percent.String() != "0.7" {
+					t.Errorf("Expected one fee rule of 0.7 percent, got %v", cfg.FeeRules)
+				}
+				if len(cfg.Rules) != 1 || cfg.Rules[0].WindowDays == nil || *cfg.Rules[0].WindowDays != 2 {
+					t.Errorf("Expected one rule with a 2 day window, got %v", cfg.Rules)
+				}
+				if len(cfg.Plugins) != 1 || cfg.Plugins[0].MaxRestarts == nil || *cfg.Plugins[0].MaxRestarts != 0 {
+					t.Errorf("Expected one plugin with max_restarts 0, got %v", cfg.Plugins)
+				}
+				if len(cfg.BankPasses["bank_va"]) != 2 {
+					t.Errorf("Expected two passes for bank_va, got %v", cfg.BankPasses)
+				}
+				if closing := cfg.Balances["bank_bca"].Closing; closing == nil || closing.String() != "2500" {
+					t.Errorf("Expected a closing balance of 2500 for bank_bca, got %v", closing)
+				}
+				if cfg.StatementFiles["bank_mandiri"] != "mandiri.sta" {
+					t.Errorf("Expected the statement file mandiri.sta for bank_mandiri, got %v", cfg.StatementFiles)
+				}
+			},
+		},
+		{
+			name:          "unknown field",
+			content:       `{"pases": ["exact"]}`,
+			expectedError: `unknown field "pases"`,
+		},
+		{
+			name:          "malformed JSON",
+			content:       `{"passes": ["exact"`,
+			expectedError: "invalid config file",
+		},
+		{
+			name:          "wrong type",
+			content:       `{"passes": "exact"}`,
+			expectedError: "invalid config file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			cfg, err := loadConfig(path)
+			if tt.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, got nil", tt.expectedError)
+				}
+				if !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tt.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+
+	if _, err := loadConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for a missing config file, got nil")
+	}
+}
+
+func TestConfigPlugins(t *testing.T) {
+	zero := 0
+	negative := -1
+
+	tests := []struct {
+		name          string
+		plugins       []PluginConfig
+		expectedError string
+	}{
+		{
+			name:          "missing name",
+			plugins:       []PluginConfig{{Command: "python3"}},
+			expectedError: "plugin 1: name and command are required",
+		},
+		{
+			name:          "missing command",
+			plugins:       []PluginConfig{{Name: "scorer"}},
+			expectedError: "plugin 1: name and command are required",
+		},
+		{
+			name:          "duplicate name",
+			plugins:       []PluginConfig{{Name: "scorer", Command: "a"}, {Name: "scorer", Command: "b"}},
+			expectedError: `duplicate plugin name "scorer"`,
+		},
+		{
+			name:          "invalid timeout",
+			plugins:       []PluginConfig{{Name: "scorer", Command: "python3", Timeout: "soon"}},
+			expectedError: `plugin "scorer": invalid timeout "soon"`,
+		},
+		{
+			name:          "zero timeout",
+			plugins:       []PluginConfig{{Name: "scorer", Command: "python3", Timeout: "0s"}},
+			expectedError: `plugin "scorer": invalid timeout "0s"`,
+		},
+		{
+			name:          "negative max restarts",
+			plugins:       []PluginConfig{{Name: "scorer", Command: "python3", MaxRestarts: &negative}},
+			expectedError: `plugin "scorer": max_restarts must be >= 0`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Plugins: tt.plugins}
+			_, err := cfg.plugins()
+			if err == nil {
+				t.Fatalf("Expected error containing %q, got nil", tt.expectedError)
+			}
+			if !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %q", tt.expectedError, err.Error())
+			}
+		})
+	}
+
+	cfg := &Config{Plugins: []PluginConfig{
+		{Name: "scorer", Command: "python3", Args: []string{"scorer.py"}, Timeout: "2s", MaxRestarts: &zero},
+		{Name: "fallback", Command: "./fallback"},
+	}}
+	plugins, err := cfg.plugins()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	scorer := plugins["scorer"]
+	if scorer.Command != "python3" || len(scorer.Args) != 1 || scorer.Timeout != 2*time.Second {
+		t.Errorf("Expected scorer to run python3 scorer.py with a 2s timeout, got %+v", scorer)
+	}
+	if scorer.MaxRestarts == nil || *scorer.MaxRestarts != 0 {
+		t.Errorf("Expected scorer to keep max_restarts 0, got %v", scorer.MaxRestarts)
+	}
+	if plugins["fallback"].MaxRestarts != nil {
+		t.Errorf("Expected fallback to use the default max_restarts, got %v", *plugins["fallback"].MaxRestarts)
+	}
+}
+
+func TestConfigStatementBalances(t *testing.T) {
+	tmpDir := t.TempDir()
+	statementFile := filepath.Join(tmpDir, "bca.sta")
+	if err := os.WriteFile(statementFile, []byte(":20:STMT\n:60F:C240115IDR1000,00\n:62F:D240115IDR200,00\n"), 0644); err != nil {
+		t.Fatalf("Failed to write statement file: %v", err)
+	}
+
+	opening, closing := decimal.NewFromInt(5), decimal.NewFromInt(6)
+	cfg := &Config{Balances: map[string]BalanceConfig{
+		"bank_bca": {Opening: &opening, Closing: &closing},
+		"bank_bri": {Opening: &opening, Closing: &closing},
+	}}
+
+	statementFiles, err := parseStatementFiles(map[string]string{"bank_bca": "configured.sta"}, []string{"bank_bca=" + statementFile})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	statements, err := cfg.statementBalances(statementFiles)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The statement file given as a flag replaces both the configured file and the configured balances
+	if bca := statements["bank_bca"]; bca.Opening == nil || !bca.Opening.Equal(decimal.NewFromInt(1000)) || bca.Closing == nil || !bca.Closing.Equal(decimal.NewFromInt(-200)) {
+		t.Errorf("Expected bank_bca balances 1000 and -200 from the statement file, got %v and %v", bca.Opening, bca.Closing)
+	}
+	if bri := statements["bank_bri"]; bri.Opening == nil || !bri.Opening.Equal(opening) {
+		t.Errorf("Expected the configured balances for bank_bri, got %v", bri.Opening)
+	}
+
+	if _, err := cfg.statementBalances(map[string]string{"bank_bca": filepath.Join(tmpDir, "missing.sta")}); err == nil || !strings.Contains(err.Error(), "for bank bank_bca") {
+		t.Errorf("Expected an error naming the bank for a missing statement file, got %v", err)
+	}
+	for _, value := range []string{"bank_bca", "=bca.sta", "bank_bca="} {
+		if _, err := parseStatementFiles(nil, []string{value}); err == nil {
+			t.Errorf("Expected error for statement %q but got nil", value)
+		}
+	}
+}
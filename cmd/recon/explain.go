@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/firmannf/recon/internal/models"
+	"github.com/firmannf/recon/internal/service"
+)
+
+// runExplain implements the "explain" subcommand which reruns the reconciliation with tracing
+// and reports how a system transaction went through matching
+func runExplain(args []string) {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	params := bindReconciliationFlags(fs)
+	fTrxID := fs.String("trx", "", "TrxID of the system transaction to explain (required)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Explain how a system transaction was matched\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  %s explain -trx=ID [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Use the same matching options as the reconciliation run to explain.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExample:\n")
+		fmt.Fprintf(os.Stderr, "  %s explain -trx=TRX123 -system=transactions.csv -banks=bank_bca.csv -start=2024-01-01 -end=2024-01-31 -passes=exact,window:1\n", os.Args[0])
+	}
+
+	fs.Parse(args)
+
+	if *fTrxID == "" || params.SystemFile == "" || params.BankFiles == "" || params.StartDate == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	input, err := buildReconciliationInput(params)
+	if err != nil {
+		log.Fatalf("Failed to prepare explain: %v", err)
+	}
+
+	// Explaining a past run feeds the files it already processed, the file check would refuse them
+	input.FileCheck = nil
+
+	explanations, err := service.NewReconciliationService().Explain(input, *fTrxID)
+	closeMatchPipelines(input)
+	if err != nil {
+		log.Fatalf("Explain failed: %v", err)
+	}
+
+	for _, explanation := range explanations {
+		formatExplanation(os.Stdout, explanation)
+	}
+}
+
+func formatExplanation(w io.Writer, explanation models.MatchExplanation) {
+	trx := explanation.SystemTransaction
+	fmt.Fprintln(w, "\n"+strings.Repeat("=", 80))
+	fmt.Fprintf(w, "%s %s %s Rp. %s\n", trx.TrxID, trx.Type, trx.TransactionTime.Format("2006-01-02 15:04:05"), trx.Amount.StringFixed(2))
+	fmt.Fprintf(w, "Outcome: %s\n", explanation.Outcome)
+	fmt.Fprintln(w, strings.Repeat("=", 80))
+
+	if len(explanation.Passes) == 0 {
+		fmt.Fprintln(w, "\nNo matching pass looked up this transaction.")
+	}
+	for i, pass := range explanation.Passes {
+		fmt.Fprintf(w, "\n%d. Pass %s\n", i+1, pass.Pass)
+		fmt.Fprintf(w, "   Key: %s\n", pass.Key)
+		if len(pass.Candidates) == 0 {
+			fmt.Fprintln(w, "   No bank statement line shares this key")
+			continue
+		}
+		fmt.Fprintf(w, "   Candidates: %d\n", len(pass.Candidates))
+		for _, candidate := range pass.Candidates {
+			stmtLine := candidate.BankStatementLine
+			fmt.Fprintf(w, "   - %-20s %-15s %-10s %20s  %s", stmtLine.UniqueIdentifier, stmtLine.BankName, stmtLine.Date.Format("2006-01-02"), fmt.Sprintf("Rp. %v", stmtLine.Amount.StringFixed(2)), strings.ToUpper(string(candidate.Status)))
+			if candidate.Reason != "" {
+				fmt.Fprintf(w, ": %s", candidate.Reason)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/firmannf/recon/internal/generator"
+)
+
+// runGenerate implements the "generate" subcommand which writes synthetic system/bank CSV pairs
+func runGenerate(args []string) {
+	defaults := generator.DefaultConfig(10_000)
+
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	var (
+		fRows          = fs.Int("rows", defaults.Rows, "Number of system transactions to generate")
+		fMatchRate     = fs.Float64("match-rate", defaults.MatchRate, "Fraction of system transactions with a bank counterpart (0-1)")
+		fDuplicateRate = fs.Float64("duplicate-rate", defaults.DuplicateRate, "Fraction of system transactions sharing type, amount and date with another (0-1)")
+		fDays          = fs.Int("days", defaults.DateSpanDays, "Number of days the transactions are spread over")
+		fBanks         = fs.Int("banks", defaults.Banks, "Number of bank statement files")
+		fStartDate     = fs.String("start", defaults.StartDate.Format(DEFAULT_DATE_FORMAT), "First transaction date (YYYY-MM-DD) in UTC+7")
+		fSeed          = fs.Int64("seed", defaults.Seed, "Random seed, the same seed always produces the same files")
+		fOutDir        = fs.String("out", ".", "Directory to write the CSV files to")
+		fPrefix        = fs.String("prefix", "synthetic", "File name prefix")
+	)
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Generate synthetic reconciliation data\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  %s generate [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExample:\n")
+		fmt.Fprintf(os.Stderr, "  %s generate -rows=1000000 -banks=5 -match-rate=0.98 -out=/tmp/recon\n", os.Args[0])
+	}
+
+	fs.Parse(args)
+
+	start, err := time.ParseInLocation(DEFAULT_DATE_FORMAT, *fStartDate, defaults.StartDate.Location())
+	if err != nil {
+		log.Fatalf("Invalid start date format: %v. Expected format: YYYY-MM-DD", err)
+	}
+
+	cfg := generator.Config{
+		Rows:          *fRows,
+		MatchRate:     *fMatchRate,
+		DuplicateRate: *fDuplicateRate,
+		DateSpanDays:  *fDays,
+		Banks:         *fBanks,
+		StartDate:     start,
+		Seed:          *fSeed,
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid generator options: %v", err)
+	}
+
+	if err := os.MkdirAll(*fOutDir, 0755); err != nil {
+		log.Fatalf("Failed to create output directory: %v", err)
+	}
+
+	systemFile, bankFiles, err := generator.WriteFiles(cfg, *fOutDir, *fPrefix)
+	if err != nil {
+		log.Fatalf("Failed to generate data: %v", err)
+	}
+
+	fmt.Printf("System transactions: %s\n", systemFile)
+	for _, bankFile := range bankFiles {
+		fmt.Printf("Bank statements: %s\n", bankFile)
+	}
+}
@@ -0,0 +1,391 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/firmannf/recon/internal/models"
+)
+
+// jsonReport is the machine-readable report written with -format=json.
+// Amounts are strings with 2 decimal places so that no precision is lost.
+type jsonReport struct {
+	Parameters                  jsonParameters         `json:"parameters"`
+	Summary                     jsonSummary            `json:"summary"`
+	Passes                      []jsonPass             `json:"passes"`
+	StrategiesByBank            map[string][]string    `json:"strategies_by_bank"`
+	Matches                     []jsonMatch            `json:"matches"`
+	UnmatchedSystemTransactions []jsonUnmatchedSystem  `json:"unmatched_system_transactions"`
+	UnmatchedBankStatementLines []jsonUnmatchedBank    `json:"unmatched_bank_statement_lines"`
+	BatchMatches                []jsonGroupedMatch     `json:"batch_matches"`
+	SplitMatches                []jsonGroupedMatch     `json:"split_matches"`
+	Reversals                   []jsonReversal         `json:"reversals"`
+	InternalTransfers           []jsonInternalTransfer `json:"internal_transfers"`
+	Duplicates                  []jsonDuplicate        `json:"duplicates"`
+	RepeatedBankFiles           []jsonFileOverlap      `json:"repeated_bank_files"`
+	BalanceGaps                 []jsonBalanceGap       `json:"balance_gaps"`
+	CoverageGaps                []jsonCoverageGap      `json:"coverage_gaps"`
+	NotYetReconcilable          []jsonTransaction      `json:"not_yet_reconcilable"`
+	Statistics                  *jsonStatistics        `json:"statistics,omitempty"` // Only with -stats
+}
+
+type jsonParameters struct {
+	SystemFile string `json:"system_file"`
+	BankFiles  string `json:"bank_files"`
+	StartDate  string `json:"start_date"`
+	EndDate    string `json:"end_date"`
+}
+
+type jsonSummary struct {
+	TotalTransactionsProcessed int    `json:"total_transactions_processed"`
+	TotalSystemTransactions    int    `json:"total_system_transactions"`
+	TotalBankStatementLines    int    `json:"total_bank_statement_lines"`
+	TotalMatchedTransactions   int    `json:"total_matched_transactions"`
+	TotalUnmatchedTransactions int    `json:"total_unmatched_transactions"`
+	TotalNotYetReconcilable    int    `json:"total_not_yet_reconcilable"`
+	TotalDiscrepancies         string `json:"total_discrepancies"`
+	TotalFees                  string `json:"total_fees"`
+}
+
+type jsonPass struct {
+	Name    string `json:"name"`
+	Bank    string `json:"bank,omitempty"`
+	Matched int    `json:"matched"`
+}
+
+type jsonMatch struct {
+	SystemTransaction  jsonTransaction       `json:"system_transaction"`
+	BankStatementLine  jsonBankStatementLine `json:"bank_statement_line"`
+	Discrepancy        string                `json:"discrepancy"`
+	Fee                string                `json:"fee"`
+	ReferenceConfirmed bool                  `json:"reference_confirmed"`
+	Pass               string                `json:"pass"`
+}
+
+// jsonStatistics holds the run statistics, durations are in seconds
+type jsonStatistics struct {
+	ParseSecondsByFile map[string]float64 `json:"parse_seconds_by_file"`
+	ParseSeconds       float64            `json:"parse_seconds"`
+	FilterSeconds      float64            `json:"filter_seconds"`
+	IndexBuildSeconds  float64            `json:"index_build_seconds"`
+	MatchSeconds       float64            `json:"match_seconds"`
+	TotalSeconds       float64            `json:"total_seconds"`
+	RowsParsed         int                `json:"rows_parsed"`
+	RowsPerSecond      float64            `json:"rows_per_second"`
+	PeakHeapBytes      uint64             `json:"peak_heap_bytes"`
+	IndexKeys          int                `json:"index_keys"`
+	IndexCollisions    int                `json:"index_collisions"`
+}
+
+type jsonTransaction struct {
+	TrxID           string `json:"trx_id"`
+	Amount          string `json:"amount"`
+	Type            string `json:"type"`
+	TransactionTime string `json:"transaction_time"`
+}
+
+type jsonBankStatementLine struct {
+	UniqueIdentifier string `json:"unique_identifier"`
+	Bank             string `json:"bank"`
+	Amount           string `json:"amount"`
+	Type             string `json:"type"`
+	Date             string `json:"date"`
+}
+
+type jsonUnmatchedSystem struct {
+	jsonTransaction
+	NearMisses []jsonSystemNearMiss `json:"near_misses,omitempty"`
+}
+
+type jsonSystemNearMiss struct {
+	jsonBankStatementLine
+	Reason string `json:"reason"`
+}
+
+type jsonUnmatchedBank struct {
+	jsonBankStatementLine
+	NearMisses []jsonBankNearMiss `json:"near_misses,omitempty"`
+}
+
+type jsonBankNearMiss struct {
+	jsonTransaction
+	Reason string `json:"reason"`
+}
+
+type jsonGroupedMatch struct {
+	SystemTransactions []jsonTransaction       `json:"system_transactions"`
+	BankStatementLines []jsonBankStatementLine `json:"bank_statement_lines"`
+	Discrepancy        string                  `json:"discrepancy"`
+}
+
+type jsonReversal struct {
+	Source   string `json:"source"`
+	Original string `json:"original"`
+	Reversal string `json:"reversal"`
+	Type     string `json:"type"`
+	Amount   string `json:"amount"`
+}
+
+type jsonDuplicate struct {
+	Source      string   `json:"source"`
+	Kind        string   `json:"kind"`
+	Identifiers []string `json:"identifiers"`
+	Removed     int      `json:"removed"`
+}
+
+type jsonFileOverlap struct {
+	File        string  `json:"file"`
+	Repeats     string  `json:"repeats"`
+	Overlap     float64 `json:"overlap"`
+	PreviousRun string  `json:"previous_run,omitempty"`
+}
+
+type jsonBalanceGap struct {
+	Bank       string `json:"bank"`
+	Kind       string `json:"kind"`
+	Line       string `json:"line,omitempty"`
+	Expected   string `json:"expected"`
+	Reported   string `json:"reported"`
+	Difference string `json:"difference"`
+}
+
+type jsonCoverageGap struct {
+	Bank        string `json:"bank"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	CoveredFrom string `json:"covered_from"`
+	CoveredTo   string `json:"covered_to"`
+}
+
+type jsonInternalTransfer struct {
+	Debit  jsonBankStatementLine `json:"debit"`
+	Credit jsonBankStatementLine `json:"credit"`
+}
+
+// formatJSONResult writes the result as an indented JSON document
+func formatJSONResult(w io.Writer, result *models.ReconciliationResult, params ReconciliationParams) error {
+	report := jsonReport{
+		Parameters: jsonParameters{
+			SystemFile: params.SystemFile,
+			BankFiles:  params.BankFiles,
+			StartDate:  params.StartDate,
+			EndDate:    params.EndDate,
+		},
+		Summary: jsonSummary{
+			TotalTransactionsProcessed: result.TotalTransactionsProcessed,
+			TotalSystemTransactions:    result.TotalSystemTransactions,
+			TotalBankStatementLines:    result.TotalBankStatementLines,
+			TotalMatchedTransactions:   result.TotalMatchedTransactions,
+			TotalUnmatchedTransactions: result.TotalUnmatchedTransactions,
+			TotalNotYetReconcilable:    len(result.NotYetReconcilable),
+			TotalDiscrepancies:         result.TotalDiscrepancies.StringFixed(2),
+			TotalFees:                  result.TotalFees.StringFixed(2),
+		},
+		Passes:                      []jsonPass{},
+		StrategiesByBank:            result.StrategiesByBank,
+		Matches:                     []jsonMatch{},
+		UnmatchedSystemTransactions: []jsonUnmatchedSystem{},
+		UnmatchedBankStatementLines: []jsonUnmatchedBank{},
+		BatchMatches:                toJSONGroupedMatches(result.BatchMatches),
+		SplitMatches:                toJSONGroupedMatches(result.SplitMatches),
+		Reversals:                   []jsonReversal{},
+		InternalTransfers:           []jsonInternalTransfer{},
+		Duplicates:                  []jsonDuplicate{},
+		RepeatedBankFiles:           []jsonFileOverlap{},
+		BalanceGaps:                 []jsonBalanceGap{},
+		CoverageGaps:                []jsonCoverageGap{},
+		NotYetReconcilable:          []jsonTransaction{},
+	}
+
+	for _, pass := range result.PassSummaries {
+		report.Passes = append(report.Passes, jsonPass{Name: pass.Name, Bank: pass.Bank, Matched: pass.Matched})
+	}
+
+	for _, match := range result.Matches {
+		report.Matches = append(report.Matches, jsonMatch{
+			SystemTransaction:  toJSONTransaction(match.SystemTransaction),
+			BankStatementLine:  toJSONBankStatementLine(match.BankStatementLine),
+			Discrepancy:        match.Discrepancy.StringFixed(2),
+			Fee:                match.Fee.StringFixed(2),
+			ReferenceConfirmed: match.ReferenceConfirmed,
+			Pass:               match.Pass,
+		})
+	}
+
+	for i, trx := range result.UnmatchedSystemTransactions {
+		unmatched := jsonUnmatchedSystem{jsonTransaction: toJSONTransaction(trx)}
+		if i < len(result.SystemNearMisses) {
+			for _, nearMiss := range result.SystemNearMisses[i] {
+				unmatched.NearMisses = append(unmatched.NearMisses, jsonSystemNearMiss{
+					jsonBankStatementLine: toJSONBankStatementLine(nearMiss.BankStatementLine),
+					Reason:                string(nearMiss.Reason),
+				})
+			}
+		}
+		report.UnmatchedSystemTransactions = append(report.UnmatchedSystemTransactions, unmatched)
+	}
+
+	// Banks are sorted so that the output is stable
+	bankNames := make([]string, 0, len(result.UnmatchedBankStatementLines))
+	for bankName := range result.UnmatchedBankStatementLines {
+		bankNames = append(bankNames, bankName)
+	}
+	sort.Strings(bankNames)
+	for _, bankName := range bankNames {
+		nearMisses := result.BankNearMisses[bankName]
+		for i, stmtLine := range result.UnmatchedBankStatementLines[bankName] {
+			unmatched := jsonUnmatchedBank{jsonBankStatementLine: toJSONBankStatementLine(stmtLine)}
+			if i < len(nearMisses) {
+				for _, nearMiss := range nearMisses[i] {
+					unmatched.NearMisses = append(unmatched.NearMisses, jsonBankNearMiss{
+						jsonTransaction: toJSONTransaction(nearMiss.SystemTransaction),
+						Reason:          string(nearMiss.Reason),
+					})
+				}
+			}
+			report.UnmatchedBankStatementLines = append(report.UnmatchedBankStatementLines, unmatched)
+		}
+	}
+
+	for _, reversal := range result.SystemReversals {
+		report.Reversals = append(report.Reversals, jsonReversal{
+			Source:   "system",
+			Original: reversal.Original.TrxID,
+			Reversal: reversal.Reversal.TrxID,
+			Type:     string(reversal.Original.Type),
+			Amount:   reversal.Original.Amount.StringFixed(2),
+		})
+	}
+	for _, reversal := range result.BankReversals {
+		report.Reversals = append(report.Reversals, jsonReversal{
+			Source:   reversal.Original.BankName,
+			Original: reversal.Original.UniqueIdentifier,
+			Reversal: reversal.Reversal.UniqueIdentifier,
+			Type:     string(reversal.Original.Type),
+			Amount:   reversal.Original.GetAbsoluteAmount().StringFixed(2),
+		})
+	}
+
+	for _, transfer := range result.InternalTransfers {
+		report.InternalTransfers = append(report.InternalTransfers, jsonInternalTransfer{
+			Debit:  toJSONBankStatementLine(transfer.Debit),
+			Credit: toJSONBankStatementLine(transfer.Credit),
+		})
+	}
+
+	for _, duplicate := range result.SystemDuplicates {
+		entry := jsonDuplicate{Source: "system", Kind: string(duplicate.Kind), Removed: duplicate.Removed}
+		for _, trx := range duplicate.Transactions {
+			entry.Identifiers = append(entry.Identifiers, trx.TrxID)
+		}
+		report.Duplicates = append(report.Duplicates, entry)
+	}
+	for _, duplicate := range result.BankDuplicates {
+		entry := jsonDuplicate{Source: duplicate.BankStatementLines[0].BankName, Kind: string(duplicate.Kind), Removed: duplicate.Removed}
+		for _, stmtLine := range duplicate.BankStatementLines {
+			entry.Identifiers = append(entry.Identifiers, stmtLine.UniqueIdentifier)
+		}
+		report.Duplicates = append(report.Duplicates, entry)
+	}
+
+	for _, overlap := range result.FileOverlaps {
+		entry := jsonFileOverlap{File: overlap.File.Path, Repeats: overlap.Other.Path, Overlap: overlap.Overlap}
+		if !overlap.PreviousRun.IsZero() {
+			entry.PreviousRun = overlap.PreviousRun.Format(time.RFC3339)
+		}
+		report.RepeatedBankFiles = append(report.RepeatedBankFiles, entry)
+	}
+
+	for _, gap := range result.BalanceGaps {
+		entry := jsonBalanceGap{
+			Bank:       gap.BankName,
+			Kind:       string(gap.Kind),
+			Expected:   gap.Expected.StringFixed(2),
+			Reported:   gap.Actual.StringFixed(2),
+			Difference: gap.Actual.Sub(gap.Expected).StringFixed(2),
+		}
+		if gap.Kind == models.BalanceGapRunning {
+			entry.Line = gap.BankStatementLine.UniqueIdentifier
+		}
+		report.BalanceGaps = append(report.BalanceGaps, entry)
+	}
+
+	for _, gap := range result.CoverageGaps {
+		entry := jsonCoverageGap{Bank: gap.BankName, From: gap.From.Format("2006-01-02"), To: gap.To.Format("2006-01-02")}
+		for _, coverage := range result.BankCoverage {
+			if coverage.BankName == gap.BankName {
+				entry.CoveredFrom = coverage.FirstDate.Format("2006-01-02")
+				entry.CoveredTo = coverage.LastDate.Format("2006-01-02")
+			}
+		}
+		report.CoverageGaps = append(report.CoverageGaps, entry)
+	}
+
+	for _, trx := range result.NotYetReconcilable {
+		report.NotYetReconcilable = append(report.NotYetReconcilable, toJSONTransaction(trx))
+	}
+
+	if params.ShowStats {
+		report.Statistics = toJSONStatistics(result.Statistics)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func toJSONTransaction(trx models.Transaction) jsonTransaction {
+	return jsonTransaction{
+		TrxID:           trx.TrxID,
+		Amount:          trx.Amount.StringFixed(2),
+		Type:            string(trx.Type),
+		TransactionTime: trx.TransactionTime.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func toJSONBankStatementLine(stmtLine models.BankStatementLine) jsonBankStatementLine {
+	return jsonBankStatementLine{
+		UniqueIdentifier: stmtLine.UniqueIdentifier,
+		Bank:             stmtLine.BankName,
+		Amount:           stmtLine.Amount.StringFixed(2),
+		Type:             string(stmtLine.Type),
+		Date:             stmtLine.Date.Format(DEFAULT_DATE_FORMAT),
+	}
+}
+
+func toJSONGroupedMatches(groups []models.GroupedMatch) []jsonGroupedMatch {
+	jsonGroups := []jsonGroupedMatch{}
+	for _, group := range groups {
+		jsonGroup := jsonGroupedMatch{Discrepancy: group.Discrepancy.StringFixed(2)}
+		for _, trx := range group.SystemTransactions {
+			jsonGroup.SystemTransactions = append(jsonGroup.SystemTransactions, toJSONTransaction(trx))
+		}
+		for _, stmtLine := range group.BankStatementLines {
+			jsonGroup.BankStatementLines = append(jsonGroup.BankStatementLines, toJSONBankStatementLine(stmtLine))
+		}
+		jsonGroups = append(jsonGroups, jsonGroup)
+	}
+	return jsonGroups
+}
+
+func toJSONStatistics(stats models.RunStatistics) *jsonStatistics {
+	jsonStats := &jsonStatistics{
+		ParseSecondsByFile: make(map[string]float64, len(stats.ParseDurations)),
+		ParseSeconds:       stats.ParseDuration.Seconds(),
+		FilterSeconds:      stats.FilterDuration.Seconds(),
+		IndexBuildSeconds:  stats.IndexBuildDuration.Seconds(),
+		MatchSeconds:       stats.MatchDuration.Seconds(),
+		TotalSeconds:       stats.TotalDuration.Seconds(),
+		RowsParsed:         stats.RowsParsed,
+		RowsPerSecond:      stats.RowsPerSecond,
+		PeakHeapBytes:      stats.PeakHeapBytes,
+		IndexKeys:          stats.IndexKeys,
+		IndexCollisions:    stats.IndexCollisions,
+	}
+	for filePath, elapsed := range stats.ParseDurations {
+		jsonStats.ParseSecondsByFile[filePath] = elapsed.Seconds()
+	}
+	return jsonStats
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/models"
+)
+
+func TestFormatJSONResult(t *testing.T) {
+	result := &models.ReconciliationResult{
+		TotalMatchedTransactions: 2,
+		Matches: []models.MatchedTransaction{
+			{
+				SystemTransaction: models.Transaction{TrxID: "TRX001", Amount: decimal.NewFromInt(100000), Type: models.TransactionTypeCredit},
+				BankStatementLine: models.BankStatementLine{UniqueIdentifier: "BCA-001", BankName: "bank_bca", Amount: decimal.NewFromInt(99300), Type: models.TransactionTypeCredit},
+				Fee:               decimal.NewFromInt(700),
+				Discrepancy:       decimal.Zero,
+				Pass:              "fee",
+			},
+			{
+				SystemTransaction:  models.Transaction{TrxID: "TRX002", Amount: decimal.NewFromInt(50000), Type: models.TransactionTypeCredit},
+				BankStatementLine:  models.BankStatementLine{UniqueIdentifier: "BCA-002", BankName: "bank_bca", Amount: decimal.NewFromInt(50000), Type: models.TransactionTypeCredit},
+				ReferenceConfirmed: true,
+				Pass:               "reference",
+			},
+		},
+		Statistics: models.RunStatistics{
+			ParseDurations: map[string]time.Duration{"bank_bca.csv": 500 * time.Millisecond},
+			ParseDuration:  time.Second,
+			RowsParsed:     4,
+			RowsPerSecond:  4,
+			PeakHeapBytes:  1024,
+		},
+	}
+
+	tests := []struct {
+		name          string
+		showStats     bool
+		expectedStats bool
+	}{
+		{name: "without stats", showStats: false},
+		{name: "with stats", showStats: true, expectedStats: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := formatJSONResult(&buf, result, ReconciliationParams{ShowStats: tt.showStats}); err != nil {
+				t.Fatalf("Failed to format JSON: %v", err)
+			}
+
+			var report jsonReport
+			if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+				t.Fatalf("Failed to decode JSON: %v", err)
+			}
+
+			if len(report.Matches) != 2 {
+				t.Fatalf("Expected 2 matches, got %d", len(report.Matches))
+			}
+			feeMatch := report.Matches[0]
+			if feeMatch.SystemTransaction.TrxID != "TRX001" || feeMatch.BankStatementLine.UniqueIdentifier != "BCA-001" || feeMatch.Fee != "700.00" || feeMatch.Pass != "fee" {
+				t.Errorf("Expected TRX001 matched with BCA-001 by fee with a fee of 700.00, got %+v", feeMatch)
+			}
+			if referenceMatch := report.Matches[1]; !referenceMatch.ReferenceConfirmed || referenceMatch.Fee != "0.00" {
+				t.Errorf("Expected TRX002 confirmed by reference without a fee, got %+v", referenceMatch)
+			}
+
+			if !tt.expectedStats {
+				if report.Statistics != nil {
+					t.Errorf("Expected no statistics without -stats, got %+v", report.Statistics)
+				}
+				return
+			}
+			if report.Statistics == nil {
+				t.Fatal("Expected statistics with -stats")
+			}
+			if report.Statistics.ParseSeconds != 1 || report.Statistics.ParseSecondsByFile["bank_bca.csv"] != 0.5 || report.Statistics.RowsParsed != 4 || report.Statistics.PeakHeapBytes != 1024 {
+				t.Errorf("Expected the run statistics in seconds, got %+v", report.Statistics)
+			}
+		})
+	}
+}
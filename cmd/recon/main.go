@@ -6,6 +6,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"sort"
 	"strings"
 	"time"
 	_ "time/tzdata"
@@ -18,6 +19,9 @@ import (
 
 const (
 	DEFAULT_DATE_FORMAT = "2006-01-02"
+
+	FORMAT_TEXT = "text"
+	FORMAT_JSON = "json"
 )
 
 type ReconciliationParams struct {
@@ -26,22 +30,75 @@ type ReconciliationParams struct {
 	StartDate  string
 	EndDate    string
 	OutputFile string
+	ShowStats  bool
+	Assignment string
+	Strategy   string
+	Passes     string
+	ConfigFile string
+	Where      string
+
+	StrictAmounts bool
+
+	BatchMatching  bool
+	SplitMatching  bool
+	GroupWindow    int
+	GroupTolerance string
+	GroupMaxSize   int
+
+	DetectReversals bool
+	ReversalWindow  int
+	DetectTransfers bool
+	TransferWindow  int
+
+	NearestTime bool
+	MaxTimeGap  time.Duration
+
+	Duplicates    string
+	CheckBalances bool
+	FileCheck     string
+	HistoryFile   string
+
+	Coverage           string
+	CoverageSettlement int
+
+	Suggestions         int
+	SuggestionWindow    int
+	SuggestionTolerance string
+	Format              string
+
+	ReferencePatterns stringList
+	BankPasses        stringList
+	StatementFiles    stringList
 }
 
 func main() {
+	// Dispatch subcommands before parsing the reconciliation flags
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "generate":
+			runGenerate(os.Args[2:])
+			return
+		case "explain":
+			runExplain(os.Args[2:])
+			return
+		}
+	}
+
 	// Define CLI flags
-	var (
-		fSystemFile = flag.String("system", "", "Path to system transactions CSV file (required)")
-		fBankFiles  = flag.String("banks", "", "Comma-separated paths to bank statement CSV files (required)")
-		fStartDate  = flag.String("start", "", "Start date for reconciliation (YYYY-MM-DD)in UTC+7 (required)")
-		fEndDate    = flag.String("end", "", "End date for reconciliation (YYYY-MM-DD) in UTC+7 (optional, defaults to start date)")
-		fOutputFile = flag.String("output", "", "Path to output file, only support txt at the moment. (optional)")
-	)
+	params := bindReconciliationFlags(flag.CommandLine)
+	fOutputFile := flag.String("output", "", "Path to output file, only support txt at the moment. (optional)")
+	fShowStats := flag.Bool("stats", false, "Include per-phase timing and memory statistics in the report (optional)")
+	fSuggestions := flag.Int("suggestions", 0, "Number of near miss counterparts suggested per unmatched item, 0 disables suggestions (optional)")
+	fSuggestionWindow := flag.Int("suggestion-window", 3, "Maximum date difference in days for same amount suggestions (optional, used with -suggestions)")
+	fSuggestionTolerance := flag.String("suggestion-tolerance", "1000", "Maximum amount difference for same date suggestions (optional, used with -suggestions)")
+	fFormat := flag.String("format", FORMAT_TEXT, "Report format: text or json (optional)")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Reconciliation Service\n\n")
 		fmt.Fprintf(os.Stderr, "Usage:\n")
-		fmt.Fprintf(os.Stderr, "  %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s generate [options]    Generate synthetic test data\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s explain [options]     Explain how a system transaction was matched\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nExample:\n")
@@ -50,105 +107,346 @@ func main() {
 
 	flag.Parse()
 
-	params := ReconciliationParams{
-		SystemFile: *fSystemFile,
-		BankFiles:  *fBankFiles,
-		StartDate:  *fStartDate,
-		EndDate:    *fEndDate,
-		OutputFile: *fOutputFile,
-	}
+	params.OutputFile = *fOutputFile
+	params.ShowStats = *fShowStats
+	params.Suggestions = *fSuggestions
+	params.SuggestionWindow = *fSuggestionWindow
+	params.SuggestionTolerance = *fSuggestionTolerance
+	params.Format = *fFormat
+
 	// Validate required flags
 	if params.SystemFile == "" || params.BankFiles == "" || params.StartDate == "" {
 		flag.Usage()
 		os.Exit(1)
 	}
 
-	// Load timezone for parsing (use UTC+7 to match parser behavior)
-	loc, err := time.LoadLocation("Asia/Jakarta")
-	if err != nil {
-		loc = time.FixedZone("UTC+7", 7*60*60)
+	if params.Format != FORMAT_TEXT && params.Format != FORMAT_JSON {
+		log.Fatalf("Invalid format: %s. Expected %s or %s", params.Format, FORMAT_TEXT, FORMAT_JSON)
 	}
 
-	// Parse dates
-	start, err := time.ParseInLocation(DEFAULT_DATE_FORMAT, params.StartDate, loc)
+	input, err := buildReconciliationInput(params)
 	if err != nil {
-		log.Fatalf("Invalid start date format: %v. Expected format: YYYY-MM-DD", err)
+		log.Fatalf("Failed to prepare reconciliation: %v", err)
 	}
-
-	var end time.Time
-	if params.EndDate != "" {
-		end, err = time.ParseInLocation(DEFAULT_DATE_FORMAT, params.EndDate, loc)
-		if err != nil {
-			log.Fatalf("Invalid end date format: %v. Expected format: YYYY-MM-DD", err)
-		}
-		// Set end date to end of day
-		end = end.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
-
-		// Validate date range
-		if start.After(end) {
-			log.Fatalf("Start date must not be after end date")
+	input.OutputFile = params.OutputFile
+	input.ProgressReporter = newProgressReporter() // nil when stderr is not a terminal
+	input.CollectStatistics = params.ShowStats
+
+	if params.Suggestions > 0 {
+		tolerance, err := decimal.NewFromString(params.SuggestionTolerance)
+		if err != nil || tolerance.IsNegative() || params.SuggestionWindow < 0 {
+			closeMatchPipelines(input)
+			log.Fatalf("Invalid suggestion options: window %d, tolerance %q. Expected days >= 0 and an amount >= 0", params.SuggestionWindow, params.SuggestionTolerance)
 		}
-	} else {
-		// If no end date provided, set to end of start day
-		end = start.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+		input.NearMisses = &service.NearMissConfig{Limit: params.Suggestions, WindowDays: params.SuggestionWindow, Tolerance: tolerance}
 	}
 
-	// Split bank files
-	bankFileList := strings.Split(params.BankFiles, ",")
-	for i, v := range bankFileList {
-		bankFileList[i] = strings.TrimSpace(v)
-	}
-
-	// Validate files exist
-	if err := validateFileExists(params.SystemFile); err != nil {
-		log.Fatalf("System transaction file error: %v", err)
-	}
-	for _, bankFile := range bankFileList {
-		if err := validateFileExists(bankFile); err != nil {
-			log.Fatalf("Bank statement file error: %v", err)
-		}
+	// Status messages go to stderr when stdout carries the JSON report
+	var status io.Writer = os.Stdout
+	if params.Format == FORMAT_JSON {
+		status = os.Stderr
 	}
 
 	// Run reconciliation
-	fmt.Println("Starting reconciliation process...")
+	fmt.Fprintln(status, "Starting reconciliation process...")
 	startTime := time.Now()
 	reconService := service.NewReconciliationService()
 
-	input := service.ReconciliationInput{
-		SystemTransactionFile: params.SystemFile,
-		BankStatementFiles:    bankFileList,
-		StartDate:             start,
-		EndDate:               end,
-		OutputFile:            params.OutputFile,
-		MatchStrategy:         service.NewExactMatchStrategy(),
-	}
-
 	result, err := reconService.Reconcile(input)
+	closeMatchPipelines(input)
 	if err != nil {
 		log.Fatalf("Reconciliation failed: %v", err)
 	}
 
+	// Remember the bank files so that feeding them again in a later run is caught
+	if input.FileCheck != nil && input.FileCheck.History != nil {
+		if err := input.FileCheck.History.Record(time.Now(), result.FileFingerprints); err != nil {
+			log.Fatalf("Failed to update run history: %v", err)
+		}
+	}
+
 	// Print results
-	printResult(result, params)
+	printResult(result, *params)
 
 	// Save to output file if specified
 	if params.OutputFile != "" {
-		if err := writeResultToFile(result, params.OutputFile, params); err != nil {
+		if err := writeResultToFile(result, params.OutputFile, *params); err != nil {
 			log.Fatalf("Failed to write output file: %v", err)
 		}
-		fmt.Printf("\nResults saved to: %s\n", params.OutputFile)
+		fmt.Fprintf(status, "\nResults saved to: %s\n", params.OutputFile)
 	}
 
 	// Exit with additional info
 	elapsed := time.Since(startTime)
 	if result.TotalUnmatchedTransactions > 0 || result.TotalDiscrepancies.GreaterThan(decimal.Zero) {
-		fmt.Printf("\nReconciliation completed successfully - There are UNMATCHED transactions or discrepancies. (Processing Time: %v)\n", elapsed)
+		fmt.Fprintf(status, "\nReconciliation completed successfully - There are UNMATCHED transactions or discrepancies. (Processing Time: %v)\n", elapsed)
 	} else {
-		fmt.Printf("\nReconciliation completed successfully - All transactions MATCHED! (Processing Time: %v)\n", elapsed)
+		fmt.Fprintf(status, "\nReconciliation completed successfully - All transactions MATCHED! (Processing Time: %v)\n", elapsed)
 	}
 	os.Exit(0)
 }
 
+// bindReconciliationFlags defines the flags that select the input files and the matching
+// behaviour, shared by the reconciliation and the explain subcommand
+func bindReconciliationFlags(fs *flag.FlagSet) *ReconciliationParams {
+	params := &ReconciliationParams{}
+
+	fs.StringVar(&params.SystemFile, "system", "", "Path to system transactions CSV file (required)")
+	fs.StringVar(&params.BankFiles, "banks", "", "Comma-separated paths to bank statement CSV files (required)")
+	fs.StringVar(&params.StartDate, "start", "", "Start date for reconciliation (YYYY-MM-DD)in UTC+7 (required)")
+	fs.StringVar(&params.EndDate, "end", "", "End date for reconciliation (YYYY-MM-DD) in UTC+7 (optional, defaults to start date)")
+	fs.StringVar(&params.Assignment, "assignment", string(service.AssignmentGreedy), "Candidate assignment: greedy (first candidate in file order) or optimal (best score per key) (optional)")
+	fs.StringVar(&params.Strategy, "strategy", STRATEGY_EXACT, "Match strategy: exact (type, amount and date) or reference (TrxID found in bank identifier/description, then exact); window, tolerance, fee, counterparty and plugin matching run through -passes (optional)")
+	fs.StringVar(&params.Passes, "passes", "", "Comma-separated match passes run in order on the remaining unmatched items, e.g. reference,exact,window:1,tolerance:500; overrides -strategy (optional)")
+	fs.StringVar(&params.ConfigFile, "config", "", "Path to JSON config file with passes, rules, reference_patterns and fee_rules; flags take precedence (optional)")
+
+	fs.BoolVar(&params.BatchMatching, "batch", false, "Match bank lines against groups of system transactions whose amounts add up to them, e.g. daily settlements (optional)")
+	fs.BoolVar(&params.SplitMatching, "split", false, "Match system transactions against groups of bank lines from one bank whose amounts add up to them, e.g. payouts split by transfer limits (optional)")
+	fs.IntVar(&params.GroupWindow, "group-window", 0, "Days bank lines may be booked after the system transactions they are grouped with (optional, used with -batch and -split)")
+	fs.StringVar(&params.GroupTolerance, "group-tolerance", "0", "Maximum difference between a group total and the single item it matches (optional, used with -batch and -split)")
+	fs.IntVar(&params.GroupMaxSize, "group-max-size", 0, "Maximum number of items in a group (optional, used with -batch and -split, defaults to 30)")
+
+	fs.BoolVar(&params.DetectReversals, "reversals", false, "Before matching, exclude entries offset by an equal entry of the opposite type in the same source when neither has a possible counterpart on the other side (optional)")
+	fs.IntVar(&params.ReversalWindow, "reversal-window", 0, "Days a reversal may be booked after the original entry (optional, used with -reversals)")
+	fs.BoolVar(&params.DetectTransfers, "transfers", false, "Pair unmatched debits and credits of equal amount in different banks as internal transfers (optional)")
+	fs.IntVar(&params.TransferWindow, "transfer-window", 0, "Days the credit may be booked after the debit (optional, used with -transfers)")
+
+	fs.BoolVar(&params.StrictAmounts, "strict-amounts", false, "Reject amounts with more than 2 decimal places or 15 integer digits and negative system amounts (optional)")
+	fs.StringVar(&params.Duplicates, "duplicates", "", "Detect repeated rows within each file: warn (report only), dedupe (drop exact repeats) or reject (fail on repeated identifiers) (optional)")
+
+	fs.BoolVar(&params.CheckBalances, "balances", false, "Check that bank balances add up: running balances from the balance column and opening/closing balances from the config file or -statement (optional, on when balances or statements are given)")
+	fs.Var(&params.StatementFiles, "statement", "MT940 or camt.053 statement of one bank as bank=path, its opening and closing balances are checked by -balances; repeatable (optional)")
+	fs.StringVar(&params.FileCheck, "file-check", "", "Detect bank files repeating another input or a file of a previous run: warn or reject (optional)")
+	fs.StringVar(&params.HistoryFile, "history", "", "Path to the JSON run history used by -file-check to recognise files processed before, updated after each run; implies -file-check=warn (optional)")
+	fs.StringVar(&params.Coverage, "coverage", "", "Compare the dates covered by each bank file with the date range: warn (report gaps) or mark (also list uncovered unmatched system transactions as not yet reconcilable) (optional)")
+	fs.IntVar(&params.CoverageSettlement, "coverage-settlement", 0, "Days after a transaction its bank line may be booked, a bank file must cover them for the transaction to count as unmatched (optional, used with -coverage)")
+
+	fs.BoolVar(&params.NearestTime, "nearest-time", false, "Prefer the candidate bank line booked closest to the transaction time over file order; needs bank files with a time (optional)")
+	fs.DurationVar(&params.MaxTimeGap, "max-time-gap", 0, "Reject bank lines with a time further from the transaction than this, e.g. 2h; implies -nearest-time (optional)")
+
+	fs.StringVar(&params.Where, "where", "", "Condition every pair matched by a pass must satisfy, e.g. 'startsWith(bank.id, \"QR\") && sys.amount > 1000000' (optional)")
+	fs.Var(&params.BankPasses, "bank-passes", "Match passes for the lines of one bank as bank=passes, e.g. bank_va=exact,window:1; repeatable, other banks use -passes or -strategy (optional)")
+	fs.Var(&params.ReferencePatterns, "reference-pattern", "Regex extracting references from bank identifier/description, repeatable; the first capture group is used when present (optional, reference strategy only)")
+
+	return params
+}
+
+// hasBankPasses reports whether some banks were matched by their own pipeline
+func hasBankPasses(result *models.ReconciliationResult) bool {
+	for _, pass := range result.PassSummaries {
+		if pass.Bank != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// buildReconciliationInput validates the parameters and creates the reconciliation input.
+// Values from the config file are applied to params where no flag was given.
+func buildReconciliationInput(params *ReconciliationParams) (_ service.ReconciliationInput, err error) {
+	assignmentMode := service.AssignmentMode(params.Assignment)
+	if assignmentMode != service.AssignmentGreedy && assignmentMode != service.AssignmentOptimal {
+		return service.ReconciliationInput{}, fmt.Errorf("invalid assignment mode: %s, expected greedy or optimal", params.Assignment)
+	}
+
+	// Load the config file, flags take precedence over its values
+	cfg := &Config{}
+	if params.ConfigFile != "" {
+		loaded, err := loadConfig(params.ConfigFile)
+		if err != nil {
+			return service.ReconciliationInput{}, fmt.Errorf("config error: %w", err)
+		}
+		cfg = loaded
+	}
+	if len(params.ReferencePatterns) == 0 {
+		params.ReferencePatterns = cfg.ReferencePatterns
+	}
+	if len(cfg.Passes) > 0 && len(cfg.Rules) > 0 {
+		return service.ReconciliationInput{}, fmt.Errorf("config error: passes and rules cannot both be configured")
+	}
+	if params.Passes != "" && len(cfg.Rules) > 0 {
+		return service.ReconciliationInput{}, fmt.Errorf("-passes cannot be combined with the rules of the config file")
+	}
+	passSpecs := cfg.Passes
+	if params.Passes != "" {
+		passSpecs = strings.Split(params.Passes, ",")
+	}
+
+	plugins, err := cfg.plugins()
+	if err != nil {
+		return service.ReconciliationInput{}, fmt.Errorf("config error: %w", err)
+	}
+	options := passOptions{
+		referencePatterns: params.ReferencePatterns,
+		feeRules:          cfg.feeRules(),
+		plugins:           plugins,
+	}
+
+	bankPassSpecs, err := parseBankPasses(cfg.BankPasses, params.BankPasses)
+	if err != nil {
+		return service.ReconciliationInput{}, err
+	}
+
+	// Plugin processes are started with the pipelines, stop them when the input cannot be used
+	input := service.ReconciliationInput{AssignmentMode: assignmentMode, StrictAmounts: params.StrictAmounts}
+	defer func() {
+		if err != nil {
+			closeMatchPipelines(input)
+		}
+	}()
+	for bank, specs := range bankPassSpecs {
+		pipeline, err := buildMatchPipeline(specs, options)
+		if err != nil {
+			return service.ReconciliationInput{}, fmt.Errorf("invalid match passes for bank %s: %w", bank, err)
+		}
+		if input.BankStrategies == nil {
+			input.BankStrategies = make(map[string]service.MatchPipeline)
+		}
+		input.BankStrategies[bank] = pipeline
+	}
+
+	if len(passSpecs) == 0 && len(cfg.Rules) > 0 {
+		input.MatchPipeline, err = service.CompileMatchRules(cfg.matchRules())
+		if err != nil {
+			return service.ReconciliationInput{}, fmt.Errorf("invalid match rules: %s", describeConditionError(err))
+		}
+	} else if len(passSpecs) > 0 {
+		input.MatchPipeline, err = buildMatchPipeline(passSpecs, options)
+		if err != nil {
+			return service.ReconciliationInput{}, fmt.Errorf("invalid match passes: %w", err)
+		}
+	} else {
+		input.MatchStrategy, err = buildMatchStrategy(*params)
+		if err != nil {
+			return service.ReconciliationInput{}, fmt.Errorf("invalid match strategy: %w", err)
+		}
+	}
+
+	if params.BatchMatching || params.SplitMatching {
+		groupMatching, err := buildGroupMatchConfig(*params)
+		if err != nil {
+			return service.ReconciliationInput{}, fmt.Errorf("invalid group matching options: %w", err)
+		}
+		if params.BatchMatching {
+			input.BatchMatching = groupMatching
+		}
+		if params.SplitMatching {
+			input.SplitMatching = groupMatching
+		}
+	}
+
+	if params.DetectReversals {
+		if params.ReversalWindow < 0 {
+			return service.ReconciliationInput{}, fmt.Errorf("invalid reversal window: %d, expected days >= 0", params.ReversalWindow)
+		}
+		input.ReversalDetection = &service.ReversalConfig{WindowDays: params.ReversalWindow}
+	}
+
+	if params.Where != "" {
+		input.MatchCondition, err = service.NewMatchCondition(params.Where)
+		if err != nil {
+			return service.ReconciliationInput{}, fmt.Errorf("invalid -where condition: %s", describeConditionError(err))
+		}
+	}
+
+	if params.DetectTransfers {
+		if params.TransferWindow < 0 {
+			return service.ReconciliationInput{}, fmt.Errorf("invalid transfer window: %d, expected days >= 0", params.TransferWindow)
+		}
+		input.TransferDetection = &service.TransferConfig{WindowDays: params.TransferWindow}
+	}
+
+	if params.Duplicates != "" {
+		input.DuplicateDetection = &service.DuplicateConfig{Policy: service.DuplicatePolicy(params.Duplicates)}
+	}
+
+	statementFiles, err := parseStatementFiles(cfg.StatementFiles, params.StatementFiles)
+	if err != nil {
+		return service.ReconciliationInput{}, err
+	}
+	if params.CheckBalances || len(cfg.Balances) > 0 || len(statementFiles) > 0 {
+		statements, err := cfg.statementBalances(statementFiles)
+		if err != nil {
+			return service.ReconciliationInput{}, err
+		}
+		input.BalanceCheck = &service.BalanceCheckConfig{Statements: statements}
+	}
+
+	if params.FileCheck != "" || params.HistoryFile != "" {
+		policy := service.DuplicatePolicy(params.FileCheck)
+		if params.FileCheck == "" {
+			policy = service.DuplicateWarn
+		}
+		input.FileCheck = &service.FileCheckConfig{Policy: policy}
+		if params.HistoryFile != "" {
+			input.FileCheck.History, err = service.OpenRunHistory(params.HistoryFile)
+			if err != nil {
+				return service.ReconciliationInput{}, fmt.Errorf("history error: %w", err)
+			}
+		}
+	}
+
+	if params.Coverage != "" {
+		input.CoverageCheck = &service.CoverageConfig{Policy: service.CoveragePolicy(params.Coverage), SettlementDays: params.CoverageSettlement}
+	}
+
+	if params.NearestTime || params.MaxTimeGap != 0 {
+		if params.MaxTimeGap < 0 {
+			return service.ReconciliationInput{}, fmt.Errorf("invalid maximum time gap: %s, expected a duration >= 0", params.MaxTimeGap)
+		}
+		input.TimeProximity = &service.TimeProximityConfig{MaxGap: params.MaxTimeGap}
+	}
+
+	// Load timezone for parsing (use UTC+7 to match parser behavior)
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		loc = time.FixedZone("UTC+7", 7*60*60)
+	}
+
+	// Parse dates
+	input.StartDate, err = time.ParseInLocation(DEFAULT_DATE_FORMAT, params.StartDate, loc)
+	if err != nil {
+		return service.ReconciliationInput{}, fmt.Errorf("invalid start date format: %v, expected format YYYY-MM-DD", err)
+	}
+
+	if params.EndDate != "" {
+		input.EndDate, err = time.ParseInLocation(DEFAULT_DATE_FORMAT, params.EndDate, loc)
+		if err != nil {
+			return service.ReconciliationInput{}, fmt.Errorf("invalid end date format: %v, expected format YYYY-MM-DD", err)
+		}
+		// Set end date to end of day
+		input.EndDate = input.EndDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+	} else {
+		// If no end date provided, set to end of start day
+		input.EndDate = input.StartDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+	}
+
+	// Validate the date range and the policies the way the reconciliation does
+	if err := input.Validate(); err != nil {
+		return service.ReconciliationInput{}, err
+	}
+
+	// Split bank files
+	bankFileList := strings.Split(params.BankFiles, ",")
+	for i, v := range bankFileList {
+		bankFileList[i] = strings.TrimSpace(v)
+	}
+
+	// Validate files exist
+	if err := validateFileExists(params.SystemFile); err != nil {
+		return service.ReconciliationInput{}, fmt.Errorf("system transaction file error: %w", err)
+	}
+	for _, bankFile := range bankFileList {
+		if err := validateFileExists(bankFile); err != nil {
+			return service.ReconciliationInput{}, fmt.Errorf("bank statement file error: %w", err)
+		}
+	}
+	input.SystemTransactionFile = params.SystemFile
+	input.BankStatementFiles = bankFileList
+
+	return input, nil
+}
+
 func validateFileExists(filePath string) error {
 	info, err := os.Stat(filePath)
 	if os.IsNotExist(err) {
@@ -164,7 +462,9 @@ func validateFileExists(filePath string) error {
 }
 
 func printResult(result *models.ReconciliationResult, params ReconciliationParams) {
-	formatResult(os.Stdout, result, params)
+	if err := writeResult(os.Stdout, result, params); err != nil {
+		log.Fatalf("Failed to print result: %v", err)
+	}
 }
 
 func writeResultToFile(result *models.ReconciliationResult, filepath string, params ReconciliationParams) error {
@@ -174,7 +474,18 @@ func writeResultToFile(result *models.ReconciliationResult, filepath string, par
 	}
 	defer file.Close()
 
-	formatResult(file, result, params)
+	if err := writeResult(file, result, params); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// writeResult writes the report in the format selected with -format
+func writeResult(w io.Writer, result *models.ReconciliationResult, params ReconciliationParams) error {
+	if params.Format == FORMAT_JSON {
+		return formatJSONResult(w, result, params)
+	}
+	formatResult(w, result, params)
 	return nil
 }
 
@@ -192,7 +503,186 @@ func formatResult(w io.Writer, result *models.ReconciliationResult, params Recon
 	fmt.Fprintf(w, "  Total Transactions Processed: %d (System: %d | Bank: %d)\n", result.TotalTransactionsProcessed, result.TotalSystemTransactions, result.TotalBankStatementLines)
 	fmt.Fprintf(w, "  Total Matched Transactions: %d pairs\n", result.TotalMatchedTransactions)
 	fmt.Fprintf(w, "  Total Unmatched Transactions: %d\n", result.TotalUnmatchedTransactions)
+	if len(result.NotYetReconcilable) > 0 {
+		fmt.Fprintf(w, "  Total Not Yet Reconcilable: %d\n", len(result.NotYetReconcilable))
+	}
 	fmt.Fprintf(w, "  Total Discrepancies (Amount): Rp. %s\n", result.TotalDiscrepancies)
+	if !result.TotalFees.IsZero() {
+		fmt.Fprintf(w, "  Total Bank Fees: Rp. %s\n", result.TotalFees)
+	}
+	if len(result.PassSummaries) > 1 {
+		fmt.Fprintln(w, "  Matches per Pass:")
+		for i, pass := range result.PassSummaries {
+			name := pass.Name
+			if pass.Bank != "" {
+				name = fmt.Sprintf("%s (%s)", pass.Name, pass.Bank)
+			}
+			fmt.Fprintf(w, "    %d. %-30s %d pairs\n", i+1, name, pass.Matched)
+		}
+	}
+	if hasBankPasses(result) {
+		fmt.Fprintln(w, "  Strategy per Bank:")
+		banks := make([]string, 0, len(result.StrategiesByBank))
+		for bank := range result.StrategiesByBank {
+			banks = append(banks, bank)
+		}
+		sort.Strings(banks)
+		for _, bank := range banks {
+			passes := strings.Join(result.StrategiesByBank[bank], ", ")
+			if passes == "" {
+				passes = "none, not matched"
+			}
+			fmt.Fprintf(w, "    %-33s %s\n", bank, passes)
+		}
+	}
+
+	// Write matches confirmed by a reference in the bank statement line
+	var referenceMatches []models.MatchedTransaction
+	for _, match := range result.Matches {
+		if match.ReferenceConfirmed {
+			referenceMatches = append(referenceMatches, match)
+		}
+	}
+	if len(referenceMatches) > 0 {
+		fmt.Fprintln(w, "\n"+strings.Repeat("-", 80))
+		fmt.Fprintf(w, "REFERENCE-CONFIRMED MATCHES: %d\n", len(referenceMatches))
+		fmt.Fprintln(w, strings.Repeat("-", 80))
+		fmt.Fprintf(w, "%-20s %-20s %-15s %20s %20s\n", "TrxID", "Unique Identifier", "Bank", "Amount", "Discrepancy")
+		for _, match := range referenceMatches {
+			fmt.Fprintf(w, "%-20s %-20s %-15s %20s %20s\n", match.SystemTransaction.TrxID, match.BankStatementLine.UniqueIdentifier, match.BankStatementLine.BankName, fmt.Sprintf("Rp. %v", match.SystemTransaction.Amount.StringFixed(2)), fmt.Sprintf("Rp. %v", match.Discrepancy.StringFixed(2)))
+		}
+	}
+
+	// Write matches whose amount difference is explained by a bank fee
+	var feeMatches []models.MatchedTransaction
+	for _, match := range result.Matches {
+		if !match.Fee.IsZero() {
+			feeMatches = append(feeMatches, match)
+		}
+	}
+	if len(feeMatches) > 0 {
+		fmt.Fprintln(w, "\n"+strings.Repeat("-", 80))
+		fmt.Fprintf(w, "FEE-ADJUSTED MATCHES: %d\n", len(feeMatches))
+		fmt.Fprintln(w, strings.Repeat("-", 80))
+		fmt.Fprintf(w, "%-20s %-20s %-15s %20s %20s\n", "TrxID", "Unique Identifier", "Bank", "Gross Amount", "Fee")
+		for _, match := range feeMatches {
+			fmt.Fprintf(w, "%-20s %-20s %-15s %20s %20s\n", match.SystemTransaction.TrxID, match.BankStatementLine.UniqueIdentifier, match.BankStatementLine.BankName, fmt.Sprintf("Rp. %v", match.SystemTransaction.Amount.StringFixed(2)), fmt.Sprintf("Rp. %v", match.Fee.StringFixed(2)))
+		}
+	}
+
+	// Write bank lines settling several system transactions
+	if len(result.BatchMatches) > 0 {
+		fmt.Fprintln(w, "\n"+strings.Repeat("-", 80))
+		fmt.Fprintf(w, "BATCH MATCHES: %d\n", len(result.BatchMatches))
+		fmt.Fprintln(w, strings.Repeat("-", 80))
+		for _, batch := range result.BatchMatches {
+			formatGroupedMatch(w, batch)
+		}
+	}
+
+	// Write system transactions executed as several bank statement lines
+	if len(result.SplitMatches) > 0 {
+		fmt.Fprintln(w, "\n"+strings.Repeat("-", 80))
+		fmt.Fprintf(w, "SPLIT MATCHES: %d\n", len(result.SplitMatches))
+		fmt.Fprintln(w, strings.Repeat("-", 80))
+		for _, split := range result.SplitMatches {
+			formatGroupedMatch(w, split)
+		}
+	}
+
+	// Write offsetting entries excluded from matching
+	if len(result.SystemReversals) > 0 || len(result.BankReversals) > 0 {
+		fmt.Fprintln(w, "\n"+strings.Repeat("-", 80))
+		fmt.Fprintf(w, "REVERSALS: %d\n", len(result.SystemReversals)+len(result.BankReversals))
+		fmt.Fprintln(w, strings.Repeat("-", 80))
+		fmt.Fprintf(w, "%-15s %-20s %-20s %-10s %20s\n", "Source", "Original", "Reversal", "Type", "Amount")
+		for _, reversal := range result.SystemReversals {
+			fmt.Fprintf(w, "%-15s %-20s %-20s %-10s %20s\n", "system", reversal.Original.TrxID, reversal.Reversal.TrxID, reversal.Original.Type, fmt.Sprintf("Rp. %v", reversal.Original.Amount.StringFixed(2)))
+		}
+		for _, reversal := range result.BankReversals {
+			fmt.Fprintf(w, "%-15s %-20s %-20s %-10s %20s\n", reversal.Original.BankName, reversal.Original.UniqueIdentifier, reversal.Reversal.UniqueIdentifier, reversal.Original.Type, fmt.Sprintf("Rp. %v", reversal.Original.GetAbsoluteAmount().StringFixed(2)))
+		}
+	}
+
+	// Write balances that do not add up, lines are likely missing from the statement
+	if len(result.BalanceGaps) > 0 {
+		fmt.Fprintln(w, "\n"+strings.Repeat("-", 80))
+		fmt.Fprintf(w, "BALANCE GAPS: %d\n", len(result.BalanceGaps))
+		fmt.Fprintln(w, strings.Repeat("-", 80))
+		fmt.Fprintf(w, "%-15s %-16s %-20s %20s %20s %20s\n", "Bank", "Kind", "At Line", "Expected", "Reported", "Difference")
+		for _, gap := range result.BalanceGaps {
+			line := "-"
+			if gap.Kind == models.BalanceGapRunning {
+				line = gap.BankStatementLine.UniqueIdentifier
+			}
+			fmt.Fprintf(w, "%-15s %-16s %-20s %20s %20s %20s\n", gap.BankName, gap.Kind, line, fmt.Sprintf("Rp. %v", gap.Expected.StringFixed(2)), fmt.Sprintf("Rp. %v", gap.Actual.StringFixed(2)), fmt.Sprintf("Rp. %v", gap.Actual.Sub(gap.Expected).StringFixed(2)))
+		}
+	}
+
+	// Write parts of the date range a bank file does not cover
+	if len(result.CoverageGaps) > 0 {
+		fmt.Fprintln(w, "\n"+strings.Repeat("-", 80))
+		fmt.Fprintf(w, "COVERAGE GAPS: %d\n", len(result.CoverageGaps))
+		fmt.Fprintln(w, strings.Repeat("-", 80))
+		fmt.Fprintf(w, "%-15s %-12s %-12s %s\n", "Bank", "From", "To", "Bank File Covers")
+		for _, gap := range result.CoverageGaps {
+			covers := "-"
+			for _, coverage := range result.BankCoverage {
+				if coverage.BankName == gap.BankName {
+					covers = fmt.Sprintf("%s to %s", coverage.FirstDate.Format("2006-01-02"), coverage.LastDate.Format("2006-01-02"))
+				}
+			}
+			fmt.Fprintf(w, "%-15s %-12s %-12s %s\n", gap.BankName, gap.From.Format("2006-01-02"), gap.To.Format("2006-01-02"), covers)
+		}
+	}
+
+	// Write bank files that would be counted twice
+	if len(result.FileOverlaps) > 0 {
+		fmt.Fprintln(w, "\n"+strings.Repeat("-", 80))
+		fmt.Fprintf(w, "REPEATED BANK FILES: %d\n", len(result.FileOverlaps))
+		fmt.Fprintln(w, strings.Repeat("-", 80))
+		fmt.Fprintf(w, "%-30s %-30s %8s  %s\n", "File", "Repeats", "Overlap", "Previous Run")
+		for _, overlap := range result.FileOverlaps {
+			previousRun := "-"
+			if !overlap.PreviousRun.IsZero() {
+				previousRun = overlap.PreviousRun.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%-30s %-30s %7.0f%%  %s\n", overlap.File.Path, overlap.Other.Path, overlap.Overlap*100, previousRun)
+		}
+	}
+
+	// Write repeated rows found in the input files
+	if len(result.SystemDuplicates) > 0 || len(result.BankDuplicates) > 0 {
+		fmt.Fprintln(w, "\n"+strings.Repeat("-", 80))
+		fmt.Fprintf(w, "DUPLICATES: %d\n", len(result.SystemDuplicates)+len(result.BankDuplicates))
+		fmt.Fprintln(w, strings.Repeat("-", 80))
+		fmt.Fprintf(w, "%-15s %-22s %-8s %-8s %s\n", "Source", "Kind", "Rows", "Removed", "Identifiers")
+		for _, duplicate := range result.SystemDuplicates {
+			var ids []string
+			for _, trx := range duplicate.Transactions {
+				ids = append(ids, trx.TrxID)
+			}
+			fmt.Fprintf(w, "%-15s %-22s %-8d %-8d %s\n", "system", duplicate.Kind, len(duplicate.Transactions), duplicate.Removed, strings.Join(ids, ", "))
+		}
+		for _, duplicate := range result.BankDuplicates {
+			var ids []string
+			for _, stmtLine := range duplicate.BankStatementLines {
+				ids = append(ids, stmtLine.UniqueIdentifier)
+			}
+			fmt.Fprintf(w, "%-15s %-22s %-8d %-8d %s\n", duplicate.BankStatementLines[0].BankName, duplicate.Kind, len(duplicate.BankStatementLines), duplicate.Removed, strings.Join(ids, ", "))
+		}
+	}
+
+	// Write transfers between our own accounts
+	if len(result.InternalTransfers) > 0 {
+		fmt.Fprintln(w, "\n"+strings.Repeat("-", 80))
+		fmt.Fprintf(w, "INTERNAL TRANSFERS: %d\n", len(result.InternalTransfers))
+		fmt.Fprintln(w, strings.Repeat("-", 80))
+		fmt.Fprintf(w, "%-15s %-20s %-15s %-20s %20s\n", "From Bank", "Debit", "To Bank", "Credit", "Amount")
+		for _, transfer := range result.InternalTransfers {
+			fmt.Fprintf(w, "%-15s %-20s %-15s %-20s %20s\n", transfer.Debit.BankName, transfer.Debit.UniqueIdentifier, transfer.Credit.BankName, transfer.Credit.UniqueIdentifier, fmt.Sprintf("Rp. %v", transfer.Credit.Amount.StringFixed(2)))
+		}
+	}
 
 	// Write unmatched system transactions
 	if len(result.UnmatchedSystemTransactions) > 0 {
@@ -200,7 +690,24 @@ func formatResult(w io.Writer, result *models.ReconciliationResult, params Recon
 		fmt.Fprintf(w, "UNMATCHED SYSTEM TRANSACTIONS: %d\n", len(result.UnmatchedSystemTransactions))
 		fmt.Fprintln(w, strings.Repeat("-", 80))
 		fmt.Fprintf(w, "%-20s %-10s %-25s %20s \n", "TrxID", "Type", "Transaction Time", "Amount")
-		for _, trx := range result.UnmatchedSystemTransactions {
+		for i, trx := range result.UnmatchedSystemTransactions {
+			fmt.Fprintf(w, "%-20s %-10s %-25s %20s\n", trx.TrxID, trx.Type, trx.TransactionTime.Format("2006-01-02 15:04:05"), fmt.Sprintf("Rp. %v", trx.Amount.StringFixed(2)))
+			if i < len(result.SystemNearMisses) {
+				for _, nearMiss := range result.SystemNearMisses[i] {
+					stmtLine := nearMiss.BankStatementLine
+					fmt.Fprintf(w, "  ? %-20s %-15s %-10s %20s  (%s)\n", stmtLine.UniqueIdentifier, stmtLine.BankName, stmtLine.Date.Format("2006-01-02"), fmt.Sprintf("Rp. %v", stmtLine.Amount.StringFixed(2)), nearMiss.Reason)
+				}
+			}
+		}
+	}
+
+	// Write system transactions no bank file covers yet, they are not counted as unmatched
+	if len(result.NotYetReconcilable) > 0 {
+		fmt.Fprintln(w, "\n"+strings.Repeat("-", 80))
+		fmt.Fprintf(w, "NOT YET RECONCILABLE: %d\n", len(result.NotYetReconcilable))
+		fmt.Fprintln(w, strings.Repeat("-", 80))
+		fmt.Fprintf(w, "%-20s %-10s %-25s %20s \n", "TrxID", "Type", "Transaction Time", "Amount")
+		for _, trx := range result.NotYetReconcilable {
 			fmt.Fprintf(w, "%-20s %-10s %-25s %20s\n", trx.TrxID, trx.Type, trx.TransactionTime.Format("2006-01-02 15:04:05"), fmt.Sprintf("Rp. %v", trx.Amount.StringFixed(2)))
 		}
 	}
@@ -219,11 +726,57 @@ func formatResult(w io.Writer, result *models.ReconciliationResult, params Recon
 		for bankName, statementLines := range result.UnmatchedBankStatementLines {
 			fmt.Fprintf(w, "\nBank: %s (%d transactions)\n", bankName, len(statementLines))
 			fmt.Fprintf(w, "%-20s %-10s %20s\n", "Unique Identifier", "Date", "Amount")
-			for _, stmtLine := range statementLines {
+			nearMisses := result.BankNearMisses[bankName]
+			for i, stmtLine := range statementLines {
 				fmt.Fprintf(w, "%-20s %-10s %20s\n", stmtLine.UniqueIdentifier, stmtLine.Date.Format("2006-01-02"), fmt.Sprintf("Rp. %v", stmtLine.Amount.StringFixed(2)))
+				if i < len(nearMisses) {
+					for _, nearMiss := range nearMisses[i] {
+						trx := nearMiss.SystemTransaction
+						fmt.Fprintf(w, "  ? %-20s %-10s %-19s %20s  (%s)\n", trx.TrxID, trx.Type, trx.TransactionTime.Format("2006-01-02 15:04:05"), fmt.Sprintf("Rp. %v", trx.Amount.StringFixed(2)), nearMiss.Reason)
+					}
+				}
 			}
 		}
 	}
 
+	if params.ShowStats {
+		formatStatistics(w, result.Statistics)
+	}
+
 	fmt.Fprintln(w, "\n"+strings.Repeat("=", 80))
 }
+
+// formatGroupedMatch writes the system transactions and bank statement lines of a group followed by its discrepancy
+func formatGroupedMatch(w io.Writer, group models.GroupedMatch) {
+	fmt.Fprintln(w)
+	for _, trx := range group.SystemTransactions {
+		fmt.Fprintf(w, "  System: %-20s %-15s %-19s %20s\n", trx.TrxID, trx.Type, trx.TransactionTime.Format("2006-01-02 15:04:05"), fmt.Sprintf("Rp. %v", trx.Amount.StringFixed(2)))
+	}
+	for _, stmtLine := range group.BankStatementLines {
+		fmt.Fprintf(w, "  Bank:   %-20s %-15s %-19s %20s\n", stmtLine.UniqueIdentifier, stmtLine.BankName, stmtLine.Date.Format("2006-01-02"), fmt.Sprintf("Rp. %v", stmtLine.Amount.StringFixed(2)))
+	}
+	fmt.Fprintf(w, "  Discrepancy: Rp. %s\n", group.Discrepancy.StringFixed(2))
+}
+
+func formatStatistics(w io.Writer, stats models.RunStatistics) {
+	fmt.Fprintln(w, "\n"+strings.Repeat("-", 80))
+	fmt.Fprintln(w, "RUN STATISTICS")
+	fmt.Fprintln(w, strings.Repeat("-", 80))
+
+	fmt.Fprintf(w, "  Parse Time: %v\n", stats.ParseDuration)
+	filePaths := make([]string, 0, len(stats.ParseDurations))
+	for filePath := range stats.ParseDurations {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+	for _, filePath := range filePaths {
+		fmt.Fprintf(w, "    %s: %v\n", filePath, stats.ParseDurations[filePath])
+	}
+	fmt.Fprintf(w, "  Filter Time: %v\n", stats.FilterDuration)
+	fmt.Fprintf(w, "  Index Build Time: %v\n", stats.IndexBuildDuration)
+	fmt.Fprintf(w, "  Match Time: %v\n", stats.MatchDuration)
+	fmt.Fprintf(w, "  Total Time: %v\n", stats.TotalDuration)
+	fmt.Fprintf(w, "  Rows Parsed: %d (%.0f rows/sec of parse time)\n", stats.RowsParsed, stats.RowsPerSecond)
+	fmt.Fprintf(w, "  Peak Heap: %.2f MiB\n", float64(stats.PeakHeapBytes)/(1024*1024))
+	fmt.Fprintf(w, "  Index Keys: %d (Collisions: %d)\n", stats.IndexKeys, stats.IndexCollisions)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/firmannf/recon/internal/service"
+)
+
+const progressBarWidth = 40
+
+// progressBar renders reconciliation progress on a terminal
+type progressBar struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// newProgressReporter returns a progress bar writing to stderr,
+// or nil when stderr is not attached to a terminal
+func newProgressReporter() service.ProgressReporter {
+	if !isTerminal(os.Stderr) {
+		return nil
+	}
+	return &progressBar{w: os.Stderr}
+}
+
+// isTerminal reports whether the file is a character device (TTY)
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func (p *progressBar) FileParsed(filePath string, rows int, elapsed time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, "  Parsed %s: %d rows (%v)\n", filepath.Base(filePath), rows, elapsed.Round(time.Millisecond))
+}
+
+func (p *progressBar) MatchProgress(done, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	percent := 100
+	if total > 0 {
+		percent = done * 100 / total
+	}
+	filled := percent * progressBarWidth / 100
+	bar := strings.Repeat("#", filled) + strings.Repeat(".", progressBarWidth-filled)
+	fmt.Fprintf(p.w, "\r  Matching [%s] %3d%% (%d/%d)", bar, percent, done, total)
+	if done >= total {
+		fmt.Fprintln(p.w)
+	}
+}
+
+func (p *progressBar) PhaseCompleted(phase service.Phase, elapsed time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, "  Phase %s completed in %v\n", phase, elapsed.Round(time.Millisecond))
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/expr"
+	"github.com/firmannf/recon/internal/service"
+)
+
+const (
+	STRATEGY_EXACT        = "exact"
+	STRATEGY_REFERENCE    = "reference"
+	STRATEGY_WINDOW       = "window"
+	STRATEGY_TOLERANCE    = "tolerance"
+	STRATEGY_FEE          = "fee"
+	STRATEGY_PLUGIN       = "plugin"
+	STRATEGY_COUNTERPARTY = "counterparty"
+)
+
+// buildMatchStrategy creates the match strategy selected on the command line
+func buildMatchStrategy(params ReconciliationParams) (service.MatchStrategy, error) {
+	switch params.Strategy {
+	case STRATEGY_EXACT:
+		return service.NewExactMatchStrategy(), nil
+	case STRATEGY_REFERENCE:
+		// Reference matches first, the rest falls back to exact amount and date matching
+		return service.NewReferenceMatchStrategy(params.ReferencePatterns, service.NewExactMatchStrategy())
+	default:
+		return nil, fmt.Errorf("unknown strategy %q, expected %s or %s (use -passes for %s, %s, %s, %s and %s)", params.Strategy, STRATEGY_EXACT, STRATEGY_REFERENCE, STRATEGY_WINDOW, STRATEGY_TOLERANCE, STRATEGY_FEE, STRATEGY_COUNTERPARTY, STRATEGY_PLUGIN)
+	}
+}
+
+// passOptions holds the configuration that pass specs can refer to
+type passOptions struct {
+	referencePatterns []string
+	feeRules          []service.FeeRule
+	plugins           map[string]service.PluginConfig
+}
+
+// buildMatchPipeline creates the match passes from specs such as "reference", "reference:3:5", "exact", "window:1", "tolerance:500",
+// "fee", "counterparty:1:0.85" or "plugin:<name>"
+func buildMatchPipeline(specs []string, options passOptions) (service.MatchPipeline, error) {
+	var pipeline service.MatchPipeline
+	for _, spec := range specs {
+		pass, err := parsePassSpec(strings.TrimSpace(spec), options)
+		if err != nil {
+			closeMatchPipeline(pipeline)
+			return nil, err
+		}
+		pipeline = append(pipeline, pass)
+	}
+	return pipeline, nil
+}
+
+func parsePassSpec(spec string, options passOptions) (service.MatchPass, error) {
+	name, arg, hasArg := strings.Cut(spec, ":")
+
+	var strategy service.MatchStrategy
+	switch name {
+	case STRATEGY_EXACT:
+		strategy = service.NewExactMatchStrategy()
+	case STRATEGY_REFERENCE:
+		reference, err := service.NewReferenceMatchStrategy(options.referencePatterns, nil)
+		if err != nil {
+			return service.MatchPass{}, err
+		}
+		if hasArg {
+			if err := parseReferenceSpec(reference, arg); err != nil {
+				return service.MatchPass{}, fmt.Errorf("invalid pass %q: expected %s[:<days>[:<max difference percent>]] with days >= 0 and a percent >= 0", spec, STRATEGY_REFERENCE)
+			}
+		}
+		strategy = reference
+	case STRATEGY_WINDOW:
+		days, err := strconv.Atoi(arg)
+		if !hasArg || err != nil || days < 0 {
+			return service.MatchPass{}, fmt.Errorf("invalid pass %q: expected window:<days> with days >= 0", spec)
+		}
+		strategy = service.NewDateWindowMatchStrategy(days)
+	case STRATEGY_TOLERANCE:
+		tolerance, err := decimal.NewFromString(arg)
+		if !hasArg || err != nil || tolerance.IsNegative() {
+			return service.MatchPass{}, fmt.Errorf("invalid pass %q: expected tolerance:<amount> with amount >= 0", spec)
+		}
+		strategy = service.NewAmountToleranceMatchStrategy(tolerance)
+	case STRATEGY_FEE:
+		fee, err := service.NewFeeMatchStrategy(options.feeRules)
+		if err != nil {
+			return service.MatchPass{}, fmt.Errorf("invalid pass %q: %w (configure fee_rules in the config file)", spec, err)
+		}
+		strategy = fee
+	case STRATEGY_COUNTERPARTY:
+		counterparty, err := parseCounterpartySpec(arg, hasArg)
+		if err != nil {
+			return service.MatchPass{}, fmt.Errorf("invalid pass %q: expected %s:<days>[:<min similarity>] with days >= 0 and similarity between 0 and 1", spec, STRATEGY_COUNTERPARTY)
+		}
+		strategy = counterparty
+	case STRATEGY_PLUGIN:
+		pluginConfig, ok := options.plugins[arg]
+		if !hasArg || !ok {
+			return service.MatchPass{}, fmt.Errorf("invalid pass %q: expected plugin:<name> with a plugin configured under plugins in the config file", spec)
+		}
+		plugin, err := service.NewPluginMatchStrategy(pluginConfig)
+		if err != nil {
+			return service.MatchPass{}, fmt.Errorf("invalid pass %q: %w", spec, err)
+		}
+		strategy = plugin
+	default:
+		return service.MatchPass{}, fmt.Errorf("unknown pass %q, expected %s[:<days>[:<percent>]], %s, %s:<days>, %s:<amount>, %s, %s:<days> or %s:<name>", spec, STRATEGY_REFERENCE, STRATEGY_EXACT, STRATEGY_WINDOW, STRATEGY_TOLERANCE, STRATEGY_FEE, STRATEGY_COUNTERPARTY, STRATEGY_PLUGIN)
+	}
+
+	if hasArg && (name == STRATEGY_EXACT || name == STRATEGY_FEE) {
+		return service.MatchPass{}, fmt.Errorf("invalid pass %q: %s takes no argument", spec, name)
+	}
+
+	return service.MatchPass{Name: spec, Strategy: strategy}, nil
+}
+
+// parseReferenceSpec applies the "<days>[:<max difference percent>]" argument of a reference pass
+func parseReferenceSpec(reference *service.ReferenceMatchStrategy, arg string) error {
+	daysArg, percentArg, hasPercent := strings.Cut(arg, ":")
+	days, err := strconv.Atoi(daysArg)
+	if err != nil || days < 0 {
+		return errors.New("invalid days")
+	}
+	reference.WindowDays = days
+
+	if hasPercent {
+		percent, err := decimal.NewFromString(percentArg)
+		if err != nil || percent.IsNegative() {
+			return errors.New("invalid percent")
+		}
+		reference.MaxDifferencePercent = percent
+	}
+	return nil
+}
+
+// parseCounterpartySpec parses the "<days>[:<min similarity>]" argument of a counterparty pass
+func parseCounterpartySpec(arg string, hasArg bool) (*service.CounterpartyMatchStrategy, error) {
+	daysArg, similarityArg, hasSimilarity := strings.Cut(arg, ":")
+	days, err := strconv.Atoi(daysArg)
+	if !hasArg || err != nil || days < 0 {
+		return nil, errors.New("invalid days")
+	}
+
+	minSimilarity := 0.0
+	if hasSimilarity {
+		minSimilarity, err = strconv.ParseFloat(similarityArg, 64)
+		if err != nil || minSimilarity < 0 || minSimilarity > 1 {
+			return nil, errors.New("invalid similarity")
+		}
+	}
+	return service.NewCounterpartyMatchStrategy(days, minSimilarity), nil
+}
+
+// parseBankPasses merges the pass specs per bank from the config file with the -bank-passes values
+// given as bank=passes, the values taking precedence for their bank
+func parseBankPasses(configured map[string][]string, values []string) (map[string][]string, error) {
+	bankPassSpecs := make(map[string][]string, len(configured))
+	for bank, specs := range configured {
+		bankPassSpecs[bank] = specs
+	}
+	for _, value := range values {
+		bank, specs, ok := strings.Cut(value, "=")
+		if !ok || bank == "" || specs == "" {
+			return nil, fmt.Errorf("invalid bank passes: %q, expected bank=passes, e.g. bank_va=exact,window:1", value)
+		}
+		bankPassSpecs[bank] = strings.Split(specs, ",")
+	}
+	return bankPassSpecs, nil
+}
+
+// closeMatchPipeline stops the plugin processes started for the passes
+func closeMatchPipeline(pipeline service.MatchPipeline) {
+	for _, pass := range pipeline {
+		if closer, ok := pass.Strategy.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+}
+
+// buildGroupMatchConfig creates the group matching options from the -group-* flags
+func buildGroupMatchConfig(params ReconciliationParams) (*service.GroupMatchConfig, error) {
+	tolerance, err := decimal.NewFromString(params.GroupTolerance)
+	if err != nil || tolerance.IsNegative() {
+		return nil, fmt.Errorf("invalid group tolerance %q: expected an amount >= 0", params.GroupTolerance)
+	}
+	if params.GroupWindow < 0 {
+		return nil, fmt.Errorf("invalid group window %d: expected days >= 0", params.GroupWindow)
+	}
+	if params.GroupMaxSize != 0 && params.GroupMaxSize < 2 {
+		return nil, fmt.Errorf("invalid group max size %d: expected at least 2", params.GroupMaxSize)
+	}
+	return &service.GroupMatchConfig{
+		WindowDays:   params.GroupWindow,
+		Tolerance:    tolerance,
+		MaxGroupSize: params.GroupMaxSize,
+	}, nil
+}
+
+// describeConditionError adds the condition with a caret under the problem to expression compile errors
+func describeConditionError(err error) string {
+	var exprErr *expr.Error
+	if !errors.As(err, &exprErr) {
+		return err.Error()
+	}
+	return fmt.Sprintf("%v\n  %s", err, strings.ReplaceAll(exprErr.Pointer(), "\n", "\n  "))
+}
+
+// closeMatchPipelines stops the plugin processes started for the default and per-bank passes
+func closeMatchPipelines(input service.ReconciliationInput) {
+	closeMatchPipeline(input.MatchPipeline)
+	for _, pipeline := range input.BankStrategies {
+		closeMatchPipeline(pipeline)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/service"
+)
+
+func TestBuildMatchPipeline(t *testing.T) {
+	options := passOptions{
+		feeRules: []service.FeeRule{{Percent: decimal.NewFromFloat(0.7)}},
+	}
+
+	tests := []struct {
+		name          string
+		specs         []string
+		options       passOptions
+		expectedNames []string
+		expectedError string
+	}{
+		{
+			name:          "passes in order",
+			specs:         []string{"reference", "exact", "window:1", "tolerance:500"},
+			options:       options,
+			expectedNames: []string{"reference", "exact", "window:1", "tolerance:500"},
+		},
+		{
+			name:          "spaces around specs are trimmed",
+			specs:         []string{" exact", "window:2 "},
+			options:       options,
+			expectedNames: []string{"exact", "window:2"},
+		},
+		{
+			name:          "fee and counterparty",
+			specs:         []string{"fee", "counterparty:1", "counterparty:1:0.85"},
+			options:       options,
+			expectedNames: []string{"fee", "counterparty:1", "counterparty:1:0.85"},
+		},
+		{
+			name:          "reference with window and amount difference",
+			specs:         []string{"reference:3", "reference:3:5"},
+			options:       options,
+			expectedNames: []string{"reference:3", "reference:3:5"},
+		},
+		{
+			name:          "reference with negative days",
+			specs:         []string{"reference:-1"},
+			expectedError: `invalid pass "reference:-1"`,
+		},
+		{
+			name:          "reference with invalid percent",
+			specs:         []string{"reference:3:abc"},
+			expectedError: `invalid pass "reference:3:abc"`,
+		},
+		{
+			name:          "window without days",
+			specs:         []string{"window"},
+			expectedError: `invalid pass "window"`,
+		},
+		{
+			name:          "negative window",
+			specs:         []string{"window:-1"},
+			expectedError: `invalid pass "window:-1"`,
+		},
+		{
+			name:          "invalid tolerance",
+			specs:         []string{"tolerance:abc"},
+			expectedError: `invalid pass "tolerance:abc"`,
+		},
+		{
+			name:          "negative tolerance",
+			specs:         []string{"tolerance:-5"},
+			expectedError: `invalid pass "tolerance:-5"`,
+		},
+		{
+			name:          "argument to exact",
+			specs:         []string{"exact:1"},
+			expectedError: `invalid pass "exact:1": exact takes no argument`,
+		},
+		{
+			name:          "fee without fee rules",
+			specs:         []string{"fee"},
+			expectedError: "configure fee_rules in the config file",
+		},
+		{
+			name:          "counterparty similarity above 1",
+			specs:         []string{"counterparty:1:2"},
+			expectedError: `invalid pass "counterparty:1:2"`,
+		},
+		{
+			name:          "unconfigured plugin",
+			specs:         []string{"plugin:scorer"},
+			expectedError: `invalid pass "plugin:scorer"`,
+		},
+		{
+			name:          "unknown pass after a valid one",
+			specs:         []string{"exact", "fuzzy"},
+			expectedError: `unknown pass "fuzzy"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline, err := buildMatchPipeline(tt.specs, tt.options)
+			if tt.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected error containing %q, got nil", tt.expectedError)
+				}
+				if !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tt.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(pipeline) != len(tt.expectedNames) {
+				t.Fatalf("Expected %d passes, got %d", len(tt.expectedNames), len(pipeline))
+			}
+			for i, pass := range pipeline {
+				if pass.Name != tt.expectedNames[i] {
+					t.Errorf("Expected pass %d to be %s, got %s", i+1, tt.expectedNames[i], pass.Name)
+				}
+				if pass.Strategy == nil {
+					t.Errorf("Expected pass %s to have a strategy", pass.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestParseBankPasses(t *testing.T) {
+	tests := []struct {
+		name          string
+		configured    map[string][]string
+		values        []string
+		expected      map[string][]string
+		expectedError bool
+	}{
+		{
+			name:     "flags only",
+			values:   []string{"bank_va=exact,window:1", "card_acquirer=fee"},
+			expected: map[string][]string{"bank_va": {"exact", "window:1"}, "card_acquirer": {"fee"}},
+		},
+		{
+			name:       "flags replace the config file for their bank",
+			configured: map[string][]string{"bank_va": {"exact"}, "bank_bca": {"reference"}},
+			values:     []string{"bank_va=window:2"},
+			expected:   map[string][]string{"bank_va": {"window:2"}, "bank_bca": {"reference"}},
+		},
+		{
+			name:          "missing separator",
+			values:        []string{"bank_va"},
+			expectedError: true,
+		},
+		{
+			name:          "missing bank",
+			values:        []string{"=exact"},
+			expectedError: true,
+		},
+		{
+			name:          "missing passes",
+			values:        []string{"bank_va="},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bankPassSpecs, err := parseBankPasses(tt.configured, tt.values)
+			if tt.expectedError {
+				if err == nil {
+					t.Errorf("Expected error but got %v", bankPassSpecs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(bankPassSpecs, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, bankPassSpecs)
+			}
+		})
+	}
+}
+
+func TestBuildMatchStrategy(t *testing.T) {
+	for _, strategy := range []string{STRATEGY_EXACT, STRATEGY_REFERENCE} {
+		if _, err := buildMatchStrategy(ReconciliationParams{Strategy: strategy}); err != nil {
+			t.Errorf("Expected strategy %s to be valid, got %v", strategy, err)
+		}
+	}
+
+	_, err := buildMatchStrategy(ReconciliationParams{Strategy: STRATEGY_WINDOW})
+	if err == nil || !strings.Contains(err.Error(), "use -passes") {
+		t.Errorf("Expected an error pointing to -passes, got %v", err)
+	}
+}
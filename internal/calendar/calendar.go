@@ -0,0 +1,19 @@
+// Package calendar holds the calendar day arithmetic shared by matching, match conditions and data generation
+package calendar
+
+import "time"
+
+// StartOfDay returns midnight of the day of t in the timezone of t
+func StartOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// DaysBetween returns the number of calendar days from a to b in the timezone of b
+func DaysBetween(a, b time.Time) int {
+	aYear, aMonth, aDay := a.In(b.Location()).Date()
+	bYear, bMonth, bDay := b.Date()
+	aDate := time.Date(aYear, aMonth, aDay, 0, 0, 0, 0, time.UTC)
+	bDate := time.Date(bYear, bMonth, bDay, 0, 0, 0, 0, time.UTC)
+	return int(bDate.Sub(aDate).Hours() / 24)
+}
@@ -0,0 +1,86 @@
+package calendar_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/firmannf/recon/internal/calendar"
+)
+
+func TestStartOfDay(t *testing.T) {
+	loc := time.FixedZone("UTC+7", 7*60*60)
+
+	tests := []struct {
+		name     string
+		input    time.Time
+		expected time.Time
+	}{
+		{
+			name:     "time of day is dropped",
+			input:    time.Date(2024, 1, 15, 23, 59, 59, 999, loc),
+			expected: time.Date(2024, 1, 15, 0, 0, 0, 0, loc),
+		},
+		{
+			name:     "midnight is kept",
+			input:    time.Date(2024, 1, 15, 0, 0, 0, 0, loc),
+			expected: time.Date(2024, 1, 15, 0, 0, 0, 0, loc),
+		},
+		{
+			name:     "day is taken in the timezone of the time",
+			input:    time.Date(2024, 1, 15, 1, 0, 0, 0, loc).UTC(),
+			expected: time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calendar.StartOfDay(tt.input)
+			if !got.Equal(tt.expected) || got.Location() != tt.expected.Location() {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDaysBetween(t *testing.T) {
+	loc := time.FixedZone("UTC+7", 7*60*60)
+
+	tests := []struct {
+		name     string
+		a, b     time.Time
+		expected int
+	}{
+		{
+			name:     "same day",
+			a:        time.Date(2024, 1, 15, 0, 0, 0, 0, loc),
+			b:        time.Date(2024, 1, 15, 23, 59, 0, 0, loc),
+			expected: 0,
+		},
+		{
+			name:     "counts calendar days, not 24 hour periods",
+			a:        time.Date(2024, 1, 15, 23, 30, 0, 0, loc),
+			b:        time.Date(2024, 1, 16, 0, 30, 0, 0, loc),
+			expected: 1,
+		},
+		{
+			name:     "negative when b is earlier",
+			a:        time.Date(2024, 3, 1, 0, 0, 0, 0, loc),
+			b:        time.Date(2024, 2, 28, 0, 0, 0, 0, loc),
+			expected: -2,
+		},
+		{
+			name:     "a is converted to the timezone of b",
+			a:        time.Date(2024, 1, 15, 18, 0, 0, 0, time.UTC),
+			b:        time.Date(2024, 1, 16, 0, 0, 0, 0, loc),
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calendar.DaysBetween(tt.a, tt.b); got != tt.expected {
+				t.Errorf("Expected %d days, got %d", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package expr
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/calendar"
+)
+
+// evalFunc evaluates a type checked node
+type evalFunc func(lookup func(name string) Value) (Value, error)
+
+var errDivisionByZero = errors.New("division by zero")
+
+// function is a builtin function with fixed parameter types
+type function struct {
+	params []Type
+	result Type
+	call   func(args []Value) Value
+}
+
+var functions = map[string]function{
+	"startsWith": {[]Type{TypeString, TypeString}, TypeBool, func(args []Value) Value {
+		return Bool(strings.HasPrefix(args[0].text, args[1].text))
+	}},
+	"endsWith": {[]Type{TypeString, TypeString}, TypeBool, func(args []Value) Value {
+		return Bool(strings.HasSuffix(args[0].text, args[1].text))
+	}},
+	"contains": {[]Type{TypeString, TypeString}, TypeBool, func(args []Value) Value {
+		return Bool(strings.Contains(args[0].text, args[1].text))
+	}},
+	"upper": {[]Type{TypeString}, TypeString, func(args []Value) Value {
+		return String(strings.ToUpper(args[0].text))
+	}},
+	"lower": {[]Type{TypeString}, TypeString, func(args []Value) Value {
+		return String(strings.ToLower(args[0].text))
+	}},
+	"trim": {[]Type{TypeString}, TypeString, func(args []Value) Value {
+		return String(strings.TrimSpace(args[0].text))
+	}},
+	"len": {[]Type{TypeString}, TypeNumber, func(args []Value) Value {
+		return Number(decimal.NewFromInt(int64(len([]rune(args[0].text)))))
+	}},
+	"abs": {[]Type{TypeNumber}, TypeNumber, func(args []Value) Value {
+		return Number(args[0].number.Abs())
+	}},
+	"min": {[]Type{TypeNumber, TypeNumber}, TypeNumber, func(args []Value) Value {
+		return Number(decimal.Min(args[0].number, args[1].number))
+	}},
+	"max": {[]Type{TypeNumber, TypeNumber}, TypeNumber, func(args []Value) Value {
+		return Number(decimal.Max(args[0].number, args[1].number))
+	}},
+	"days": {[]Type{TypeTime, TypeTime}, TypeNumber, func(args []Value) Value {
+		return Number(decimal.NewFromInt(int64(calendar.DaysBetween(args[0].time, args[1].time))))
+	}},
+}
+
+// matchesFunction takes a regex literal, compiled once with the expression
+const matchesFunction = "matches"
+
+// compiler type checks the syntax tree and turns it into closures
+type compiler struct {
+	source string
+	vars   Vars
+}
+
+func (c *compiler) compile(n node) (Type, evalFunc, error) {
+	switch n := n.(type) {
+	case *literalNode:
+		value := n.value
+		return value.typ, func(func(string) Value) (Value, error) { return value, nil }, nil
+
+	case *variableNode:
+		typ, ok := c.vars[n.name]
+		if !ok {
+			return 0, nil, newError(c.source, n.pos, "unknown variable %q%s", n.name, suggest(n.name, c.variableNames()))
+		}
+		name := n.name
+		return typ, func(lookup func(string) Value) (Value, error) { return lookup(name), nil }, nil
+
+	case *unaryNode:
+		return c.compileUnary(n)
+
+	case *binaryNode:
+		return c.compileBinary(n)
+
+	case *callNode:
+		return c.compileCall(n)
+
+	default:
+		return 0, nil, newError(c.source, n.position(), "unsupported expression")
+	}
+}
+
+func (c *compiler) compileUnary(n *unaryNode) (Type, evalFunc, error) {
+	typ, operand, err := c.compile(n.operand)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	switch {
+	case n.op == "!" && typ == TypeBool:
+		return TypeBool, func(lookup func(string) Value) (Value, error) {
+			v, err := operand(lookup)
+			return Bool(!v.boolean), err
+		}, nil
+	case n.op == "-" && typ == TypeNumber:
+		return TypeNumber, func(lookup func(string) Value) (Value, error) {
+			v, err := operand(lookup)
+			return Number(v.number.Neg()), err
+		}, nil
+	default:
+		return 0, nil, newError(c.source, n.pos, "operator %s cannot be applied to a %s", n.op, typ)
+	}
+}
+
+func (c *compiler) compileBinary(n *binaryNode) (Type, evalFunc, error) {
+	leftType, left, err := c.compile(n.left)
+	if err != nil {
+		return 0, nil, err
+	}
+	rightType, right, err := c.compile(n.right)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	switch n.op {
+	case "&&", "||":
+		if leftType != TypeBool || rightType != TypeBool {
+			return 0, nil, newError(c.source, n.pos, "operator %s needs bool operands, got %s and %s", n.op, leftType, rightType)
+		}
+		isAnd := n.op == "&&"
+		return TypeBool, func(lookup func(string) Value) (Value, error) {
+			l, err := left(lookup)
+			if err != nil {
+				return Value{}, err
+			}
+			// Short circuit: false && x and true || x do not evaluate x
+			if l.boolean != isAnd {
+				return l, nil
+			}
+			return right(lookup)
+		}, nil
+
+	case "==", "!=", "<", "<=", ">", ">=":
+		if leftType != rightType {
+			return 0, nil, newError(c.source, n.pos, "cannot compare a %s with a %s", leftType, rightType)
+		}
+		if leftType == TypeBool && n.op != "==" && n.op != "!=" {
+			return 0, nil, newError(c.source, n.pos, "operator %s cannot be applied to bool values", n.op)
+		}
+		op := n.op
+		return TypeBool, func(lookup func(string) Value) (Value, error) {
+			l, err := left(lookup)
+			if err != nil {
+				return Value{}, err
+			}
+			r, err := right(lookup)
+			if err != nil {
+				return Value{}, err
+			}
+			return Bool(compareResult(op, compareValues(l, r))), nil
+		}, nil
+
+	case "+":
+		if leftType == TypeString && rightType == TypeString {
+			return TypeString, func(lookup func(string) Value) (Value, error) {
+				l, err := left(lookup)
+				if err != nil {
+					return Value{}, err
+				}
+				r, err := right(lookup)
+				return String(l.text + r.text), err
+			}, nil
+		}
+		fallthrough
+
+	case "-", "*", "/":
+		if leftType != TypeNumber || rightType != TypeNumber {
+			return 0, nil, newError(c.source, n.pos, "operator %s needs number operands, got %s and %s", n.op, leftType, rightType)
+		}
+		op := n.op
+		return TypeNumber, func(lookup func(string) Value) (Value, error) {
+			l, err := left(lookup)
+			if err != nil {
+				return Value{}, err
+			}
+			r, err := right(lookup)
+			if err != nil {
+				return Value{}, err
+			}
+			switch op {
+			case "+":
+				return Number(l.number.Add(r.number)), nil
+			case "-":
+				return Number(l.number.Sub(r.number)), nil
+			case "*":
+				return Number(l.number.Mul(r.number)), nil
+			default:
+				if r.number.IsZero() {
+					return Value{}, errDivisionByZero
+				}
+				return Number(l.number.Div(r.number)), nil
+			}
+		}, nil
+
+	default:
+		return 0, nil, newError(c.source, n.pos, "unknown operator %s", n.op)
+	}
+}
+
+func (c *compiler) compileCall(n *callNode) (Type, evalFunc, error) {
+	if n.name == matchesFunction {
+		return c.compileMatches(n)
+	}
+
+	fn, ok := functions[n.name]
+	if !ok {
+		return 0, nil, newError(c.source, n.pos, "unknown function %q%s", n.name, suggest(n.name, functionNames()))
+	}
+	if len(n.args) != len(fn.params) {
+		return 0, nil, newError(c.source, n.pos, "%s takes %d arguments, got %d", n.name, len(fn.params), len(n.args))
+	}
+
+	args := make([]evalFunc, len(n.args))
+	for i, arg := range n.args {
+		typ, eval, err := c.compile(arg)
+		if err != nil {
+			return 0, nil, err
+		}
+		if typ != fn.params[i] {
+			return 0, nil, newError(c.source, arg.position(), "argument %d of %s must be a %s, got a %s", i+1, n.name, fn.params[i], typ)
+		}
+		args[i] = eval
+	}
+
+	return fn.result, func(lookup func(string) Value) (Value, error) {
+		values := make([]Value, len(args))
+		for i, arg := range args {
+			v, err := arg(lookup)
+			if err != nil {
+				return Value{}, err
+			}
+			values[i] = v
+		}
+		return fn.call(values), nil
+	}, nil
+}
+
+// compileMatches compiles matches(text, "regex"), the regex must be a string literal
+func (c *compiler) compileMatches(n *callNode) (Type, evalFunc, error) {
+	if len(n.args) != 2 {
+		return 0, nil, newError(c.source, n.pos, "%s takes 2 arguments, got %d", matchesFunction, len(n.args))
+	}
+	typ, text, err := c.compile(n.args[0])
+	if err != nil {
+		return 0, nil, err
+	}
+	if typ != TypeString {
+		return 0, nil, newError(c.source, n.args[0].position(), "argument 1 of %s must be a string, got a %s", matchesFunction, typ)
+	}
+	pattern, ok := n.args[1].(*literalNode)
+	if !ok || pattern.value.typ != TypeString {
+		return 0, nil, newError(c.source, n.args[1].position(), "argument 2 of %s must be a string literal", matchesFunction)
+	}
+	re, err := regexp.Compile(pattern.value.text)
+	if err != nil {
+		return 0, nil, newError(c.source, pattern.pos, "invalid regex: %v", err)
+	}
+
+	return TypeBool, func(lookup func(string) Value) (Value, error) {
+		v, err := text(lookup)
+		if err != nil {
+			return Value{}, err
+		}
+		return Bool(re.MatchString(v.text)), nil
+	}, nil
+}
+
+func (c *compiler) variableNames() []string {
+	names := make([]string, 0, len(c.vars))
+	for name := range c.vars {
+		names = append(names, name)
+	}
+	return names
+}
+
+func functionNames() []string {
+	names := []string{matchesFunction}
+	for name := range functions {
+		names = append(names, name)
+	}
+	return names
+}
+
+// compareValues returns -1, 0 or 1 for two values of the same type
+func compareValues(a, b Value) int {
+	switch a.typ {
+	case TypeNumber:
+		return a.number.Cmp(b.number)
+	case TypeString:
+		return strings.Compare(a.text, b.text)
+	case TypeTime:
+		return a.time.Compare(b.time)
+	default:
+		if a.boolean == b.boolean {
+			return 0
+		}
+		return 1
+	}
+}
+
+func compareResult(op string, cmp int) bool {
+	switch op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
@@ -0,0 +1,123 @@
+// Package expr implements a small expression language for match conditions, e.g.
+//
+//	startsWith(bank.id, "QR") && sys.amount > 1000000
+//
+// Expressions are type checked against the declared variables when compiled and cannot
+// have side effects. Supported are number, string, bool and time values, the operators
+// || && ! (or the words or, and, not), == != < <= > >=, + - * / and the builtin functions
+// startsWith, endsWith, contains, matches, upper, lower, trim, len, abs, min, max and days.
+package expr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Vars declares the variables an expression may use and their types
+type Vars map[string]Type
+
+// Program is a compiled, type checked expression
+type Program struct {
+	source string
+	typ    Type
+	eval   evalFunc
+}
+
+// Compile parses and type checks the source
+func Compile(source string, vars Vars) (*Program, error) {
+	root, err := parse(source)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &compiler{source: source, vars: vars}
+	typ, eval, err := c.compile(root)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{source: source, typ: typ, eval: eval}, nil
+}
+
+// Type returns the type of the value the program produces
+func (p *Program) Type() Type {
+	return p.typ
+}
+
+func (p *Program) String() string {
+	return p.source
+}
+
+// Eval runs the program, lookup returns the value of a declared variable.
+// The only runtime error is a division by zero.
+func (p *Program) Eval(lookup func(name string) Value) (Value, error) {
+	return p.eval(lookup)
+}
+
+// Error is a compile error at a position in the source
+type Error struct {
+	Source string
+	Pos    int // Byte offset in Source
+	Msg    string
+}
+
+func newError(source string, pos int, format string, args ...any) *Error {
+	return &Error{Source: source, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Column(), e.Msg)
+}
+
+// Column returns the 1-based column of the error
+func (e *Error) Column() int {
+	return column(e.Source, e.Pos)
+}
+
+// column returns the 1-based column, counted in characters, of a byte offset in the source
+func column(source string, pos int) int {
+	return utf8.RuneCountInString(source[:pos]) + 1
+}
+
+// Pointer returns the source with a caret under the error position
+func (e *Error) Pointer() string {
+	return e.Source + "\n" + strings.Repeat(" ", e.Column()-1) + "^"
+}
+
+// suggest returns a "did you mean" hint for the closest known name, if any is close enough
+func suggest(name string, known []string) string {
+	sort.Strings(known)
+	best, bestDistance := "", len(name)/2+1
+	for _, candidate := range known {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(candidate)); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q?", best)
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(br)]
+}
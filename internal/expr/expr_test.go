@@ -0,0 +1,147 @@
+package expr_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/expr"
+)
+
+var testVars = expr.Vars{
+	"id":     expr.TypeString,
+	"amount": expr.TypeNumber,
+	"posted": expr.TypeTime,
+	"booked": expr.TypeTime,
+	"zero":   expr.TypeNumber,
+	"active": expr.TypeBool,
+}
+
+func testLookup(name string) expr.Value {
+	switch name {
+	case "id":
+		return expr.String("QR-20240115-001")
+	case "amount":
+		return expr.Number(decimal.RequireFromString("1500000.50"))
+	case "posted":
+		return expr.Time(time.Date(2024, 1, 15, 23, 30, 0, 0, time.UTC))
+	case "booked":
+		return expr.Time(time.Date(2024, 1, 17, 1, 0, 0, 0, time.UTC))
+	case "zero":
+		return expr.Number(decimal.Zero)
+	default:
+		return expr.Bool(true)
+	}
+}
+
+func TestCompile_Eval(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected bool
+	}{
+		{name: "string prefix and amount", source: `startsWith(id, "QR") && amount > 1000000`, expected: true},
+		{name: "word operators", source: `not endsWith(id, "002") and (amount < 10 or active)`, expected: true},
+		{name: "decimal arithmetic", source: `amount * 2 - 1 == 3000000`, expected: true},
+		{name: "number separators", source: `amount >= 1_500_000.50`, expected: true},
+		{name: "unary minus", source: `-amount < 0`, expected: true},
+		{name: "precedence", source: `1 + 2 * 3 == 7`, expected: true},
+		{name: "string functions", source: `upper(lower(id)) == id && len(trim("  ab ")) == 2`, expected: true},
+		{name: "string concatenation", source: `"QR-" + "20240115" + "-001" == id`, expected: true},
+		{name: "regex", source: `matches(id, "^QR-[0-9]{8}-")`, expected: true},
+		{name: "contains single quotes", source: `contains(id, '0115')`, expected: true},
+		{name: "date difference", source: `days(posted, booked) == 2`, expected: true},
+		{name: "time comparison", source: `posted < booked`, expected: true},
+		{name: "abs min max", source: `abs(-5) == max(min(5, 7), 3)`, expected: true},
+		{name: "false result", source: `amount > 2000000`, expected: false},
+		{name: "short circuit skips division by zero", source: `false && amount / zero > 1`, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, err := expr.Compile(tt.source, testVars)
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			if program.Type() != expr.TypeBool {
+				t.Fatalf("Expected a bool expression, got %s", program.Type())
+			}
+
+			value, err := program.Eval(testLookup)
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			if value.Bool() != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, value.Bool())
+			}
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		source         string
+		expectedError  string
+		expectedColumn int
+	}{
+		{name: "empty", source: ``, expectedError: "empty expression", expectedColumn: 1},
+		{name: "unknown variable", source: `amout > 1`, expectedError: `unknown variable "amout", did you mean "amount"?`, expectedColumn: 1},
+		{name: "unknown function", source: `startswith(id, "QR")`, expectedError: `did you mean "startsWith"?`, expectedColumn: 1},
+		{name: "type mismatch", source: `amount > "1000"`, expectedError: "cannot compare a number with a string", expectedColumn: 8},
+		{name: "bool operand", source: `amount && active`, expectedError: "operator && needs bool operands, got number and bool", expectedColumn: 8},
+		{name: "argument type", source: `startsWith(amount, "1")`, expectedError: "argument 1 of startsWith must be a string, got a number", expectedColumn: 12},
+		{name: "argument count", source: `abs(1, 2)`, expectedError: "abs takes 1 arguments, got 2", expectedColumn: 1},
+		{name: "regex not literal", source: `matches(id, id)`, expectedError: "argument 2 of matches must be a string literal", expectedColumn: 13},
+		{name: "invalid regex", source: `matches(id, "[")`, expectedError: "invalid regex", expectedColumn: 13},
+		{name: "single equals", source: `amount = 1`, expectedError: `use "==" to compare`, expectedColumn: 8},
+		{name: "unclosed parenthesis", source: `(amount > 1`, expectedError: `expected ")" to close "(" at column 1`, expectedColumn: 12},
+		{name: "unclosed parenthesis after multibyte characters", source: `id == "é" && (amount > 1`, expectedError: `expected ")" to close "(" at column 14`, expectedColumn: 25},
+		{name: "nested too deeply", source: strings.Repeat("(", 1000) + "1" + strings.Repeat(")", 1000), expectedError: "nested too deeply", expectedColumn: 101},
+		{name: "negated too deeply", source: strings.Repeat("!", 1000) + "active", expectedError: "nested too deeply", expectedColumn: 101},
+		{name: "trailing token", source: `amount > 1 2`, expectedError: `unexpected "2", expected an operator or end of expression`, expectedColumn: 12},
+		{name: "chained comparison", source: `1 < amount < 2`, expectedError: "comparisons cannot be chained", expectedColumn: 12},
+		{name: "unterminated string", source: `id == "QR`, expectedError: "unterminated string", expectedColumn: 7},
+		{name: "missing operand", source: `amount >`, expectedError: "unexpected end of expression, expected a value", expectedColumn: 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := expr.Compile(tt.source, testVars)
+			if err == nil {
+				t.Fatal("Expected error but got nil")
+			}
+			if !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %q", tt.expectedError, err.Error())
+			}
+
+			var exprErr *expr.Error
+			if !errors.As(err, &exprErr) {
+				t.Fatalf("Expected *expr.Error, got %T", err)
+			}
+			if exprErr.Column() != tt.expectedColumn {
+				t.Errorf("Expected column %d, got %d", tt.expectedColumn, exprErr.Column())
+			}
+		})
+	}
+}
+
+func TestCompile_MaximumDepth(t *testing.T) {
+	source := strings.Repeat("(", 99) + "amount > 1" + strings.Repeat(")", 99)
+	if _, err := expr.Compile(source, testVars); err != nil {
+		t.Errorf("Expected 99 nested parentheses to compile, got %v", err)
+	}
+}
+
+func TestEval_DivisionByZero(t *testing.T) {
+	program, err := expr.Compile(`amount / zero > 1`, testVars)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if _, err := program.Eval(testLookup); err == nil {
+		t.Error("Expected error but got nil")
+	}
+}
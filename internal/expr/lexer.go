@@ -0,0 +1,160 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string // Identifier, operator or number text, or the unquoted string
+	pos  int    // Byte offset in the source
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return fmt.Sprintf("string %q", t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// Word operators are accepted as aliases of the symbolic ones
+var wordOperators = map[string]string{
+	"and": "&&",
+	"or":  "||",
+	"not": "!",
+}
+
+var twoCharOperators = []string{"&&", "||", "==", "!=", "<=", ">="}
+
+// tokenize splits the source into tokens, ending with a tokenEOF token
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+	offsets := make([]int, len(runes)+1)
+	offset := 0
+	for i, r := range runes {
+		offsets[i] = offset
+		offset += len(string(r))
+	}
+	offsets[len(runes)] = offset
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := offsets[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			if op, ok := wordOperators[strings.ToLower(text)]; ok {
+				tokens = append(tokens, token{kind: tokenOperator, text: op, pos: pos})
+			} else {
+				tokens = append(tokens, token{kind: tokenIdent, text: text, pos: pos})
+			}
+
+		case unicode.IsDigit(r):
+			start := i
+			seenDot := false
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '_' || (runes[i] == '.' && !seenDot)) {
+				if runes[i] == '.' {
+					seenDot = true
+				}
+				i++
+			}
+			text := strings.ReplaceAll(string(runes[start:i]), "_", "")
+			if strings.HasSuffix(text, ".") {
+				return nil, newError(source, offsets[i-1], "number %q has no digits after the decimal point", string(runes[start:i]))
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: text, pos: pos})
+
+		case r == '"' || r == '\'':
+			quote := r
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, newError(source, pos, "unterminated string")
+				}
+				if runes[i] == quote {
+					i++
+					break
+				}
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), pos: pos})
+
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: pos})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: pos})
+			i++
+
+		default:
+			matched := false
+			if i+1 < len(runes) {
+				pair := string(runes[i : i+2])
+				for _, op := range twoCharOperators {
+					if pair == op {
+						tokens = append(tokens, token{kind: tokenOperator, text: op, pos: pos})
+						i += 2
+						matched = true
+						break
+					}
+				}
+			}
+			if matched {
+				continue
+			}
+			if strings.ContainsRune("!<>+-*/", r) {
+				tokens = append(tokens, token{kind: tokenOperator, text: string(r), pos: pos})
+				i++
+				continue
+			}
+			switch r {
+			case '=':
+				return nil, newError(source, pos, "unexpected \"=\", use \"==\" to compare")
+			case '&':
+				return nil, newError(source, pos, "unexpected \"&\", use \"&&\" or and")
+			case '|':
+				return nil, newError(source, pos, "unexpected \"|\", use \"||\" or or")
+			}
+			return nil, newError(source, pos, "unexpected character %q", r)
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(source)})
+	return tokens, nil
+}
@@ -0,0 +1,211 @@
+package expr
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// node is an untyped syntax tree node
+type node interface {
+	position() int
+}
+
+type literalNode struct {
+	pos   int
+	value Value
+}
+
+type variableNode struct {
+	pos  int
+	name string
+}
+
+type unaryNode struct {
+	pos     int
+	op      string
+	operand node
+}
+
+type binaryNode struct {
+	pos         int
+	op          string
+	left, right node
+}
+
+type callNode struct {
+	pos  int
+	name string
+	args []node
+}
+
+func (n *literalNode) position() int  { return n.pos }
+func (n *variableNode) position() int { return n.pos }
+func (n *unaryNode) position() int    { return n.pos }
+func (n *binaryNode) position() int   { return n.pos }
+func (n *callNode) position() int     { return n.pos }
+
+// Binary operator precedence, higher binds tighter
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3, "<": 3, "<=": 3, ">": 3, ">=": 3,
+	"+": 4, "-": 4,
+	"*": 5, "/": 5,
+}
+
+// maxDepth limits how deeply parentheses, calls and unary operators may nest, the parser
+// recurses once per level
+const maxDepth = 100
+
+type parser struct {
+	source string
+	tokens []token
+	pos    int
+	depth  int
+}
+
+// parse builds the syntax tree of the source
+func parse(source string) (node, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	if tokens[0].kind == tokenEOF {
+		return nil, newError(source, 0, "empty expression")
+	}
+
+	p := &parser{source: source, tokens: tokens}
+	root, err := p.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEOF {
+		return nil, newError(source, next.pos, "unexpected %s, expected an operator or end of expression", next)
+	}
+	return root, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// parseBinary parses operators of at least minPrecedence by precedence climbing.
+// Comparisons do not chain, a < b < c is rejected.
+func (p *parser) parseBinary(minPrecedence int) (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op := p.peek()
+		prec, isBinary := precedence[op.text]
+		if op.kind != tokenOperator || !isBinary || prec < minPrecedence {
+			return left, nil
+		}
+		p.next()
+
+		right, err := p.parseBinary(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{pos: op.pos, op: op.text, left: left, right: right}
+
+		if prec == precedence["=="] {
+			if next := p.peek(); next.kind == tokenOperator && precedence[next.text] == prec {
+				return nil, newError(p.source, next.pos, "comparisons cannot be chained, combine them with &&")
+			}
+		}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, newError(p.source, p.peek().pos, "expression is nested too deeply, at most %d levels are allowed", maxDepth)
+	}
+
+	if t := p.peek(); t.kind == tokenOperator && (t.text == "!" || t.text == "-") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{pos: t.pos, op: t.text, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		number, err := decimal.NewFromString(t.text)
+		if err != nil {
+			return nil, newError(p.source, t.pos, "invalid number %q", t.text)
+		}
+		return &literalNode{pos: t.pos, value: Number(number)}, nil
+
+	case tokenString:
+		return &literalNode{pos: t.pos, value: String(t.text)}, nil
+
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return &literalNode{pos: t.pos, value: Bool(true)}, nil
+		case "false":
+			return &literalNode{pos: t.pos, value: Bool(false)}, nil
+		}
+		if p.peek().kind == tokenLParen {
+			return p.parseCall(t)
+		}
+		return &variableNode{pos: t.pos, name: t.text}, nil
+
+	case tokenLParen:
+		inner, err := p.parseBinary(1)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, newError(p.source, closing.pos, "unexpected %s, expected \")\" to close \"(\" at column %d", closing, column(p.source, t.pos))
+		}
+		return inner, nil
+
+	default:
+		return nil, newError(p.source, t.pos, "unexpected %s, expected a value", t)
+	}
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	p.next() // (
+	call := &callNode{pos: name.pos, name: name.text}
+	if p.peek().kind == tokenRParen {
+		p.next()
+		return call, nil
+	}
+
+	for {
+		arg, err := p.parseBinary(1)
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+
+		switch t := p.next(); t.kind {
+		case tokenComma:
+			continue
+		case tokenRParen:
+			return call, nil
+		default:
+			return nil, newError(p.source, t.pos, "unexpected %s, expected \",\" or \")\" in the arguments of %s", t, name.text)
+		}
+	}
+}
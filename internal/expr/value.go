@@ -0,0 +1,77 @@
+package expr
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Type is the static type of an expression or variable
+type Type int
+
+const (
+	TypeBool Type = iota + 1
+	TypeNumber
+	TypeString
+	TypeTime
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeBool:
+		return "bool"
+	case TypeNumber:
+		return "number"
+	case TypeString:
+		return "string"
+	case TypeTime:
+		return "time"
+	default:
+		return "unknown"
+	}
+}
+
+// Value is a typed value produced by an expression or supplied for a variable
+type Value struct {
+	typ     Type
+	boolean bool
+	number  decimal.Decimal
+	text    string
+	time    time.Time
+}
+
+func Bool(b bool) Value {
+	return Value{typ: TypeBool, boolean: b}
+}
+
+func Number(d decimal.Decimal) Value {
+	return Value{typ: TypeNumber, number: d}
+}
+
+func String(s string) Value {
+	return Value{typ: TypeString, text: s}
+}
+
+func Time(t time.Time) Value {
+	return Value{typ: TypeTime, time: t}
+}
+
+func (v Value) Type() Type {
+	return v.typ
+}
+
+func (v Value) Bool() bool {
+	return v.boolean
+}
+
+func (v Value) Number() decimal.Decimal {
+	return v.number
+}
+
+func (v Value) Text() string {
+	return v.text
+}
+
+func (v Value) Time() time.Time {
+	return v.time
+}
@@ -0,0 +1,269 @@
+package generator
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/calendar"
+	"github.com/firmannf/recon/internal/models"
+)
+
+// defaultBankNames are used for the first generated banks, additional banks are numbered
+var defaultBankNames = []string{"bank_bca", "bank_mandiri", "bank_bri", "bank_bni", "bank_btn"}
+
+// Config describes the shape of the synthetic data set
+type Config struct {
+	Rows          int       // Number of system transactions
+	MatchRate     float64   // Fraction of system transactions that have a bank counterpart (0-1)
+	DuplicateRate float64   // Fraction of system transactions sharing type, amount and date with a previous one (0-1)
+	DateSpanDays  int       // Number of days the transactions are spread over
+	Banks         int       // Number of bank statement files
+	StartDate     time.Time // First transaction date
+	Seed          int64     // Random seed, the same seed always produces the same data
+}
+
+// BenchmarkSizes returns the row counts the benchmarks generate data for, the large sizes need RECON_BENCH_LARGE=1
+func BenchmarkSizes() []int {
+	if os.Getenv("RECON_BENCH_LARGE") != "" {
+		return []int{10_000, 1_000_000, 10_000_000}
+	}
+	return []int{10_000}
+}
+
+// DefaultConfig returns a configuration producing a realistic data set of the given size
+func DefaultConfig(rows int) Config {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		loc = time.FixedZone("UTC+7", 7*60*60)
+	}
+	return Config{
+		Rows:          rows,
+		MatchRate:     0.95,
+		DuplicateRate: 0.01,
+		DateSpanDays:  30,
+		Banks:         3,
+		StartDate:     time.Date(2024, 1, 1, 0, 0, 0, 0, loc),
+		Seed:          1,
+	}
+}
+
+// Validate checks that the configuration can produce a data set
+func (c Config) Validate() error {
+	if c.Rows < 1 {
+		return fmt.Errorf("rows must be at least 1 (got %d)", c.Rows)
+	}
+	if c.MatchRate < 0 || c.MatchRate > 1 {
+		return fmt.Errorf("match rate must be between 0 and 1 (got %v)", c.MatchRate)
+	}
+	if c.DuplicateRate < 0 || c.DuplicateRate > 1 {
+		return fmt.Errorf("duplicate rate must be between 0 and 1 (got %v)", c.DuplicateRate)
+	}
+	if c.DateSpanDays < 1 {
+		return fmt.Errorf("date span must be at least 1 day (got %d)", c.DateSpanDays)
+	}
+	if c.Banks < 1 {
+		return fmt.Errorf("banks must be at least 1 (got %d)", c.Banks)
+	}
+	return nil
+}
+
+// BankNames returns the bank names used for the configured number of banks
+func (c Config) BankNames() []string {
+	names := make([]string, c.Banks)
+	for i := range names {
+		if i < len(defaultBankNames) {
+			names[i] = defaultBankNames[i]
+		} else {
+			names[i] = fmt.Sprintf("bank_%03d", i+1)
+		}
+	}
+	return names
+}
+
+// Generate builds system transactions and bank statement lines in memory, sorted by time like real exports.
+// Unmatched system transactions are balanced by the same number of bank-only lines.
+func Generate(cfg Config) ([]models.Transaction, []models.BankStatementLine, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	bankNames := cfg.BankNames()
+	spanSeconds := int64(cfg.DateSpanDays) * 24 * 60 * 60
+
+	systemTrxs := make([]models.Transaction, 0, cfg.Rows)
+	bankStmtLines := make([]models.BankStatementLine, 0, cfg.Rows)
+
+	for i := 0; i < cfg.Rows; i++ {
+		var trx models.Transaction
+		if i > 0 && rng.Float64() < cfg.DuplicateRate {
+			// Same type, amount and day as an earlier transaction, at a different time of day
+			trx = systemTrxs[rng.Intn(len(systemTrxs))]
+			day := calendar.StartOfDay(trx.TransactionTime)
+			trx.TransactionTime = day.Add(time.Duration(rng.Int63n(24*60*60)) * time.Second)
+		} else {
+			trx = models.Transaction{
+				Amount:          randomAmount(rng),
+				Type:            randomType(rng),
+				TransactionTime: cfg.StartDate.Add(time.Duration(rng.Int63n(spanSeconds)) * time.Second),
+			}
+		}
+		systemTrxs = append(systemTrxs, trx)
+
+		bankName := bankNames[rng.Intn(len(bankNames))]
+		if rng.Float64() < cfg.MatchRate {
+			bankStmtLines = append(bankStmtLines, bankLineFor(trx, bankName))
+			continue
+		}
+
+		// Bank-only line that has no system counterpart
+		orphan := models.Transaction{
+			Amount:          randomAmount(rng),
+			Type:            randomType(rng),
+			TransactionTime: cfg.StartDate.Add(time.Duration(rng.Int63n(spanSeconds)) * time.Second),
+		}
+		bankStmtLines = append(bankStmtLines, bankLineFor(orphan, bankName))
+	}
+
+	// Assign identifiers in time order
+	sort.SliceStable(systemTrxs, func(i, j int) bool {
+		return systemTrxs[i].TransactionTime.Before(systemTrxs[j].TransactionTime)
+	})
+	for i := range systemTrxs {
+		systemTrxs[i].TrxID = fmt.Sprintf("TRX%09d", i+1)
+	}
+
+	sort.SliceStable(bankStmtLines, func(i, j int) bool {
+		return bankStmtLines[i].Date.Before(bankStmtLines[j].Date)
+	})
+	bankSeq := make(map[string]int)
+	for i := range bankStmtLines {
+		bankSeq[bankStmtLines[i].BankName]++
+		bankStmtLines[i].UniqueIdentifier = fmt.Sprintf("%s-%09d", bankStmtLines[i].BankName, bankSeq[bankStmtLines[i].BankName])
+	}
+
+	return systemTrxs, bankStmtLines, nil
+}
+
+// WriteFiles generates a data set and writes it to dir as <prefix>_system.csv and <prefix>_<bank>.csv,
+// returning the system file path and the bank file paths
+func WriteFiles(cfg Config, dir, prefix string) (string, []string, error) {
+	systemTrxs, bankStmtLines, err := Generate(cfg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	systemFile := filepath.Join(dir, prefix+"_system.csv")
+	if err := writeFile(systemFile, func(w io.Writer) error { return WriteSystemCSV(w, systemTrxs) }); err != nil {
+		return "", nil, err
+	}
+
+	linesByBank := make(map[string][]models.BankStatementLine)
+	for _, stmtLine := range bankStmtLines {
+		linesByBank[stmtLine.BankName] = append(linesByBank[stmtLine.BankName], stmtLine)
+	}
+
+	var bankFiles []string
+	for _, bankName := range cfg.BankNames() {
+		stmtLines := linesByBank[bankName]
+		if len(stmtLines) == 0 {
+			continue
+		}
+		bankFile := filepath.Join(dir, prefix+"_"+bankName+".csv")
+		if err := writeFile(bankFile, func(w io.Writer) error { return WriteBankCSV(w, stmtLines) }); err != nil {
+			return "", nil, err
+		}
+		bankFiles = append(bankFiles, bankFile)
+	}
+
+	return systemFile, bankFiles, nil
+}
+
+// WriteSystemCSV writes system transactions in the trxID,amount,type,transactionTime format
+func WriteSystemCSV(w io.Writer, transactions []models.Transaction) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"trxID", "amount", "type", "transactionTime"}); err != nil {
+		return err
+	}
+	for _, trx := range transactions {
+		record := []string{trx.TrxID, trx.Amount.StringFixed(2), string(trx.Type), trx.TransactionTime.Format("2006-01-02 15:04:05")}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteBankCSV writes bank statement lines in the unique_identifier,amount,date format
+func WriteBankCSV(w io.Writer, statementLines []models.BankStatementLine) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"unique_identifier", "amount", "date"}); err != nil {
+		return err
+	}
+	for _, stmtLine := range statementLines {
+		record := []string{stmtLine.UniqueIdentifier, stmtLine.Amount.StringFixed(2), stmtLine.Date.Format("2006-01-02")}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeFile(path string, write func(w io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer file.Close()
+
+	buffered := bufio.NewWriter(file)
+	if err := write(buffered); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+func bankLineFor(trx models.Transaction, bankName string) models.BankStatementLine {
+	amount := trx.Amount
+	if trx.Type == models.TransactionTypeDebit {
+		amount = amount.Neg()
+	}
+	return models.BankStatementLine{
+		Amount:   amount,
+		Type:     trx.Type,
+		Date:     calendar.StartOfDay(trx.TransactionTime),
+		BankName: bankName,
+	}
+}
+
+// randomAmount returns an amount between 1.000 and 10.000.000, mostly rounded to the thousand
+func randomAmount(rng *rand.Rand) decimal.Decimal {
+	thousands := rng.Int63n(10_000) + 1
+	amount := decimal.NewFromInt(thousands * 1000)
+	if rng.Intn(10) == 0 {
+		amount = amount.Add(decimal.New(rng.Int63n(100_000), -2))
+	}
+	return amount
+}
+
+// randomType returns CREDIT for roughly 70% of transactions
+func randomType(rng *rand.Rand) models.TransactionType {
+	if rng.Intn(10) < 7 {
+		return models.TransactionTypeCredit
+	}
+	return models.TransactionTypeDebit
+}
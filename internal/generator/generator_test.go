@@ -0,0 +1,139 @@
+package generator_test
+
+import (
+	"testing"
+
+	"github.com/firmannf/recon/internal/generator"
+	"github.com/firmannf/recon/internal/parser"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(cfg *generator.Config)
+		shouldFail bool
+	}{
+		{
+			name:   "default config",
+			modify: func(cfg *generator.Config) {},
+		},
+		{
+			name: "everything matched across many banks",
+			modify: func(cfg *generator.Config) {
+				cfg.MatchRate = 1
+				cfg.Banks = 8
+			},
+		},
+		{
+			name: "invalid match rate",
+			modify: func(cfg *generator.Config) {
+				cfg.MatchRate = 1.5
+			},
+			shouldFail: true,
+		},
+		{
+			name: "no banks",
+			modify: func(cfg *generator.Config) {
+				cfg.Banks = 0
+			},
+			shouldFail: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := generator.DefaultConfig(1000)
+			tt.modify(&cfg)
+
+			systemTrxs, bankStmtLines, err := generator.Generate(cfg)
+			if tt.shouldFail {
+				if err == nil {
+					t.Error("Expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			if len(systemTrxs) != cfg.Rows {
+				t.Errorf("Expected %d system transactions, got %d", cfg.Rows, len(systemTrxs))
+			}
+			if len(bankStmtLines) != cfg.Rows {
+				t.Errorf("Expected %d bank statement lines, got %d", cfg.Rows, len(bankStmtLines))
+			}
+
+			for i := 1; i < len(systemTrxs); i++ {
+				if systemTrxs[i].TransactionTime.Before(systemTrxs[i-1].TransactionTime) {
+					t.Fatalf("Expected system transactions sorted by time, row %d is out of order", i)
+				}
+			}
+
+			end := cfg.StartDate.AddDate(0, 0, cfg.DateSpanDays)
+			for _, trx := range systemTrxs {
+				if trx.TransactionTime.Before(cfg.StartDate) || !trx.TransactionTime.Before(end) {
+					t.Fatalf("Expected %s within the configured date span, got %v", trx.TrxID, trx.TransactionTime)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerate_Deterministic(t *testing.T) {
+	cfg := generator.DefaultConfig(500)
+
+	firstSystem, firstBank, err := generator.Generate(cfg)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	secondSystem, secondBank, err := generator.Generate(cfg)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(firstSystem) != len(secondSystem) {
+		t.Fatalf("Expected %d system transactions with the same seed, got %d", len(firstSystem), len(secondSystem))
+	}
+	if len(firstBank) != len(secondBank) {
+		t.Fatalf("Expected %d bank statement lines with the same seed, got %d", len(firstBank), len(secondBank))
+	}
+	for i := range firstSystem {
+		if firstSystem[i].TrxID != secondSystem[i].TrxID || !firstSystem[i].Amount.Equal(secondSystem[i].Amount) {
+			t.Fatalf("Expected identical system transaction at row %d with the same seed", i)
+		}
+	}
+	for i := range firstBank {
+		if firstBank[i].UniqueIdentifier != secondBank[i].UniqueIdentifier || !firstBank[i].Amount.Equal(secondBank[i].Amount) {
+			t.Fatalf("Expected identical bank statement line at row %d with the same seed", i)
+		}
+	}
+}
+
+func TestWriteFiles_ParsableOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := generator.DefaultConfig(200)
+
+	systemFile, bankFiles, err := generator.WriteFiles(cfg, tmpDir, "synthetic")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(bankFiles) != cfg.Banks {
+		t.Errorf("Expected %d bank files, got %d", cfg.Banks, len(bankFiles))
+	}
+
+	transactions, err := parser.NewTransactionParser().ParseCSV(systemFile)
+	if err != nil {
+		t.Fatalf("Expected generated system file to parse, got: %v", err)
+	}
+	if len(transactions) != cfg.Rows {
+		t.Errorf("Expected %d system transactions, got %d", cfg.Rows, len(transactions))
+	}
+
+	statementLines, err := parser.NewBankStatementParser().ParseMultipleCSVs(bankFiles)
+	if err != nil {
+		t.Fatalf("Expected generated bank files to parse, got: %v", err)
+	}
+	if len(statementLines) != cfg.Rows {
+		t.Errorf("Expected %d bank statement lines, got %d", cfg.Rows, len(statementLines))
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+// CandidateStatus describes what happened to a bank statement line found for a system transaction
+type CandidateStatus string
+
+const (
+	CandidateMatched     CandidateStatus = "matched"      // Paired with the system transaction
+	CandidateConsumed    CandidateStatus = "consumed"     // Already paired with something else
+	CandidateRejected    CandidateStatus = "rejected"     // Shares the key but failed the strategy's IsMatch
+	CandidateNotSelected CandidateStatus = "not selected" // Eligible, but another candidate was paired instead
+)
+
+// MatchExplanation describes how a system transaction went through matching
+type MatchExplanation struct {
+	SystemTransaction Transaction
+	Passes            []PassExplanation // Passes that tried to match the transaction, in order
+	Outcome           string            // How the transaction ended up, e.g. matched by a pass or unmatched
+}
+
+// PassExplanation describes the lookup of a system transaction in one matching pass
+type PassExplanation struct {
+	Pass       string
+	Key        string // Index key built for the system transaction
+	Candidates []CandidateExplanation
+}
+
+// CandidateExplanation describes a bank statement line that shares the key of the system transaction
+type CandidateExplanation struct {
+	BankStatementLine BankStatementLine
+	Status            CandidateStatus
+	Reason            string // Why the candidate was consumed, rejected or not selected
+}
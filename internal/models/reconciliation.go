@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"github.com/shopspring/decimal"
 )
 
@@ -11,7 +13,179 @@ type ReconciliationResult struct {
 	TotalTransactionsProcessed  int
 	TotalMatchedTransactions    int
 	TotalUnmatchedTransactions  int
+	Matches                     []MatchedTransaction
+	BatchMatches                []GroupedMatch      // Bank statement lines settling several system transactions
+	SplitMatches                []GroupedMatch      // System transactions executed as several bank statement lines
+	SystemReversals             []SystemReversal    // Offsetting system transactions excluded from matching
+	BankReversals               []BankReversal      // Offsetting bank statement lines excluded from matching
+	InternalTransfers           []InternalTransfer  // Transfers between our own bank accounts, excluded from the unmatched lines
+	SystemDuplicates            []SystemDuplicate   // System transactions that look like repeats of each other
+	BankDuplicates              []BankDuplicate     // Bank statement lines that look like repeats of each other
+	FileFingerprints            []FileFingerprint   // Bank statement files of the run, set when file checks are enabled
+	FileOverlaps                []FileOverlap       // Bank statement files that repeat another input or a previous run
+	BalanceGaps                 []BalanceGap        // Places where the bank balances do not add up, a sign of missing lines
+	BankCoverage                []BankCoverage      // Date span covered by each bank statement file, set when coverage is checked
+	CoverageGaps                []CoverageGap       // Parts of the reconciliation period a bank statement file does not cover
+	NotYetReconcilable          []Transaction       // Unmatched system transactions outside every bank's coverage, not counted as unmatched
+	PassSummaries               []PassSummary       // Matches per matching pass, in pipeline order
+	StrategiesByBank            map[string][]string // Names of the passes applied to the lines of each bank, in order
 	UnmatchedSystemTransactions []Transaction
 	UnmatchedBankStatementLines map[string][]BankStatementLine // Grouped by bank
+	SystemNearMisses            [][]NearMiss                   // Suggested counterparts per unmatched system transaction, same order as UnmatchedSystemTransactions
+	BankNearMisses              map[string][][]NearMiss        // Suggested counterparts per unmatched bank statement line, same order as UnmatchedBankStatementLines
 	TotalDiscrepancies          decimal.Decimal
+	TotalFees                   decimal.Decimal // Bank fees explaining the difference between gross and net amounts
+	Statistics                  RunStatistics
+}
+
+// MatchedTransaction represents a system transaction paired with a bank statement line
+type MatchedTransaction struct {
+	SystemTransaction  Transaction
+	BankStatementLine  BankStatementLine
+	Discrepancy        decimal.Decimal // Absolute amount difference between the pair not explained by the fee
+	Fee                decimal.Decimal // Bank fee charged on the transaction, zero unless matched by a fee-aware strategy
+	ReferenceConfirmed bool            // Matched through a reference found in the bank statement line
+	Pass               string          // Name of the matching pass that produced the match
+}
+
+// GroupedMatch represents system transactions and bank statement lines matched as a group
+// because their totals agree, e.g. a daily settlement covering many payments
+type GroupedMatch struct {
+	SystemTransactions []Transaction
+	BankStatementLines []BankStatementLine
+	Discrepancy        decimal.Decimal // Absolute difference between the system total and the bank total
+}
+
+// SystemReversal represents a system transaction offset by a later one, e.g. a payment and its refund
+type SystemReversal struct {
+	Original Transaction
+	Reversal Transaction
+}
+
+// BankReversal represents a bank statement line offset by a later one from the same bank,
+// e.g. a failed transfer debit and its reversal credit
+type BankReversal struct {
+	Original BankStatementLine
+	Reversal BankStatementLine
+}
+
+// InternalTransfer represents funds moved between two of our own bank accounts,
+// seen as a debit in one bank statement and a credit in another
+type InternalTransfer struct {
+	Debit  BankStatementLine
+	Credit BankStatementLine
+}
+
+// DuplicateKind describes how a group of duplicates was detected
+type DuplicateKind string
+
+const (
+	DuplicateSameIdentifier DuplicateKind = "same identifier"
+	DuplicateSameAmountTime DuplicateKind = "same amount and time"
+)
+
+// SystemDuplicate is a group of system transactions that look like the same entry, in file order
+type SystemDuplicate struct {
+	Kind         DuplicateKind
+	Transactions []Transaction
+	Removed      int // Exact repeats of the first transaction dropped before matching
+}
+
+// BankDuplicate is a group of bank statement lines from one bank that look like the same entry, in file order
+type BankDuplicate struct {
+	Kind               DuplicateKind
+	BankStatementLines []BankStatementLine
+	Removed            int // Exact repeats of the first line dropped before matching
+}
+
+// FileFingerprint identifies the content of a bank statement file
+type FileFingerprint struct {
+	Path        string
+	Account     string // Bank name derived from the file name
+	ContentHash string // SHA-256 of the file bytes
+	RowsHash    string // SHA-256 of the sorted rows, equal for the same statement saved in another row order or format
+	Rows        int
+	FirstDate   time.Time // Earliest bank date in the file
+	LastDate    time.Time // Latest bank date in the file
+}
+
+// FileOverlap is a bank statement file whose rows were already seen in another input of the run
+// or in a file processed by a previous run
+type FileOverlap struct {
+	File        FileFingerprint
+	Other       FileFingerprint
+	Overlap     float64   // Share of the rows of the smaller file found in the other one, 1 for the same content
+	PreviousRun time.Time // When the other file was processed, zero when it is an input of this run
+}
+
+// BalanceGapKind tells which balance failed to add up
+type BalanceGapKind string
+
+const (
+	BalanceGapRunning BalanceGapKind = "running balance" // A line balance does not follow from the previous balance
+	BalanceGapClosing BalanceGapKind = "closing balance" // The closing balance does not follow from the last balance
+)
+
+// BalanceGap is a point in a bank statement where the balance differs from the previous balance
+// plus the amounts of the lines in between, meaning lines are missing or amounts are wrong
+type BalanceGap struct {
+	Kind              BalanceGapKind
+	BankName          string
+	BankStatementLine BankStatementLine // The line whose balance does not add up, zero for the closing balance
+	Expected          decimal.Decimal   // Previous balance plus the amounts in between
+	Actual            decimal.Decimal   // Balance reported by the bank
+}
+
+// BankCoverage is the span of calendar days covered by the lines of one bank statement file
+type BankCoverage struct {
+	BankName  string
+	FirstDate time.Time
+	LastDate  time.Time
+}
+
+// CoverageGap is a span of calendar days of the reconciliation period, both inclusive, that a
+// bank statement file does not cover
+type CoverageGap struct {
+	BankName string
+	From     time.Time
+	To       time.Time
+}
+
+// NearMissReason describes the single difference that kept a near miss from matching
+type NearMissReason string
+
+const (
+	NearMissDateDifference   NearMissReason = "same amount, different date"
+	NearMissAmountDifference NearMissReason = "same date, amount difference"
+	NearMissSwappedType      NearMissReason = "same amount and date, swapped type"
+)
+
+// NearMiss is an unmatched system transaction and bank statement line that differ in a single way
+type NearMiss struct {
+	SystemTransaction Transaction
+	BankStatementLine BankStatementLine
+	Reason            NearMissReason
+}
+
+// PassSummary holds the number of matches produced by a matching pass
+type PassSummary struct {
+	Name    string
+	Bank    string // Bank of a per-bank pass, empty when the pass applies to all other banks
+	Matched int
+}
+
+// RunStatistics holds per-phase timings and resource usage of a reconciliation run,
+// recorded when the input sets CollectStatistics apart from the index counters
+type RunStatistics struct {
+	ParseDurations     map[string]time.Duration // Keyed by file path
+	ParseDuration      time.Duration            // Wall time of the whole parse phase
+	FilterDuration     time.Duration
+	IndexBuildDuration time.Duration
+	MatchDuration      time.Duration
+	TotalDuration      time.Duration
+	RowsParsed         int
+	RowsPerSecond      float64 // Rows parsed per second of the parse phase
+	PeakHeapBytes      uint64  // Largest heap sampled during the run, sampled every few milliseconds
+	IndexKeys          int
+	IndexCollisions    int // Keys shared by more than one bank statement line
 }
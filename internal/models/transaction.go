@@ -20,6 +20,8 @@ type Transaction struct {
 	Amount          decimal.Decimal
 	Type            TransactionType
 	TransactionTime time.Time
+	Channel         string // Optional payment channel, e.g. QRIS or CARD, used to select fee rules
+	Counterparty    string // Optional payer or payee name
 }
 
 // BankStatementLine represents an entry in bank statement file
@@ -28,10 +30,22 @@ type BankStatementLine struct {
 	Amount           decimal.Decimal // Can be negative for debit
 	Type             TransactionType // Derived from amount sign
 	Date             time.Time
+	HasTime          bool // Date includes the booking time, from the date or the optional time column
 	BankName         string
+	Description      string          // Optional narrative, may contain references such as TrxID or invoice number
+	Counterparty     string          // Optional payer or payee name, when the bank reports it apart from the narrative
+	Balance          decimal.Decimal // Running account balance after the line, only set when HasBalance
+	HasBalance       bool
 }
 
 // GetAbsoluteAmount returns the absolute value of the amount
 func (bs *BankStatementLine) GetAbsoluteAmount() decimal.Decimal {
 	return bs.Amount.Abs()
 }
+
+// StatementBalances are the opening and closing balances of one bank statement, as found in
+// statement headers and footers. Either may be nil when unknown.
+type StatementBalances struct {
+	Opening *decimal.Decimal
+	Closing *decimal.Decimal
+}
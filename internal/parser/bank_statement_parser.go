@@ -1,7 +1,10 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"runtime"
+	"sync"
 	"time"
 	_ "time/tzdata"
 
@@ -12,7 +15,9 @@ import (
 
 // BankStatementParser handles parsing of bank statement CSV files
 type BankStatementParser struct {
-	timezone *time.Location
+	timezone      *time.Location
+	maxWorkers    int
+	strictAmounts bool
 }
 
 // NewBankStatementParser creates a new BankStatementParser with UTC+7 timezone
@@ -24,18 +29,25 @@ func NewBankStatementParser() *BankStatementParser {
 		loc = time.FixedZone("UTC+7", 7*60*60)
 	}
 	return &BankStatementParser{
-		timezone: loc,
+		timezone:   loc,
+		maxWorkers: runtime.NumCPU(),
 	}
 }
 
 // ParseCSV reads and parses a bank statement CSV file
-// Expected CSV format: unique_identifier,amount,date
+// Expected CSV format: unique_identifier,amount,date followed by optional columns (e.g. description, time, balance)
 func (p *BankStatementParser) ParseCSV(filePath string) ([]models.BankStatementLine, error) {
 	records, err := readCSVFile(filePath)
 	if err != nil {
 		return nil, err
 	}
 
+	optionalCols, err := parseOptionalColumns(records[0], bankStatementColumnCount, bankStatementOptionalColumns)
+	if err != nil {
+		return nil, err
+	}
+	columnCount := bankStatementColumnCount + len(optionalCols)
+
 	// Extract bank name for grouping from the file path
 	bankName := extractFileName(filePath)
 
@@ -43,20 +55,42 @@ func (p *BankStatementParser) ParseCSV(filePath string) ([]models.BankStatementL
 
 	// Skip header row
 	for i, record := range records[1:] {
-		if len(record) != bankStatementColumnCount {
-			return nil, fmt.Errorf("invalid record at row %d: expected %d columns, got %d", i+2, bankStatementColumnCount, len(record))
+		if len(record) != columnCount {
+			return nil, fmt.Errorf("invalid record at row %d: expected %d columns, got %d", i+2, columnCount, len(record))
 		}
 
-		amount, err := decimal.NewFromString(record[bankStatementColAmount])
+		amount, err := parseAmount(record[bankStatementColAmount], p.strictAmounts)
 		if err != nil {
 			return nil, fmt.Errorf("invalid amount at row %d: %w", i+2, err)
 		}
 
-		date, err := parseDate(record[bankStatementColDate], p.timezone)
+		date, hasTime, err := parseDateTime(record[bankStatementColDate], p.timezone)
 		if err != nil {
 			return nil, fmt.Errorf("invalid date at row %d: %w", i+2, err)
 		}
 
+		// Some exports carry the booking time in a separate column
+		if timeStr := optionalValue(record, optionalCols, bankStatementColTime); timeStr != "" {
+			if hasTime {
+				return nil, fmt.Errorf("invalid time at row %d: the date %q already has a time", i+2, record[bankStatementColDate])
+			}
+			date, err = withTimeOfDay(date, timeStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid time at row %d: %w", i+2, err)
+			}
+			hasTime = true
+		}
+
+		// The running balance is what reveals lines missing from the statement
+		var balance decimal.Decimal
+		balanceStr := optionalValue(record, optionalCols, bankStatementColBalance)
+		if balanceStr != "" {
+			balance, err = parseAmount(balanceStr, p.strictAmounts)
+			if err != nil {
+				return nil, fmt.Errorf("invalid balance at row %d: %w", i+2, err)
+			}
+		}
+
 		// Derive transaction type from amount sign
 		trxType := models.TransactionTypeCredit
 		if amount.IsNegative() {
@@ -68,24 +102,94 @@ func (p *BankStatementParser) ParseCSV(filePath string) ([]models.BankStatementL
 			Amount:           amount,
 			Type:             trxType,
 			Date:             date,
+			HasTime:          hasTime,
 			BankName:         bankName,
+			Description:      optionalValue(record, optionalCols, bankStatementColDescription),
+			Counterparty:     optionalValue(record, optionalCols, bankStatementColCounterparty),
+			Balance:          balance,
+			HasBalance:       balanceStr != "",
 		})
 	}
 
 	return statementLines, nil
 }
 
-// ParseMultipleCSVs reads and parses multiple bank statement CSV files
+// FileParsedFunc is called after a file has been parsed successfully.
+// It may be called concurrently from multiple goroutines.
+type FileParsedFunc func(filePath string, rows int, elapsed time.Duration)
+
+// ParseMultipleCSVs reads and parses multiple bank statement CSV files concurrently.
+// Statement lines are returned in the same order as filePaths regardless of which
+// file finishes first, and every per-file error is returned together.
 func (p *BankStatementParser) ParseMultipleCSVs(filePaths []string) ([]models.BankStatementLine, error) {
-	var allStatementLines []models.BankStatementLine
+	return p.ParseMultipleCSVsWithProgress(filePaths, nil)
+}
 
-	for _, filePath := range filePaths {
-		statementLines, err := p.ParseCSV(filePath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse %s: %w", filePath, err)
-		}
+// ParseMultipleCSVsWithProgress behaves like ParseMultipleCSVs and calls onFileParsed
+// (when not nil) as soon as each file has been parsed
+func (p *BankStatementParser) ParseMultipleCSVsWithProgress(filePaths []string, onFileParsed FileParsedFunc) ([]models.BankStatementLine, error) {
+	results, err := p.ParseCSVsPerFile(filePaths, onFileParsed)
+	if err != nil {
+		return nil, err
+	}
+
+	var allStatementLines []models.BankStatementLine
+	for _, statementLines := range results {
 		allStatementLines = append(allStatementLines, statementLines...)
 	}
 
 	return allStatementLines, nil
 }
+
+// ParseCSVsPerFile behaves like ParseMultipleCSVsWithProgress but keeps the statement lines
+// of each file apart, results[i] holds the lines of filePaths[i]
+func (p *BankStatementParser) ParseCSVsPerFile(filePaths []string, onFileParsed FileParsedFunc) ([][]models.BankStatementLine, error) {
+	results := make([][]models.BankStatementLine, len(filePaths))
+	errs := make([]error, len(filePaths))
+
+	workers := min(p.maxWorkers, len(filePaths))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				startTime := time.Now()
+				statementLines, err := p.ParseCSV(filePaths[i])
+				if err != nil {
+					errs[i] = fmt.Errorf("failed to parse %s: %w", filePaths[i], err)
+					continue
+				}
+				results[i] = statementLines
+				if onFileParsed != nil {
+					onFileParsed(filePaths[i], len(statementLines), time.Since(startTime))
+				}
+			}
+		}()
+	}
+
+	for i := range filePaths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// SetMaxWorkers sets the number of files parsed concurrently by ParseMultipleCSVs.
+// Values below 1 are treated as 1.
+func (p *BankStatementParser) SetMaxWorkers(n int) {
+	p.maxWorkers = max(n, 1)
+}
+
+// SetStrictAmounts makes ParseCSV reject amounts and balances with more than 2 decimal places
+// or 15 integer digits.
+func (p *BankStatementParser) SetStrictAmounts(strict bool) {
+	p.strictAmounts = strict
+}
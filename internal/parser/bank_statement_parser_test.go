@@ -1,8 +1,10 @@
 package parser_test
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/shopspring/decimal"
@@ -54,6 +56,99 @@ BANK-001,1000.00,2024-01-15`,
 			expectedCount:    1,
 			expectedBankName: "bank_mandiri",
 		},
+		{
+			name: "optional description column",
+			csvContent: `unique_identifier,amount,date,Description
+BANK-001,1000.00,2024-01-15, TRF INV-001 PT MAJU `,
+			fileName:         "bank.csv",
+			expectedCount:    1,
+			expectedBankName: "bank",
+			verify: func(t *testing.T, statementLines []models.BankStatementLine) {
+				if statementLines[0].Description != "TRF INV-001 PT MAJU" {
+					t.Errorf("Expected description 'TRF INV-001 PT MAJU', got '%s'", statementLines[0].Description)
+				}
+			},
+		},
+		{
+			name: "optional counterparty column",
+			csvContent: `unique_identifier,amount,date,description,Counterparty
+BANK-001,1000.00,2024-01-15,TRF INV-001,BPK BUDI SANTOSO`,
+			fileName:         "bank.csv",
+			expectedCount:    1,
+			expectedBankName: "bank",
+			verify: func(t *testing.T, statementLines []models.BankStatementLine) {
+				if statementLines[0].Counterparty != "BPK BUDI SANTOSO" {
+					t.Errorf("Expected counterparty 'BPK BUDI SANTOSO', got '%s'", statementLines[0].Counterparty)
+				}
+			},
+		},
+		{
+			name: "time in date column",
+			csvContent: `unique_identifier,amount,date
+BANK-001,1000.00,2024-01-15 14:30:05
+BANK-002,1000.00,2024-01-15`,
+			fileName:         "bank.csv",
+			expectedCount:    2,
+			expectedBankName: "bank",
+			verify: func(t *testing.T, statementLines []models.BankStatementLine) {
+				if !statementLines[0].HasTime || statementLines[0].Date.Hour() != 14 || statementLines[0].Date.Second() != 5 {
+					t.Errorf("Expected 14:30:05 with time, got %v (has time %v)", statementLines[0].Date, statementLines[0].HasTime)
+				}
+				if statementLines[1].HasTime {
+					t.Error("Expected a date-only line without time")
+				}
+			},
+		},
+		{
+			name: "optional time column",
+			csvContent: `unique_identifier,amount,date,time
+BANK-001,1000.00,15/01/2024, 09:05
+BANK-002,1000.00,15/01/2024,`,
+			fileName:         "bank.csv",
+			expectedCount:    2,
+			expectedBankName: "bank",
+			verify: func(t *testing.T, statementLines []models.BankStatementLine) {
+				if !statementLines[0].HasTime || statementLines[0].Date.Day() != 15 || statementLines[0].Date.Hour() != 9 || statementLines[0].Date.Minute() != 5 {
+					t.Errorf("Expected 2024-01-15 09:05 with time, got %v (has time %v)", statementLines[0].Date, statementLines[0].HasTime)
+				}
+				if statementLines[1].HasTime {
+					t.Error("Expected an empty time to leave the line date-only")
+				}
+			},
+		},
+		{
+			name: "optional balance column",
+			csvContent: `unique_identifier,amount,date,balance
+BANK-001,1000.00,2024-01-15,11000.00
+BANK-002,-500.00,2024-01-15,`,
+			fileName:         "bank.csv",
+			expectedCount:    2,
+			expectedBankName: "bank",
+			verify: func(t *testing.T, statementLines []models.BankStatementLine) {
+				if !statementLines[0].HasBalance || !statementLines[0].Balance.Equal(decimal.NewFromInt(11000)) {
+					t.Errorf("Expected balance 11000, got %s (has balance %v)", statementLines[0].Balance, statementLines[0].HasBalance)
+				}
+				if statementLines[1].HasBalance {
+					t.Error("Expected an empty balance to leave the line without balance")
+				}
+			},
+		},
+		{
+			name: "padded amount and date",
+			csvContent: `unique_identifier,amount,date
+BANK-001, 1000.500 , 2024-01-15 `,
+			fileName:         "bank.csv",
+			expectedCount:    1,
+			expectedBankName: "bank",
+			verify: func(t *testing.T, statementLines []models.BankStatementLine) {
+				if !statementLines[0].Amount.Equal(decimal.NewFromFloat(1000.50)) {
+					t.Errorf("Expected amount 1000.50, got %s", statementLines[0].Amount)
+				}
+				if statementLines[0].Date.Day() != 15 {
+					t.Errorf("Expected 2024-01-15, got %v", statementLines[0].Date)
+				}
+			},
+		},
 		{
 			name: "format date YYYY-MM-DD",
 			csvContent: `unique_identifier,amount,date
@@ -129,76 +224,131 @@ BANK-001,1000.00,15-01-2024`,
 func TestBankStatementParser_ParseCSV_ErrorCases(t *testing.T) {
 	tests := []struct {
 		name       string
-		setupFile  func(tmpDir string) string
+		setupFile  func(t *testing.T, tmpDir string) string
 		shouldFail bool
 	}{
 		{
 			name: "file not found",
-			setupFile: func(tmpDir string) string {
+			setupFile: func(t *testing.T, tmpDir string) string {
 				return "/nonexistent/path/bank.csv"
 			},
 			shouldFail: true,
 		},
 		{
 			name: "non-CSV extension",
-			setupFile: func(tmpDir string) string {
+			setupFile: func(t *testing.T, tmpDir string) string {
 				txtPath := filepath.Join(tmpDir, "bank.txt")
-				os.WriteFile(txtPath, []byte("some content"), 0644)
+				writeTestFile(t, txtPath, "some content")
 				return txtPath
 			},
 			shouldFail: true,
 		},
 		{
 			name: "empty file - only header",
-			setupFile: func(tmpDir string) string {
+			setupFile: func(t *testing.T, tmpDir string) string {
 				csvPath := filepath.Join(tmpDir, "bank.csv")
-				os.WriteFile(csvPath, []byte("unique_identifier,amount,date\n"), 0644)
+				writeTestFile(t, csvPath, "unique_identifier,amount,date\n")
 				return csvPath
 			},
 			shouldFail: true,
 		},
 		{
 			name: "invalid amount",
-			setupFile: func(tmpDir string) string {
+			setupFile: func(t *testing.T, tmpDir string) string {
 				csvPath := filepath.Join(tmpDir, "bank.csv")
 				content := `unique_identifier,amount,date
 BANK-001,not-a-number,2024-01-15`
-				os.WriteFile(csvPath, []byte(content), 0644)
+				writeTestFile(t, csvPath, content)
+				return csvPath
+			},
+			shouldFail: true,
+		},
+		{
+			name: "amount out of range",
+			setupFile: func(t *testing.T, tmpDir string) string {
+				csvPath := filepath.Join(tmpDir, "bank.csv")
+				content := `unique_identifier,amount,date
+BANK-001,-1e100000000,2024-01-15`
+				writeTestFile(t, csvPath, content)
 				return csvPath
 			},
 			shouldFail: true,
 		},
 		{
 			name: "invalid date",
-			setupFile: func(tmpDir string) string {
+			setupFile: func(t *testing.T, tmpDir string) string {
 				csvPath := filepath.Join(tmpDir, "bank.csv")
 				content := `unique_identifier,amount,date
 BANK-001,1000.00,invalid-date`
-				os.WriteFile(csvPath, []byte(content), 0644)
+				writeTestFile(t, csvPath, content)
 				return csvPath
 			},
 			shouldFail: true,
 		},
 		{
 			name: "missing columns",
-			setupFile: func(tmpDir string) string {
+			setupFile: func(t *testing.T, tmpDir string) string {
 				csvPath := filepath.Join(tmpDir, "bank.csv")
 				content := `unique_identifier,amount,date
 BANK-001,1000.00`
-				os.WriteFile(csvPath, []byte(content), 0644)
+				writeTestFile(t, csvPath, content)
+				return csvPath
+			},
+			shouldFail: true,
+		},
+		{
+			name: "duplicate optional column",
+			setupFile: func(t *testing.T, tmpDir string) string {
+				csvPath := filepath.Join(tmpDir, "bank.csv")
+				content := `unique_identifier,amount,date,description,description
+BANK-001,1000.00,2024-01-15,a,b`
+				writeTestFile(t, csvPath, content)
+				return csvPath
+			},
+			shouldFail: true,
+		},
+		{
+			name: "invalid time",
+			setupFile: func(t *testing.T, tmpDir string) string {
+				csvPath := filepath.Join(tmpDir, "bank.csv")
+				content := `unique_identifier,amount,date,time
+BANK-001,1000.00,2024-01-15,25:00`
+				writeTestFile(t, csvPath, content)
+				return csvPath
+			},
+			shouldFail: true,
+		},
+		{
+			name: "time column with a datetime",
+			setupFile: func(t *testing.T, tmpDir string) string {
+				csvPath := filepath.Join(tmpDir, "bank.csv")
+				content := `unique_identifier,amount,date,time
+BANK-001,1000.00,2024-01-15 10:00:00,11:00:00`
+				writeTestFile(t, csvPath, content)
+				return csvPath
+			},
+			shouldFail: true,
+		},
+		{
+			name: "invalid balance",
+			setupFile: func(t *testing.T, tmpDir string) string {
+				csvPath := filepath.Join(tmpDir, "bank.csv")
+				content := `unique_identifier,amount,date,balance
+BANK-001,1000.00,2024-01-15,n/a`
+				writeTestFile(t, csvPath, content)
 				return csvPath
 			},
 			shouldFail: true,
 		},
 		{
 			name: "row count is not bank format standard",
-			setupFile: func(tmpDir string) string {
+			setupFile: func(t *testing.T, tmpDir string) string {
 				csvPath := filepath.Join(tmpDir, "bank.csv")
 				content := `unique_identifier,amount,date1,date2
 BANK-001,1000.00,2024-01-15,2024-01-15
 BANK-002,500.00,2024-01-15,2024-01-15
 BANK-003,250.00,2024-01-17,2024-01-17`
-				os.WriteFile(csvPath, []byte(content), 0644)
+				writeTestFile(t, csvPath, content)
 				return csvPath
 			},
 			shouldFail: true,
@@ -208,7 +358,7 @@ BANK-003,250.00,2024-01-17,2024-01-17`
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tmpDir := t.TempDir()
-			csvPath := tt.setupFile(tmpDir)
+			csvPath := tt.setupFile(t, tmpDir)
 
 			parser := parser.NewBankStatementParser()
 			_, err := parser.ParseCSV(csvPath)
@@ -223,6 +373,37 @@ BANK-003,250.00,2024-01-17,2024-01-17`
 	}
 }
 
+func TestBankStatementParser_ParseCSV_StrictAmounts(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount string
+	}{
+		{name: "more than 2 decimal places", amount: "-1000.005"},
+		{name: "more than 15 integer digits", amount: "1000000000000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csvPath := filepath.Join(t.TempDir(), "bank.csv")
+			writeTestFile(t, csvPath, "unique_identifier,amount,date\nBANK-001,"+tt.amount+",2024-01-15")
+
+			parser := parser.NewBankStatementParser()
+			statementLines, err := parser.ParseCSV(csvPath)
+			if err != nil {
+				t.Fatalf("Expected %s to be accepted without strict amounts, got: %v", tt.amount, err)
+			}
+			if !statementLines[0].Amount.Equal(mustDecimal(tt.amount)) {
+				t.Errorf("Expected amount %s, got %s", tt.amount, statementLines[0].Amount)
+			}
+
+			parser.SetStrictAmounts(true)
+			if _, err := parser.ParseCSV(csvPath); err == nil {
+				t.Errorf("Expected %s to be rejected with strict amounts", tt.amount)
+			}
+		})
+	}
+}
+
 func TestBankStatementLineParser_ParseMultipleCSVs(t *testing.T) {
 	tests := []struct {
 		name               string
@@ -317,3 +498,102 @@ BCA-001,1000.00,2024-01-15`
 		})
 	}
 }
+
+func TestBankStatementLineParser_ParseMultipleCSVs_Concurrency(t *testing.T) {
+	t.Run("preserves file order", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		var files []string
+		for i := 0; i < 20; i++ {
+			path := filepath.Join(tmpDir, fmt.Sprintf("bank_%02d.csv", i))
+			content := fmt.Sprintf("unique_identifier,amount,date\nBANK-%02d-A,100.00,2024-01-15\nBANK-%02d-B,200.00,2024-01-15", i, i)
+			writeTestFile(t, path, content)
+			files = append(files, path)
+		}
+
+		parser := parser.NewBankStatementParser()
+		parser.SetMaxWorkers(4)
+		statementLines, err := parser.ParseMultipleCSVs(files)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if len(statementLines) != 40 {
+			t.Fatalf("Expected 40 statements, got %d", len(statementLines))
+		}
+		for i, stmtLine := range statementLines {
+			expectedID := fmt.Sprintf("BANK-%02d-%c", i/2, 'A'+rune(i%2))
+			if stmtLine.UniqueIdentifier != expectedID {
+				t.Errorf("Expected statement %d to be '%s', got '%s'", i, expectedID, stmtLine.UniqueIdentifier)
+			}
+		}
+	})
+
+	t.Run("keeps files apart", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		bca := filepath.Join(tmpDir, "bank_bca.csv")
+		if err := os.WriteFile(bca, []byte("unique_identifier,amount,date\nBCA-001,1000.00,2024-01-15\nBCA-002,2000.00,2024-01-15"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		bri := filepath.Join(tmpDir, "bank_bri.csv")
+		if err := os.WriteFile(bri, []byte("unique_identifier,amount,date\nBRI-001,500.00,2024-01-15"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		parser := parser.NewBankStatementParser()
+		parser.SetMaxWorkers(2)
+		results, err := parser.ParseCSVsPerFile([]string{bca, bri, bca}, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		expectedCounts := []int{2, 1, 2}
+		if len(results) != len(expectedCounts) {
+			t.Fatalf("Expected %d files, got %d", len(expectedCounts), len(results))
+		}
+		for i, expectedCount := range expectedCounts {
+			if len(results[i]) != expectedCount {
+				t.Errorf("Expected %d statements in file %d, got %d", expectedCount, i, len(results[i]))
+			}
+		}
+		if results[1][0].UniqueIdentifier != "BRI-001" {
+			t.Errorf("Expected BRI-001 in the second file, got %s", results[1][0].UniqueIdentifier)
+		}
+	})
+
+	t.Run("reports every failing file", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		valid := filepath.Join(tmpDir, "bank_bca.csv")
+		writeTestFile(t, valid, "unique_identifier,amount,date\nBCA-001,1000.00,2024-01-15")
+
+		invalid := filepath.Join(tmpDir, "bank_bri.csv")
+		writeTestFile(t, invalid, "unique_identifier,amount,date\nBRI-001,not-a-number,2024-01-15")
+
+		missing := filepath.Join(tmpDir, "bank_missing.csv")
+
+		parser := parser.NewBankStatementParser()
+		_, err := parser.ParseMultipleCSVs([]string{valid, invalid, missing})
+		if err == nil {
+			t.Fatal("Expected error but got nil")
+		}
+
+		for _, path := range []string{invalid, missing} {
+			if !strings.Contains(err.Error(), path) {
+				t.Errorf("Expected error to mention %s, got: %v", path, err)
+			}
+		}
+		if strings.Contains(err.Error(), valid) {
+			t.Errorf("Expected error not to mention %s, got: %v", valid, err)
+		}
+	})
+}
+
+// writeTestFile writes an input file for a test and stops the test when it cannot be written
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
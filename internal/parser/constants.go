@@ -6,6 +6,12 @@ const (
 	bankStatementColumnCount = 3 // unique_identifier, amount, date
 	headerRowCount           = 1
 
+	// Amount limits, amounts beyond maxAmountDigits integer digits or decimal places are always rejected,
+	// strict amounts are Rupiah with at most 2 decimal places and 15 integer digits
+	maxAmountDigits           = 100
+	strictAmountDecimalPlaces = 2
+	strictAmountIntegerDigits = 15
+
 	// Transaction CSV column indices
 	transactionColTrxID           = 0
 	transactionColAmount          = 1
@@ -16,4 +22,28 @@ const (
 	bankStatementColUniqueIdentifier = 0
 	bankStatementColAmount           = 1
 	bankStatementColDate             = 2
+
+	// Optional transaction CSV columns, identified by header name after the required columns
+	transactionColChannel      = "channel"
+	transactionColCounterparty = "counterparty"
+
+	// Optional bank statement CSV columns, identified by header name after the required columns
+	bankStatementColDescription  = "description"
+	bankStatementColCounterparty = "counterparty"
+	bankStatementColTime         = "time"
+	bankStatementColBalance      = "balance"
 )
+
+// transactionOptionalColumns lists the optional column headers accepted in transaction files
+var transactionOptionalColumns = []string{
+	transactionColChannel,
+	transactionColCounterparty,
+}
+
+// bankStatementOptionalColumns lists the optional column headers accepted in bank statement files
+var bankStatementOptionalColumns = []string{
+	bankStatementColDescription,
+	bankStatementColCounterparty,
+	bankStatementColTime,
+	bankStatementColBalance,
+}
@@ -5,33 +5,137 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
+// dateTimeFormats are the accepted formats with a time component
+var dateTimeFormats = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	"02-01-2006 15:04:05",
+	"02-01-2006 15:04",
+}
+
+// dateOnlyFormats are the accepted formats without a time component
+var dateOnlyFormats = []string{
+	"2006-01-02",
+	"02-01-2006",
+	"02/01/2006",
+}
+
+// timeOfDayFormats are the accepted formats of a separate time column
+var timeOfDayFormats = []string{
+	"15:04:05",
+	"15:04",
+}
+
 // parseDate tries to parse date/datetime in multiple formats with given timezone
 func parseDate(dateStr string, loc *time.Location) (time.Time, error) {
-	formats := []string{
-		// DateTime formats with time component
-		"2006-01-02 15:04:05",
-		"2006-01-02 15:04",
-		"02/01/2006 15:04:05",
-		"02/01/2006 15:04",
-		"02-01-2006 15:04:05",
-		"02-01-2006 15:04",
-		// Date-only formats
-		"2006-01-02",
-		"02-01-2006",
-		"02/01/2006",
-	}
-
-	for _, format := range formats {
+	t, _, err := parseDateTime(dateStr, loc)
+	return t, err
+}
+
+// parseDateTime parses a date or datetime and reports whether the value had a time component
+func parseDateTime(dateStr string, loc *time.Location) (time.Time, bool, error) {
+	dateStr = strings.TrimSpace(dateStr)
+	for _, format := range dateTimeFormats {
+		if t, err := time.ParseInLocation(format, dateStr, loc); err == nil {
+			return t, true, nil
+		}
+	}
+	for _, format := range dateOnlyFormats {
 		if t, err := time.ParseInLocation(format, dateStr, loc); err == nil {
-			return t, nil
+			return t, false, nil
+		}
+	}
+
+	return time.Time{}, false, fmt.Errorf("unable to parse date: %s", dateStr)
+}
+
+// withTimeOfDay sets the time of day of date from a time column value such as 14:30:05
+func withTimeOfDay(date time.Time, timeStr string) (time.Time, error) {
+	timeStr = strings.TrimSpace(timeStr)
+	for _, format := range timeOfDayFormats {
+		if t, err := time.Parse(format, timeStr); err == nil {
+			year, month, day := date.Date()
+			return time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), 0, date.Location()), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unable to parse time: %s", timeStr)
+}
+
+// parseAmount parses an amount, rejecting values with more than maxAmountDigits integer digits or
+// decimal places so inputs such as "1e100000000" are never materialized. Strict parsing also rejects
+// values with more than two decimal places or more integer digits than any real transaction can have
+func parseAmount(amountStr string, strict bool) (decimal.Decimal, error) {
+	amount, err := decimal.NewFromString(strings.TrimSpace(amountStr))
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	if amount.IsZero() {
+		return decimal.Zero, nil
+	}
+
+	maxIntegerDigits, maxDecimalPlaces := int64(maxAmountDigits), int64(maxAmountDigits)
+	if strict {
+		maxIntegerDigits, maxDecimalPlaces = strictAmountIntegerDigits, strictAmountDecimalPlaces
+	}
+
+	// Check magnitude from coefficient and exponent before doing any arithmetic. The digits are
+	// counted from the coefficient, NumDigits estimates them through a logarithm and is off at powers of ten
+	coefficient := amount.Coefficient()
+	digits := int64(len(coefficient.Abs(coefficient).String()))
+	exp := int64(amount.Exponent())
+	if digits+exp > maxIntegerDigits {
+		return decimal.Decimal{}, fmt.Errorf("amount out of range: %s", amountStr)
+	}
+	if exp < -maxDecimalPlaces {
+		// A coefficient with fewer digits than the excess decimal places cannot end in enough zeros
+		if -exp-maxDecimalPlaces > digits || !amount.Equal(amount.Truncate(int32(maxDecimalPlaces))) {
+			return decimal.Decimal{}, fmt.Errorf("amount has more than %d decimal places: %s", maxDecimalPlaces, amountStr)
+		}
+	}
+
+	return amount, nil
+}
+
+// parseOptionalColumns maps the header names after the required columns to their index.
+// Every extra column must be one of the supported optional columns and appear only once.
+func parseOptionalColumns(header []string, requiredCount int, supported []string) (map[string]int, error) {
+	if len(header) < requiredCount {
+		return nil, fmt.Errorf("invalid header: expected at least %d columns, got %d", requiredCount, len(header))
+	}
+
+	optionalCols := make(map[string]int)
+	for i := requiredCount; i < len(header); i++ {
+		name := strings.ToLower(strings.TrimSpace(header[i]))
+		if !slices.Contains(supported, name) {
+			return nil, fmt.Errorf("invalid header: unsupported column %q (supported optional columns: %s)", header[i], strings.Join(supported, ", "))
+		}
+		if _, exists := optionalCols[name]; exists {
+			return nil, fmt.Errorf("invalid header: duplicate column %q", header[i])
 		}
+		optionalCols[name] = i
 	}
 
-	return time.Time{}, fmt.Errorf("unable to parse date: %s", dateStr)
+	return optionalCols, nil
+}
+
+// optionalValue returns the trimmed value of an optional column, or "" when the column is absent
+func optionalValue(record []string, optionalCols map[string]int, name string) string {
+	idx, exists := optionalCols[name]
+	if !exists {
+		return ""
+	}
+	return strings.TrimSpace(record[idx])
 }
 
 // extractFileName extracts a file name without extension from the file path
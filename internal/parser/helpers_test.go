@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func FuzzParseDate(f *testing.F) {
+	for _, seed := range []string{
+		"2024-01-15 10:30:00",
+		"2024-01-15 10:30",
+		"15/01/2024 10:30:00",
+		"15/01/2024 10:30",
+		"15-01-2024 10:30:00",
+		"15-01-2024 10:30",
+		"2024-01-15",
+		"15-01-2024",
+		"15/01/2024",
+		"20240115",
+		"2024-02-30",
+		" 2024-01-15 10:30:00 ",
+	} {
+		f.Add(seed)
+	}
+
+	loc := time.FixedZone("UTC+7", 7*60*60)
+	f.Fuzz(func(t *testing.T, dateStr string) {
+		parsed, err := parseDate(dateStr, loc)
+		if err != nil {
+			return
+		}
+
+		if parsed.Location() != loc {
+			t.Errorf("Expected %q parsed in UTC+7, got %v", dateStr, parsed.Location())
+		}
+
+		// Formatting the parsed value with the default layout must parse back to the same instant
+		reformatted := parsed.Format("2006-01-02 15:04:05")
+		reparsed, err := parseDate(reformatted, loc)
+		if err != nil {
+			t.Fatalf("Failed to reparse %q (from %q): %v", reformatted, dateStr, err)
+		}
+		if !reparsed.Equal(parsed) {
+			t.Errorf("Expected %q to round trip to %v, got %v", dateStr, parsed, reparsed)
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package parser_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/firmannf/recon/internal/generator"
+	"github.com/firmannf/recon/internal/parser"
+)
+
+func BenchmarkTransactionParser_ParseCSV(b *testing.B) {
+	for _, rows := range generator.BenchmarkSizes() {
+		b.Run(fmt.Sprintf("rows=%d", rows), func(b *testing.B) {
+			systemFile, _, err := generator.WriteFiles(generator.DefaultConfig(rows), b.TempDir(), "bench")
+			if err != nil {
+				b.Fatalf("Failed to generate data: %v", err)
+			}
+
+			p := parser.NewTransactionParser()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := p.ParseCSV(systemFile); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(rows)*float64(b.N)/b.Elapsed().Seconds(), "rows/s")
+		})
+	}
+}
+
+func BenchmarkBankStatementParser_ParseMultipleCSVs(b *testing.B) {
+	for _, rows := range generator.BenchmarkSizes() {
+		b.Run(fmt.Sprintf("rows=%d", rows), func(b *testing.B) {
+			_, bankFiles, err := generator.WriteFiles(generator.DefaultConfig(rows), b.TempDir(), "bench")
+			if err != nil {
+				b.Fatalf("Failed to generate data: %v", err)
+			}
+
+			p := parser.NewBankStatementParser()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := p.ParseMultipleCSVs(bankFiles); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(rows)*float64(b.N)/b.Elapsed().Seconds(), "rows/s")
+		})
+	}
+}
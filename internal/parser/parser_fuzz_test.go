@@ -0,0 +1,114 @@
+package parser_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/firmannf/recon/internal/models"
+	"github.com/firmannf/recon/internal/parser"
+)
+
+// addTestdataSeeds adds every testdata file matching pattern to the fuzz corpus
+func addTestdataSeeds(f *testing.F, pattern string) {
+	files, err := filepath.Glob(filepath.Join("..", "..", "testdata", pattern))
+	if err != nil {
+		f.Fatalf("Failed to list testdata: %v", err)
+	}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatalf("Failed to read %s: %v", file, err)
+		}
+		f.Add(content)
+	}
+}
+
+func FuzzTransactionParser_ParseCSV(f *testing.F) {
+	addTestdataSeeds(f, "*_system.csv")
+	f.Add([]byte("trxID,amount,type,transactionTime\nTRX001,1000.00,credit,15/01/2024 10:30"))
+	f.Add([]byte("trxID,amount,type,transactionTime\nTRX001,1e5,DEBIT,2024-01-15"))
+	f.Add([]byte("trxID,amount,type,transactionTime\nTRX001,1e100000000,DEBIT,2024-01-15"))
+
+	f.Fuzz(func(t *testing.T, content []byte) {
+		csvPath := filepath.Join(t.TempDir(), "transactions.csv")
+		if err := os.WriteFile(csvPath, content, 0644); err != nil {
+			t.Fatalf("Failed to create test CSV: %v", err)
+		}
+
+		transactions, err := parser.NewTransactionParser().ParseCSV(csvPath)
+		if err != nil {
+			return
+		}
+
+		for _, trx := range transactions {
+			if trx.Type != models.TransactionTypeCredit && trx.Type != models.TransactionTypeDebit {
+				t.Errorf("Parsed invalid transaction type %q", trx.Type)
+			}
+			if trx.TransactionTime.IsZero() {
+				t.Errorf("Parsed zero transaction time for %s", trx.TrxID)
+			}
+		}
+
+		strictParser := parser.NewTransactionParser()
+		strictParser.SetStrictAmounts(true)
+		transactions, err = strictParser.ParseCSV(csvPath)
+		if err != nil {
+			return
+		}
+
+		for _, trx := range transactions {
+			if trx.Amount.IsNegative() {
+				t.Errorf("Parsed negative amount %s for %s", trx.Amount, trx.TrxID)
+			}
+			if !trx.Amount.Equal(trx.Amount.Truncate(2)) {
+				t.Errorf("Parsed amount %s with more than 2 decimal places", trx.Amount)
+			}
+		}
+	})
+}
+
+func FuzzBankStatementParser_ParseCSV(f *testing.F) {
+	addTestdataSeeds(f, "*_bank_*.csv")
+	f.Add([]byte("unique_identifier,amount,date\nBANK-001,-0.00,2024-01-15"))
+	f.Add([]byte("unique_identifier,amount,date\nBANK-001,-500.50,16-01-2024 14:22"))
+	f.Add([]byte("unique_identifier,amount,date\nBANK-001,1e-100000000,2024-01-15"))
+
+	f.Fuzz(func(t *testing.T, content []byte) {
+		csvPath := filepath.Join(t.TempDir(), "bank.csv")
+		if err := os.WriteFile(csvPath, content, 0644); err != nil {
+			t.Fatalf("Failed to create test CSV: %v", err)
+		}
+
+		statementLines, err := parser.NewBankStatementParser().ParseCSV(csvPath)
+		if err != nil {
+			return
+		}
+
+		for _, stmtLine := range statementLines {
+			expectedType := models.TransactionTypeCredit
+			if stmtLine.Amount.IsNegative() {
+				expectedType = models.TransactionTypeDebit
+			}
+			if stmtLine.Type != expectedType {
+				t.Errorf("Expected type %s for amount %s, got %s", expectedType, stmtLine.Amount, stmtLine.Type)
+			}
+			if stmtLine.BankName != "bank" {
+				t.Errorf("Expected bank name 'bank', got '%s'", stmtLine.BankName)
+			}
+		}
+
+		strictParser := parser.NewBankStatementParser()
+		strictParser.SetStrictAmounts(true)
+		statementLines, err = strictParser.ParseCSV(csvPath)
+		if err != nil {
+			return
+		}
+
+		for _, stmtLine := range statementLines {
+			if !stmtLine.Amount.Equal(stmtLine.Amount.Truncate(2)) {
+				t.Errorf("Parsed amount %s with more than 2 decimal places", stmtLine.Amount)
+			}
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/models"
+)
+
+// mt940BalancePattern matches the value of a balance field such as C240115IDR1000,00:
+// debit/credit mark, YYMMDD date, currency and an amount with a decimal comma
+var mt940BalancePattern = regexp.MustCompile(`^([CD])(\d{6})([A-Z]{3})(\d+(?:,\d*)?)$`)
+
+// camtDocument holds the balances of the statements in a camt.053 file, namespaces are ignored
+type camtDocument struct {
+	Statements []struct {
+		Balances []camtBalance `xml:"Bal"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtBalance struct {
+	Code      string `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    string `xml:"Amt"`
+	Indicator string `xml:"CdtDbtInd"`
+}
+
+// ParseStatementBalances reads the opening and closing balances of an MT940 or camt.053 statement.
+// MT940 files use the :60F: and :62F: fields, camt.053 files the OPBD and CLBD balances. The format
+// is recognised from the content, an XML document is read as camt.053. When the file holds several
+// statements the opening balance of the first and the closing balance of the last are used.
+func ParseStatementBalances(filePath string) (models.StatementBalances, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return models.StatementBalances{}, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	var balances models.StatementBalances
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("<")) {
+		balances, err = parseCamtBalances(content)
+	} else {
+		balances, err = parseMT940Balances(content)
+	}
+	if err != nil {
+		return models.StatementBalances{}, err
+	}
+
+	if balances.Opening == nil && balances.Closing == nil {
+		return models.StatementBalances{}, fmt.Errorf("no opening or closing balance found")
+	}
+	return balances, nil
+}
+
+// parseMT940Balances reads the first :60F: and the last :62F: field
+func parseMT940Balances(content []byte) (models.StatementBalances, error) {
+	var balances models.StatementBalances
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, ":") {
+			continue
+		}
+		tag, value, _ := strings.Cut(line[1:], ":")
+		if tag != "60F" && tag != "62F" {
+			continue
+		}
+
+		amount, err := parseMT940Balance(value)
+		if err != nil {
+			return models.StatementBalances{}, fmt.Errorf("invalid :%s: balance at line %d: %w", tag, lineNumber, err)
+		}
+		if tag == "60F" && balances.Opening == nil {
+			balances.Opening = &amount
+		}
+		if tag == "62F" {
+			balances.Closing = &amount
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return models.StatementBalances{}, fmt.Errorf("failed to read MT940: %w", err)
+	}
+	return balances, nil
+}
+
+// parseMT940Balance returns the amount of a balance field, negative for a debit balance
+func parseMT940Balance(value string) (decimal.Decimal, error) {
+	parts := mt940BalancePattern.FindStringSubmatch(value)
+	if parts == nil {
+		return decimal.Zero, fmt.Errorf("%q, expected a mark, date, currency and amount such as C240115IDR1000,00", value)
+	}
+
+	amount, err := decimal.NewFromString(strings.TrimSuffix(strings.Replace(parts[4], ",", ".", 1), "."))
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("%q: %w", value, err)
+	}
+	if parts[1] == "D" {
+		amount = amount.Neg()
+	}
+	return amount, nil
+}
+
+// parseCamtBalances reads the first OPBD and the last CLBD balance
+func parseCamtBalances(content []byte) (models.StatementBalances, error) {
+	var document camtDocument
+	if err := xml.Unmarshal(content, &document); err != nil {
+		return models.StatementBalances{}, fmt.Errorf("failed to read camt.053: %w", err)
+	}
+
+	var balances models.StatementBalances
+	for _, statement := range document.Statements {
+		for _, balance := range statement.Balances {
+			code := strings.TrimSpace(balance.Code)
+			if code != "OPBD" && code != "CLBD" {
+				continue
+			}
+
+			amount, err := parseCamtBalance(balance)
+			if err != nil {
+				return models.StatementBalances{}, fmt.Errorf("invalid %s balance: %w", code, err)
+			}
+			if code == "OPBD" && balances.Opening == nil {
+				balances.Opening = &amount
+			}
+			if code == "CLBD" {
+				balances.Closing = &amount
+			}
+		}
+	}
+	return balances, nil
+}
+
+// parseCamtBalance returns the amount of a balance, negative for a debit balance
+func parseCamtBalance(balance camtBalance) (decimal.Decimal, error) {
+	amount, err := decimal.NewFromString(strings.TrimSpace(balance.Amount))
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("amount %q: %w", balance.Amount, err)
+	}
+
+	switch strings.TrimSpace(balance.Indicator) {
+	case "CRDT":
+		return amount, nil
+	case "DBIT":
+		return amount.Neg(), nil
+	default:
+		return decimal.Zero, fmt.Errorf("credit/debit indicator %q, expected CRDT or DBIT", balance.Indicator)
+	}
+}
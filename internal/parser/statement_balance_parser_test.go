@@ -0,0 +1,203 @@
+package parser_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/firmannf/recon/internal/parser"
+)
+
+const camtHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <GrpHdr><MsgId>STMT-001</MsgId></GrpHdr>`
+
+func TestParseStatementBalances(t *testing.T) {
+	tests := []struct {
+		name            string
+		fileName        string
+		content         string
+		expectedOpening string // Empty when no opening balance is expected
+		expectedClosing string // Empty when no closing balance is expected
+	}{
+		{
+			name:     "MT940",
+			fileName: "bca.sta",
+			content: `{1:F01BCAXIDJAXXXX0000000000}{2:O9400000000000}{4:
+:20:STMT240115
+:25:1234567890
+:28C:1/1
+:60F:C240115IDR10000000,00
+:61:2401150115C2500000,00NTRFNONREF
+:86:PAYMENT TRX001
+:62F:C240115IDR12500000,00
+-}`,
+			expectedOpening: "10000000",
+			expectedClosing: "12500000",
+		},
+		{
+			name:            "MT940 debit balances and CRLF line endings",
+			fileName:        "overdraft.mt940",
+			content:         ":20:STMT\r\n:60F:D240115IDR1500,5\r\n:62F:D240115IDR500,\r\n",
+			expectedOpening: "-1500.5",
+			expectedClosing: "-500",
+		},
+		{
+			name:     "MT940 with several statements and intermediate balances",
+			fileName: "bca.txt",
+			content: `:20:STMT1
+:60F:C240115IDR1000,00
+:62M:C240115IDR1500,00
+:20:STMT2
+:60M:C240115IDR1500,00
+:62F:C240115IDR2000,00
+:20:STMT3
+:60F:C240116IDR2000,00
+:62F:C240116IDR3000,00`,
+			expectedOpening: "1000",
+			expectedClosing: "3000",
+		},
+		{
+			name:            "MT940 with only a closing balance",
+			fileName:        "bca.sta",
+			content:         ":20:STMT\n:62F:C240115IDR2000,00",
+			expectedClosing: "2000",
+		},
+		{
+			name:     "camt.053",
+			fileName: "mandiri.xml",
+			content: camtHeader + `
+    <Stmt>
+      <Id>STMT-001</Id>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>PRCD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="IDR">9000.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+      </Bal>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="IDR">10000000.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Dt><Dt>2024-01-15</Dt></Dt>
+      </Bal>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="IDR">250.50</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Dt><Dt>2024-01-15</Dt></Dt>
+      </Bal>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`,
+			expectedOpening: "10000000",
+			expectedClosing: "-250.5",
+		},
+		{
+			name:     "camt.053 with several statements",
+			fileName: "mandiri.xml",
+			content: camtHeader + `
+    <Stmt>
+      <Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Amt Ccy="IDR">100</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal>
+      <Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Amt Ccy="IDR">200</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal>
+    </Stmt>
+    <Stmt>
+      <Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Amt Ccy="IDR">200</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal>
+      <Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Amt Ccy="IDR">350</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`,
+			expectedOpening: "100",
+			expectedClosing: "350",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.fileName)
+			writeTestFile(t, path, tt.content)
+
+			balances, err := parser.ParseStatementBalances(path)
+			if err != nil {
+				t.Fatalf("Failed to parse statement balances: %v", err)
+			}
+
+			if tt.expectedOpening == "" && balances.Opening != nil {
+				t.Errorf("Expected no opening balance, got %s", balances.Opening)
+			}
+			if tt.expectedOpening != "" && (balances.Opening == nil || !balances.Opening.Equal(mustDecimal(tt.expectedOpening))) {
+				t.Errorf("Expected opening balance %s, got %v", tt.expectedOpening, balances.Opening)
+			}
+			if tt.expectedClosing == "" && balances.Closing != nil {
+				t.Errorf("Expected no closing balance, got %s", balances.Closing)
+			}
+			if tt.expectedClosing != "" && (balances.Closing == nil || !balances.Closing.Equal(mustDecimal(tt.expectedClosing))) {
+				t.Errorf("Expected closing balance %s, got %v", tt.expectedClosing, balances.Closing)
+			}
+		})
+	}
+}
+
+func TestParseStatementBalances_ErrorCases(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{
+			name:          "no balances",
+			content:       ":20:STMT\n:61:2401150115C2500000,00NTRFNONREF",
+			expectedError: "no opening or closing balance found",
+		},
+		{
+			name:          "MT940 balance without currency",
+			content:       ":20:STMT\n:60F:C2401151000,00",
+			expectedError: "invalid :60F: balance at line 2",
+		},
+		{
+			name:          "MT940 balance with a decimal point",
+			content:       ":62F:C240115IDR1000.00",
+			expectedError: "invalid :62F: balance at line 1",
+		},
+		{
+			name: "camt.053 unknown indicator",
+			content: camtHeader + `
+    <Stmt><Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Amt Ccy="IDR">100</Amt><CdtDbtInd>CR</CdtDbtInd></Bal></Stmt>
+  </BkToCstmrStmt>
+</Document>`,
+			expectedError: "invalid OPBD balance",
+		},
+		{
+			name: "camt.053 invalid amount",
+			content: camtHeader + `
+    <Stmt><Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Amt Ccy="IDR">1.000,00</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal></Stmt>
+  </BkToCstmrStmt>
+</Document>`,
+			expectedError: "invalid CLBD balance",
+		},
+		{
+			name:          "malformed XML",
+			content:       camtHeader + `<Stmt>`,
+			expectedError: "failed to read camt.053",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "statement.sta")
+			writeTestFile(t, path, tt.content)
+
+			_, err := parser.ParseStatementBalances(path)
+			if err == nil {
+				t.Fatalf("Expected error containing %q, got nil", tt.expectedError)
+			}
+			if !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %q", tt.expectedError, err.Error())
+			}
+		})
+	}
+
+	if _, err := parser.ParseStatementBalances(filepath.Join(t.TempDir(), "missing.sta")); err == nil {
+		t.Error("Expected error for a missing file but got nil")
+	}
+}
@@ -6,14 +6,13 @@ import (
 	"time"
 	_ "time/tzdata"
 
-	"github.com/shopspring/decimal"
-
 	"github.com/firmannf/recon/internal/models"
 )
 
 // TransactionParser handles parsing of system transaction CSV files
 type TransactionParser struct {
-	timezone *time.Location
+	timezone      *time.Location
+	strictAmounts bool
 }
 
 // NewTransactionParser creates a new TransactionParser with UTC+7 timezone
@@ -30,27 +29,36 @@ func NewTransactionParser() *TransactionParser {
 }
 
 // ParseCSV reads and parses a transaction CSV file
-// Expected CSV format: trxID,amount,type,transactionTime
+// Expected CSV format: trxID,amount,type,transactionTime followed by optional columns (e.g. channel)
 func (p *TransactionParser) ParseCSV(filePath string) ([]models.Transaction, error) {
 	records, err := readCSVFile(filePath)
 	if err != nil {
 		return nil, err
 	}
 
+	optionalCols, err := parseOptionalColumns(records[0], transactionColumnCount, transactionOptionalColumns)
+	if err != nil {
+		return nil, err
+	}
+	columnCount := transactionColumnCount + len(optionalCols)
+
 	var transactions []models.Transaction
 
 	// Skip header row
 	for i, record := range records[1:] {
-		if len(record) != transactionColumnCount {
-			return nil, fmt.Errorf("invalid record at row %d: expected %d columns, got %d", i+2, transactionColumnCount, len(record))
+		if len(record) != columnCount {
+			return nil, fmt.Errorf("invalid record at row %d: expected %d columns, got %d", i+2, columnCount, len(record))
 		}
 
-		amount, err := decimal.NewFromString(record[transactionColAmount])
+		amount, err := parseAmount(record[transactionColAmount], p.strictAmounts)
 		if err != nil {
 			return nil, fmt.Errorf("invalid amount at row %d: %w", i+2, err)
 		}
+		if p.strictAmounts && amount.IsNegative() {
+			return nil, fmt.Errorf("invalid amount at row %d: amount must not be negative, use the type column for debits", i+2)
+		}
 
-		trxType := models.TransactionType(strings.ToUpper(record[transactionColType]))
+		trxType := models.TransactionType(strings.ToUpper(strings.TrimSpace(record[transactionColType])))
 		if trxType != models.TransactionTypeDebit && trxType != models.TransactionTypeCredit {
 			return nil, fmt.Errorf("invalid transaction type at row %d: %s", i+2, record[transactionColType])
 		}
@@ -66,8 +74,16 @@ func (p *TransactionParser) ParseCSV(filePath string) ([]models.Transaction, err
 			Amount:          amount,
 			Type:            trxType,
 			TransactionTime: transactionTime,
+			Channel:         optionalValue(record, optionalCols, transactionColChannel),
+			Counterparty:    optionalValue(record, optionalCols, transactionColCounterparty),
 		})
 	}
 
 	return transactions, nil
 }
+
+// SetStrictAmounts makes ParseCSV reject negative amounts and amounts with more than
+// 2 decimal places or 15 integer digits.
+func (p *TransactionParser) SetStrictAmounts(strict bool) {
+	p.strictAmounts = strict
+}
@@ -42,6 +42,32 @@ TRX002,250,DEBIT,2024-01-16 14:22:30`,
 				}
 			},
 		},
+		{
+			name: "optional channel column",
+			csvContent: `trxID,amount,type,transactionTime,Channel
+TRX001,100000.00,CREDIT,2024-01-15 10:30:00, QRIS
+TRX002,250,DEBIT,2024-01-16 14:22:30,`,
+			expectedCount: 2,
+			verify: func(t *testing.T, transactions []models.Transaction) {
+				if transactions[0].Channel != "QRIS" {
+					t.Errorf("Expected channel 'QRIS', got '%s'", transactions[0].Channel)
+				}
+				if transactions[1].Channel != "" {
+					t.Errorf("Expected empty channel, got '%s'", transactions[1].Channel)
+				}
+			},
+		},
+		{
+			name: "optional counterparty column",
+			csvContent: `trxID,amount,type,transactionTime,counterparty
+TRX001,100000.00,CREDIT,2024-01-15 10:30:00, PT Maju Jaya `,
+			expectedCount: 1,
+			verify: func(t *testing.T, transactions []models.Transaction) {
+				if transactions[0].Counterparty != "PT Maju Jaya" {
+					t.Errorf("Expected counterparty 'PT Maju Jaya', got '%s'", transactions[0].Counterparty)
+				}
+			},
+		},
 		{
 			name: "format date YYYY-MM-DD HH:MM:SS",
 			csvContent: `trxID,amount,type,transactionTime
@@ -141,85 +167,96 @@ TRX001,1000.00,CREDIT,15-01-2024 10:30`,
 func TestTransactionParser_ParseCSV_ErrorCases(t *testing.T) {
 	tests := []struct {
 		name       string
-		setupFile  func(tmpDir string) string
+		setupFile  func(t *testing.T, tmpDir string) string
 		shouldFail bool
 	}{
 		{
 			name: "file not found",
-			setupFile: func(tmpDir string) string {
+			setupFile: func(t *testing.T, tmpDir string) string {
 				return "/nonexistent/path/transactions.csv"
 			},
 			shouldFail: true,
 		},
 		{
 			name: "non-CSV extension",
-			setupFile: func(tmpDir string) string {
+			setupFile: func(t *testing.T, tmpDir string) string {
 				txtPath := filepath.Join(tmpDir, "transactions.txt")
-				os.WriteFile(txtPath, []byte("some content"), 0644)
+				writeTestFile(t, txtPath, "some content")
 				return txtPath
 			},
 			shouldFail: true,
 		},
 		{
 			name: "empty file - only header",
-			setupFile: func(tmpDir string) string {
+			setupFile: func(t *testing.T, tmpDir string) string {
 				csvPath := filepath.Join(tmpDir, "transactions.csv")
-				os.WriteFile(csvPath, []byte("trxID,amount,type,transactionTime\n"), 0644)
+				writeTestFile(t, csvPath, "trxID,amount,type,transactionTime\n")
 				return csvPath
 			},
 			shouldFail: true,
 		},
 		{
 			name: "invalid amount",
-			setupFile: func(tmpDir string) string {
+			setupFile: func(t *testing.T, tmpDir string) string {
 				csvPath := filepath.Join(tmpDir, "transactions.csv")
 				content := `trxID,amount,type,transactionTime
 TRX001,invalid-amount,CREDIT,2024-01-15 10:30:00`
-				os.WriteFile(csvPath, []byte(content), 0644)
+				writeTestFile(t, csvPath, content)
+				return csvPath
+			},
+			shouldFail: true,
+		},
+		{
+			name: "amount out of range",
+			setupFile: func(t *testing.T, tmpDir string) string {
+				csvPath := filepath.Join(tmpDir, "transactions.csv")
+				content := `trxID,amount,type,transactionTime
+TRX001,1e100000000,CREDIT,2024-01-15 10:30:00`
+				writeTestFile(t, csvPath, content)
 				return csvPath
 			},
 			shouldFail: true,
 		},
 		{
 			name: "invalid date",
-			setupFile: func(tmpDir string) string {
+			setupFile: func(t *testing.T, tmpDir string) string {
 				csvPath := filepath.Join(tmpDir, "transactions.csv")
 				content := `trxID,amount,type,transactionTime
 TRX001,1000.00,CREDIT,invalid-date`
-				os.WriteFile(csvPath, []byte(content), 0644)
+				writeTestFile(t, csvPath, content)
 				return csvPath
 			},
 			shouldFail: true,
 		},
 		{
 			name: "missing columns",
-			setupFile: func(tmpDir string) string {
+			setupFile: func(t *testing.T, tmpDir string) string {
 				csvPath := filepath.Join(tmpDir, "transactions.csv")
 				content := `trxID,amount,type,transactionTime
 TRX001,1000.00,CREDIT`
-				os.WriteFile(csvPath, []byte(content), 0644)
+				writeTestFile(t, csvPath, content)
 				return csvPath
 			},
 			shouldFail: true,
 		},
 		{
 			name: "invalid type empty",
-			setupFile: func(tmpDir string) string {
+			setupFile: func(t *testing.T, tmpDir string) string {
 				csvPath := filepath.Join(tmpDir, "transactions.csv")
 				content := `trxID,amount,type,transactionTime
 TRX001,1000.00,,2024-01-15 10:30:00`
-				os.WriteFile(csvPath, []byte(content), 0644)
+				writeTestFile(t, csvPath, content)
 				return csvPath
 			},
 			shouldFail: true,
 		},
 		{
 			name: "invalid type random",
-			setupFile: func(tmpDir string) string {
+			setupFile: func(t *testing.T, tmpDir string) string {
 				csvPath := filepath.Join(tmpDir, "transactions.csv")
 				content := `trxID,amount,type,transactionTime
 TRX001,1000.00,PAYMENT,2024-01-15 10:30:00`
-				os.WriteFile(csvPath, []byte(content), 0644)
+				writeTestFile(t, csvPath, content)
 				return csvPath
 			},
 			shouldFail: true,
@@ -227,11 +264,11 @@ TRX001,1000.00,PAYMENT,2024-01-15 10:30:00`
 
 		{
 			name: "row count is not system transaction standard format",
-			setupFile: func(tmpDir string) string {
+			setupFile: func(t *testing.T, tmpDir string) string {
 				csvPath := filepath.Join(tmpDir, "bank.csv")
 				content := `trxID,amount,type,transactionTime,extraColumn
 TRX001,1000.00,CREDIT,2024-01-15 10:30:00,2024-01-15 10:30:00`
-				os.WriteFile(csvPath, []byte(content), 0644)
+				writeTestFile(t, csvPath, content)
 				return csvPath
 			},
 			shouldFail: true,
@@ -241,7 +278,7 @@ TRX001,1000.00,CREDIT,2024-01-15 10:30:00,2024-01-15 10:30:00`
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tmpDir := t.TempDir()
-			csvPath := tt.setupFile(tmpDir)
+			csvPath := tt.setupFile(t, tmpDir)
 
 			parser := parser.NewTransactionParser()
 			_, err := parser.ParseCSV(csvPath)
@@ -256,6 +293,38 @@ TRX001,1000.00,CREDIT,2024-01-15 10:30:00,2024-01-15 10:30:00`
 	}
 }
 
+func TestTransactionParser_ParseCSV_StrictAmounts(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount string
+	}{
+		{name: "more than 2 decimal places", amount: "1000.005"},
+		{name: "more than 15 integer digits", amount: "1000000000000000"},
+		{name: "negative amount", amount: "-1000.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csvPath := filepath.Join(t.TempDir(), "transactions.csv")
+			writeTestFile(t, csvPath, "trxID,amount,type,transactionTime\nTRX001,"+tt.amount+",CREDIT,2024-01-15 10:30:00")
+
+			parser := parser.NewTransactionParser()
+			transactions, err := parser.ParseCSV(csvPath)
+			if err != nil {
+				t.Fatalf("Expected %s to be accepted without strict amounts, got: %v", tt.amount, err)
+			}
+			if !transactions[0].Amount.Equal(mustDecimal(tt.amount)) {
+				t.Errorf("Expected amount %s, got %s", tt.amount, transactions[0].Amount)
+			}
+
+			parser.SetStrictAmounts(true)
+			if _, err := parser.ParseCSV(csvPath); err == nil {
+				t.Errorf("Expected %s to be rejected with strict amounts", tt.amount)
+			}
+		})
+	}
+}
+
 // Helper function
 func mustDecimal(s string) decimal.Decimal {
 	d, err := decimal.NewFromString(s)
@@ -0,0 +1,114 @@
+package service
+
+import "math"
+
+// maxScoreAssignment solves the one-to-one assignment between rows and columns.
+// It first maximizes the number of allowed pairs and then the total score of those pairs
+// (scores are expected in [0, 1]). The result holds the assigned column per row, or -1.
+func maxScoreAssignment(allowed [][]bool, scores [][]float64) []int {
+	rows := len(allowed)
+	if rows == 0 {
+		return nil
+	}
+	cols := len(allowed[0])
+	n := max(rows, cols)
+
+	// Every allowed pair is cheaper than any combination of score differences,
+	// so minimizing cost never trades a match for a better score
+	matchBonus := float64(n + 1)
+	cost := make([][]float64, n)
+	for i := range cost {
+		cost[i] = make([]float64, n)
+		if i >= rows {
+			continue
+		}
+		for j := 0; j < cols; j++ {
+			if allowed[i][j] {
+				score := math.Min(math.Max(scores[i][j], 0), 1)
+				cost[i][j] = (1 - score) - matchBonus
+			}
+		}
+	}
+
+	assignment := hungarian(cost)
+
+	result := make([]int, rows)
+	for i := range result {
+		j := assignment[i]
+		if j < cols && allowed[i][j] {
+			result[i] = j
+		} else {
+			result[i] = -1
+		}
+	}
+	return result
+}
+
+// hungarian returns the minimum cost perfect assignment of a square cost matrix
+// as the assigned column per row (Kuhn-Munkres with potentials, O(n^3))
+func hungarian(cost [][]float64) []int {
+	n := len(cost)
+
+	// 1-indexed potentials and matching, column 0 is a sentinel
+	u := make([]float64, n+1)
+	v := make([]float64, n+1)
+	p := make([]int, n+1) // p[j] is the row assigned to column j
+	way := make([]int, n+1)
+
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, n+1)
+		used := make([]bool, n+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+
+		for {
+			used[j0] = true
+			i0 := p[j0]
+			delta := math.Inf(1)
+			j1 := 0
+			for j := 1; j <= n; j++ {
+				if used[j] {
+					continue
+				}
+				cur := cost[i0-1][j-1] - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= n; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	assignment := make([]int, n)
+	for j := 1; j <= n; j++ {
+		if p[j] > 0 {
+			assignment[p[j]-1] = j - 1
+		}
+	}
+	return assignment
+}
@@ -0,0 +1,63 @@
+package service
+
+import "testing"
+
+func TestMaxScoreAssignment(t *testing.T) {
+	tests := []struct {
+		name     string
+		allowed  [][]bool
+		scores   [][]float64
+		expected []int
+	}{
+		{
+			name:     "picks the highest total score",
+			allowed:  [][]bool{{true, true}, {true, true}},
+			scores:   [][]float64{{0.4, 0.9}, {0.3, 0.5}},
+			expected: []int{1, 0},
+		},
+		{
+			name:     "prefers more matches over a better score",
+			allowed:  [][]bool{{true, true}, {true, false}},
+			scores:   [][]float64{{0.1, 1.0}, {0.1, 0}},
+			expected: []int{1, 0},
+		},
+		{
+			name:     "maximizes matches when greedy would strand a row",
+			allowed:  [][]bool{{true, true}, {true, false}},
+			scores:   [][]float64{{1.0, 0.0}, {0.0, 0}},
+			expected: []int{1, 0},
+		},
+		{
+			name:     "more rows than columns",
+			allowed:  [][]bool{{true}, {true}, {true}},
+			scores:   [][]float64{{0.2}, {0.8}, {0.5}},
+			expected: []int{-1, 0, -1},
+		},
+		{
+			name:     "more columns than rows",
+			allowed:  [][]bool{{true, true, true}},
+			scores:   [][]float64{{0.2, 0.8, 0.5}},
+			expected: []int{1},
+		},
+		{
+			name:     "nothing allowed",
+			allowed:  [][]bool{{false, false}, {false, false}},
+			scores:   [][]float64{{0, 0}, {0, 0}},
+			expected: []int{-1, -1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := maxScoreAssignment(tt.allowed, tt.scores)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, result)
+			}
+			for i := range tt.expected {
+				if result[i] != tt.expected[i] {
+					t.Fatalf("Expected %v, got %v", tt.expected, result)
+				}
+			}
+		})
+	}
+}
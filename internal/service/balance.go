@@ -0,0 +1,98 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/firmannf/recon/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// StatementBalances are the opening and closing balances of one bank statement, configured or
+// read with parser.ParseStatementBalances
+type StatementBalances = models.StatementBalances
+
+// BalanceCheckConfig configures the validation of bank balances. Each bank statement file is
+// checked for continuous running balances (the optional balance column) and, when given,
+// that the opening balance plus the lines equals the closing balance.
+type BalanceCheckConfig struct {
+	Statements map[string]StatementBalances // Optional, by bank name
+}
+
+// checkBalances walks the lines of each bank in file order and reports every balance that does
+// not equal the previous known balance plus the amounts in between. After a gap the check
+// continues from the balance reported by the bank, so each missing stretch is reported once.
+// With skipRepeats, lines repeating an earlier line in every field are left out, as they are
+// by DuplicateDedupe before matching, so that a resent line is only reported as a duplicate.
+func checkBalances(bankStmtLines []models.BankStatementLine, cfg BalanceCheckConfig, skipRepeats bool) []models.BalanceGap {
+	var bankNames []string
+	linesByBank := make(map[string][]models.BankStatementLine)
+	for _, bankStmtLine := range bankStmtLines {
+		if _, exists := linesByBank[bankStmtLine.BankName]; !exists {
+			bankNames = append(bankNames, bankStmtLine.BankName)
+		}
+		linesByBank[bankStmtLine.BankName] = append(linesByBank[bankStmtLine.BankName], bankStmtLine)
+	}
+
+	// Statements configured for banks without lines still have to add up
+	for bankName := range cfg.Statements {
+		if _, exists := linesByBank[bankName]; !exists {
+			bankNames = append(bankNames, bankName)
+		}
+	}
+	sort.Strings(bankNames)
+
+	var gaps []models.BalanceGap
+	for _, bankName := range bankNames {
+		statement := cfg.Statements[bankName]
+
+		// The running balance is unknown until the opening balance or the first line balance
+		var running decimal.Decimal
+		known := statement.Opening != nil
+		if known {
+			running = *statement.Opening
+		}
+
+		seen := make(map[string][]models.BankStatementLine)
+		for _, bankStmtLine := range linesByBank[bankName] {
+			if skipRepeats && repeatsEarlierLine(bankStmtLine, seen[bankStmtLine.UniqueIdentifier]) {
+				continue
+			}
+			seen[bankStmtLine.UniqueIdentifier] = append(seen[bankStmtLine.UniqueIdentifier], bankStmtLine)
+
+			running = running.Add(bankStmtLine.Amount)
+			if !bankStmtLine.HasBalance {
+				continue
+			}
+			if known && !running.Equal(bankStmtLine.Balance) {
+				gaps = append(gaps, models.BalanceGap{
+					Kind:              models.BalanceGapRunning,
+					BankName:          bankName,
+					BankStatementLine: bankStmtLine,
+					Expected:          running,
+					Actual:            bankStmtLine.Balance,
+				})
+			}
+			running, known = bankStmtLine.Balance, true
+		}
+
+		if statement.Closing != nil && known && !running.Equal(*statement.Closing) {
+			gaps = append(gaps, models.BalanceGap{
+				Kind:     models.BalanceGapClosing,
+				BankName: bankName,
+				Expected: running,
+				Actual:   *statement.Closing,
+			})
+		}
+	}
+	return gaps
+}
+
+// repeatsEarlierLine reports whether the line repeats one of the earlier lines in every field
+func repeatsEarlierLine(bankStmtLine models.BankStatementLine, earlier []models.BankStatementLine) bool {
+	for _, earlierLine := range earlier {
+		if sameBankStatementLine(earlierLine, bankStmtLine) {
+			return true
+		}
+	}
+	return false
+}
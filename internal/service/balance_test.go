@@ -0,0 +1,153 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/models"
+	"github.com/firmannf/recon/internal/service"
+)
+
+func TestReconciliation_BalanceCheck(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-15 10:00:00`)
+
+	// A 2000 credit between BCA-002 and BCA-004 is missing from the statement
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date,balance
+BCA-001,1000.00,2024-01-15,11000.00
+BCA-002,-500.00,2024-01-15,10500.00
+BCA-004,300.00,2024-01-16,12800.00
+BCA-005,200.00,2024-01-16,`)
+
+	// No running balances, only the statement totals
+	briCSV := filepath.Join(tmpDir, "bank_bri.csv")
+	writeTestFile(t, briCSV, `unique_identifier,amount,date
+BRI-001,200.00,2024-01-15
+BRI-002,200.00,2024-01-16`)
+
+	amount := func(value string) *decimal.Decimal {
+		d := decimal.RequireFromString(value)
+		return &d
+	}
+
+	type gap struct {
+		kind     models.BalanceGapKind
+		bank     string
+		line     string
+		expected string
+		actual   string
+	}
+
+	tests := []struct {
+		name         string
+		statements   map[string]service.StatementBalances
+		expectedGaps []gap
+	}{
+		{
+			name: "running balances only",
+			expectedGaps: []gap{
+				{kind: models.BalanceGapRunning, bank: "bank_bca", line: "BCA-004", expected: "10800", actual: "12800"},
+			},
+		},
+		{
+			name: "opening and closing balances",
+			statements: map[string]service.StatementBalances{
+				"bank_bca": {Opening: amount("9000"), Closing: amount("13200")},
+				"bank_bri": {Opening: amount("0"), Closing: amount("500")},
+			},
+			expectedGaps: []gap{
+				{kind: models.BalanceGapRunning, bank: "bank_bca", line: "BCA-001", expected: "10000", actual: "11000"},
+				{kind: models.BalanceGapRunning, bank: "bank_bca", line: "BCA-004", expected: "10800", actual: "12800"},
+				{kind: models.BalanceGapClosing, bank: "bank_bca", expected: "13000", actual: "13200"},
+				{kind: models.BalanceGapClosing, bank: "bank_bri", expected: "400", actual: "500"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconService := service.NewReconciliationService()
+			result, err := reconService.Reconcile(service.ReconciliationInput{
+				SystemTransactionFile: systemCSV,
+				BankStatementFiles:    []string{bcaCSV, briCSV},
+				StartDate:             mustParseTime("2024-01-15 00:00:00"),
+				EndDate:               mustParseTime("2024-01-15 23:59:59"),
+				MatchStrategy:         service.NewExactMatchStrategy(),
+				BalanceCheck:          &service.BalanceCheckConfig{Statements: tt.statements},
+			})
+			if err != nil {
+				t.Fatalf("Reconciliation failed: %v", err)
+			}
+
+			if len(result.BalanceGaps) != len(tt.expectedGaps) {
+				t.Fatalf("Expected %d balance gaps, got %d: %v", len(tt.expectedGaps), len(result.BalanceGaps), result.BalanceGaps)
+			}
+			for i, expected := range tt.expectedGaps {
+				got := result.BalanceGaps[i]
+				if got.Kind != expected.kind || got.BankName != expected.bank || got.BankStatementLine.UniqueIdentifier != expected.line ||
+					got.Expected.String() != expected.expected || got.Actual.String() != expected.actual {
+					t.Errorf("Expected %s gap in %s at %q of %s against %s, got %s gap in %s at %q of %s against %s",
+						expected.kind, expected.bank, expected.line, expected.expected, expected.actual,
+						got.Kind, got.BankName, got.BankStatementLine.UniqueIdentifier, got.Expected, got.Actual)
+				}
+			}
+		})
+	}
+}
+
+func TestReconciliation_BalanceCheckWithDuplicates(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	if err := os.WriteFile(systemCSV, []byte(`trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-15 10:00:00`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// BCA-001 was sent twice, balances included
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	if err := os.WriteFile(bcaCSV, []byte(`unique_identifier,amount,date,balance
+BCA-001,1000.00,2024-01-15,11000.00
+BCA-001,1000.00,2024-01-15,11000.00
+BCA-002,-500.00,2024-01-15,10500.00`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name                string
+		policy              service.DuplicatePolicy
+		expectedBalanceGaps int
+	}{
+		{name: "warn reports the resent line as a gap too", policy: service.DuplicateWarn, expectedBalanceGaps: 1},
+		{name: "dedupe leaves the resent line out of the balances", policy: service.DuplicateDedupe},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconService := service.NewReconciliationService()
+			result, err := reconService.Reconcile(service.ReconciliationInput{
+				SystemTransactionFile: systemCSV,
+				BankStatementFiles:    []string{bcaCSV},
+				StartDate:             mustParseTime("2024-01-15 00:00:00"),
+				MatchStrategy:         service.NewExactMatchStrategy(),
+				DuplicateDetection:    &service.DuplicateConfig{Policy: tt.policy},
+				BalanceCheck:          &service.BalanceCheckConfig{},
+			})
+			if err != nil {
+				t.Fatalf("Reconciliation failed: %v", err)
+			}
+
+			if len(result.BankDuplicates) != 1 {
+				t.Errorf("Expected 1 bank duplicate, got %d", len(result.BankDuplicates))
+			}
+			if len(result.BalanceGaps) != tt.expectedBalanceGaps {
+				t.Errorf("Expected %d balance gaps, got %d: %v", tt.expectedBalanceGaps, len(result.BalanceGaps), result.BalanceGaps)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package service_test
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/firmannf/recon/internal/service"
+)
+
+func TestReconciliation_BankStrategies(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-15 10:00:00
+TRX002,2000.00,CREDIT,2024-01-15 11:00:00
+TRX003,3000.00,CREDIT,2024-01-15 12:00:00`)
+
+	// BCA settles same day, a next day line must not match
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date
+BCA-001,1000.00,2024-01-15
+BCA-003,3000.00,2024-01-16`)
+
+	// The virtual account bank settles T+1
+	vaCSV := filepath.Join(tmpDir, "bank_va.csv")
+	writeTestFile(t, vaCSV, `unique_identifier,amount,date
+VA-002,2000.00,2024-01-16`)
+
+	reconService := service.NewReconciliationService()
+	result, err := reconService.Reconcile(service.ReconciliationInput{
+		SystemTransactionFile: systemCSV,
+		BankStatementFiles:    []string{bcaCSV, vaCSV},
+		StartDate:             mustParseTime("2024-01-01 00:00:00"),
+		EndDate:               mustParseTime("2024-12-31 23:59:59"),
+		MatchStrategy:         service.NewExactMatchStrategy(),
+		BankStrategies: map[string]service.MatchPipeline{
+			"bank_va": {
+				{Strategy: service.NewExactMatchStrategy()},
+				{Strategy: service.NewDateWindowMatchStrategy(1)},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	if result.TotalMatchedTransactions != 2 {
+		t.Errorf("Expected 2 matches, got %d", result.TotalMatchedTransactions)
+	}
+	if len(result.UnmatchedSystemTransactions) != 1 || result.UnmatchedSystemTransactions[0].TrxID != "TRX003" {
+		t.Errorf("Expected TRX003 unmatched, got %v", result.UnmatchedSystemTransactions)
+	}
+
+	// The pipelines run step by step: the first passes of all pipelines before the second ones
+	expectedPasses := []struct {
+		name    string
+		bank    string
+		matched int
+	}{
+		{name: "exact", matched: 1},
+		{name: "exact", bank: "bank_va", matched: 0},
+		{name: "window:1", bank: "bank_va", matched: 1},
+	}
+	if len(result.PassSummaries) != len(expectedPasses) {
+		t.Fatalf("Expected %d pass summaries, got %d", len(expectedPasses), len(result.PassSummaries))
+	}
+	for i, expected := range expectedPasses {
+		pass := result.PassSummaries[i]
+		if pass.Name != expected.name || pass.Bank != expected.bank || pass.Matched != expected.matched {
+			t.Errorf("Expected pass %d to be %s (%s) with %d matches, got %s (%s) with %d", i+1, expected.name, expected.bank, expected.matched, pass.Name, pass.Bank, pass.Matched)
+		}
+	}
+
+	if !slices.Equal(result.StrategiesByBank["bank_bca"], []string{"exact"}) {
+		t.Errorf("Expected bank_bca to use exact, got %v", result.StrategiesByBank["bank_bca"])
+	}
+	if !slices.Equal(result.StrategiesByBank["bank_va"], []string{"exact", "window:1"}) {
+		t.Errorf("Expected bank_va to use exact, window:1, got %v", result.StrategiesByBank["bank_va"])
+	}
+}
+
+func TestReconciliation_BankStrategiesRequireDefault(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-15 10:00:00
+TRX002,2000.00,CREDIT,2024-01-15 11:00:00`)
+
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date
+BCA-001,1000.00,2024-01-15`)
+
+	briCSV := filepath.Join(tmpDir, "bank_bri.csv")
+	writeTestFile(t, briCSV, `unique_identifier,amount,date
+BRI-002,2000.00,2024-01-15`)
+
+	input := service.ReconciliationInput{
+		SystemTransactionFile: systemCSV,
+		BankStatementFiles:    []string{bcaCSV, briCSV},
+		StartDate:             mustParseTime("2024-01-01 00:00:00"),
+		EndDate:               mustParseTime("2024-12-31 23:59:59"),
+		BankStrategies: map[string]service.MatchPipeline{
+			"bank_bca": {{Strategy: service.NewExactMatchStrategy()}},
+		},
+	}
+
+	// Without a default bank_bri would not be matched at all
+	reconService := service.NewReconciliationService()
+	expectedError := "required for banks without their own pipeline"
+	if err := input.Validate(); err == nil || !strings.Contains(err.Error(), expectedError) {
+		t.Errorf("Expected error containing %q, got %v", expectedError, err)
+	}
+	if _, err := reconService.Reconcile(input); err == nil {
+		t.Error("Expected error without a default strategy but got nil")
+	}
+
+	input.MatchStrategy = service.NewExactMatchStrategy()
+	result, err := reconService.Reconcile(input)
+	if err != nil {
+		t.Fatalf("Reconciliation failed: %v", err)
+	}
+	if result.TotalMatchedTransactions != 2 {
+		t.Errorf("Expected 2 matches, got %d", result.TotalMatchedTransactions)
+	}
+
+	input.BankStrategies["bank_bri"] = service.MatchPipeline{}
+	if _, err := reconService.Reconcile(input); err == nil {
+		t.Error("Expected error for an empty bank pipeline but got nil")
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/firmannf/recon/internal/expr"
+	"github.com/firmannf/recon/internal/models"
+)
+
+// conditionVars are the fields of a candidate pair a match condition can use
+var conditionVars = expr.Vars{
+	"sys.id":            expr.TypeString,
+	"sys.amount":        expr.TypeNumber,
+	"sys.type":          expr.TypeString,
+	"sys.time":          expr.TypeTime,
+	"sys.channel":       expr.TypeString,
+	"sys.counterparty":  expr.TypeString,
+	"bank.id":           expr.TypeString,
+	"bank.name":         expr.TypeString,
+	"bank.amount":       expr.TypeNumber,
+	"bank.type":         expr.TypeString,
+	"bank.date":         expr.TypeTime,
+	"bank.description":  expr.TypeString,
+	"bank.counterparty": expr.TypeString,
+}
+
+// MatchCondition is an expression a candidate pair must satisfy in addition to the
+// strategy, e.g. startsWith(bank.id, "QR") && sys.amount > 1000000
+type MatchCondition struct {
+	program *expr.Program
+}
+
+// NewMatchCondition compiles the condition, which must be a bool expression.
+// Compile errors are *expr.Error values with the position of the problem.
+func NewMatchCondition(source string) (*MatchCondition, error) {
+	program, err := expr.Compile(source, conditionVars)
+	if err != nil {
+		return nil, err
+	}
+	if program.Type() != expr.TypeBool {
+		return nil, fmt.Errorf("condition must be a bool expression, got a %s", program.Type())
+	}
+	return &MatchCondition{program: program}, nil
+}
+
+func (c *MatchCondition) String() string {
+	return c.program.String()
+}
+
+// Matches reports whether the pair satisfies the condition, a failed evaluation does not match
+func (c *MatchCondition) Matches(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) bool {
+	return c.ExplainMismatch(sysTrx, bankStmtLine) == ""
+}
+
+// ExplainMismatch returns why the pair fails the condition, or an empty string when it matches
+func (c *MatchCondition) ExplainMismatch(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) string {
+	value, err := c.program.Eval(func(name string) expr.Value {
+		switch name {
+		case "sys.id":
+			return expr.String(sysTrx.TrxID)
+		case "sys.amount":
+			return expr.Number(sysTrx.Amount)
+		case "sys.type":
+			return expr.String(string(sysTrx.Type))
+		case "sys.time":
+			return expr.Time(sysTrx.TransactionTime)
+		case "sys.channel":
+			return expr.String(sysTrx.Channel)
+		case "sys.counterparty":
+			return expr.String(sysTrx.Counterparty)
+		case "bank.id":
+			return expr.String(bankStmtLine.UniqueIdentifier)
+		case "bank.name":
+			return expr.String(bankStmtLine.BankName)
+		case "bank.amount":
+			return expr.Number(bankStmtLine.Amount)
+		case "bank.type":
+			return expr.String(string(bankStmtLine.Type))
+		case "bank.date":
+			return expr.Time(bankStmtLine.Date)
+		case "bank.description":
+			return expr.String(bankStmtLine.Description)
+		case "bank.counterparty":
+			return expr.String(bankStmtLine.Counterparty)
+		default:
+			// Compile only accepts conditionVars, a variable without a case here is a programming error
+			panic(fmt.Sprintf("condition variable %s has no value", name))
+		}
+	})
+	if err != nil {
+		return fmt.Sprintf("condition %s failed: %v", c, err)
+	}
+	if !value.Bool() {
+		return fmt.Sprintf("condition %s is false", c)
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+package service_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/firmannf/recon/internal/models"
+	"github.com/firmannf/recon/internal/service"
+)
+
+func TestReconciliation_MatchCondition(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,2500000.00,CREDIT,2024-01-15 10:00:00
+TRX002,500000.00,CREDIT,2024-01-15 11:00:00
+TRX003,3000000.00,CREDIT,2024-01-15 12:00:00`)
+
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date
+QR-001,2500000.00,2024-01-15
+QR-002,500000.00,2024-01-15
+TF-003,3000000.00,2024-01-15`)
+
+	tests := []struct {
+		name              string
+		condition         string
+		expectedMatched   int
+		expectedUnmatched []string
+	}{
+		{
+			name:            "no condition",
+			expectedMatched: 3,
+		},
+		{
+			name:              "large QR payments only",
+			condition:         `startsWith(bank.id, "QR") && sys.amount > 1000000`,
+			expectedMatched:   1,
+			expectedUnmatched: []string{"TRX002", "TRX003"},
+		},
+		{
+			name:              "same day",
+			condition:         `days(sys.time, bank.date) == 0 and bank.name == "bank_bca" and sys.amount != 500000`,
+			expectedMatched:   2,
+			expectedUnmatched: []string{"TRX002"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := service.ReconciliationInput{
+				SystemTransactionFile: systemCSV,
+				BankStatementFiles:    []string{bcaCSV},
+				StartDate:             mustParseTime("2024-01-01 00:00:00"),
+				EndDate:               mustParseTime("2024-12-31 23:59:59"),
+				MatchStrategy:         service.NewExactMatchStrategy(),
+			}
+			if tt.condition != "" {
+				condition, err := service.NewMatchCondition(tt.condition)
+				if err != nil {
+					t.Fatalf("Invalid condition: %v", err)
+				}
+				input.MatchCondition = condition
+			}
+
+			reconService := service.NewReconciliationService()
+			result, err := reconService.Reconcile(input)
+			if err != nil {
+				t.Fatalf("Reconciliation failed: %v", err)
+			}
+
+			if result.TotalMatchedTransactions != tt.expectedMatched {
+				t.Errorf("Expected %d matches, got %d", tt.expectedMatched, result.TotalMatchedTransactions)
+			}
+			if len(result.UnmatchedSystemTransactions) != len(tt.expectedUnmatched) {
+				t.Fatalf("Expected %d unmatched system transactions, got %d", len(tt.expectedUnmatched), len(result.UnmatchedSystemTransactions))
+			}
+			for i, trxID := range tt.expectedUnmatched {
+				if result.UnmatchedSystemTransactions[i].TrxID != trxID {
+					t.Errorf("Expected %s unmatched, got %s", trxID, result.UnmatchedSystemTransactions[i].TrxID)
+				}
+			}
+		})
+	}
+}
+
+func TestNewMatchCondition_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition string
+	}{
+		{name: "not a bool", condition: `sys.amount + 1`},
+		{name: "unknown field", condition: `sys.trxID == "TRX001"`},
+		{name: "syntax error", condition: `sys.amount >`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.NewMatchCondition(tt.condition); err == nil {
+				t.Error("Expected error but got nil")
+			}
+		})
+	}
+}
+
+func TestCompileMatchRules_Condition(t *testing.T) {
+	if _, err := service.CompileMatchRules([]service.MatchRule{
+		{Name: "qr", KeyFields: []string{"type", "amount", "date"}, Condition: `startsWith(bank.id, "QR"`},
+	}); err == nil {
+		t.Error("Expected error but got nil")
+	}
+
+	pipeline, err := service.CompileMatchRules([]service.MatchRule{
+		{Name: "qr", KeyFields: []string{"type", "amount", "date"}, Condition: `startsWith(bank.id, "QR")`},
+	})
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	sysTrx := models.Transaction{TrxID: "TRX001", Type: models.TransactionTypeCredit}
+	if pipeline[0].Strategy.IsMatch(sysTrx, models.BankStatementLine{UniqueIdentifier: "TF-001", Type: models.TransactionTypeCredit}) {
+		t.Error("Expected the condition to reject a non QR line")
+	}
+	if !pipeline[0].Strategy.IsMatch(sysTrx, models.BankStatementLine{UniqueIdentifier: "QR-001", Type: models.TransactionTypeCredit}) {
+		t.Error("Expected the condition to accept a QR line")
+	}
+}
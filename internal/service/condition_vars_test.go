@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/models"
+)
+
+// TestConditionVars_AllHaveValues makes sure every variable a condition may use is evaluated,
+// ExplainMismatch panics on a variable without a value
+func TestConditionVars_AllHaveValues(t *testing.T) {
+	sysTrx := models.Transaction{
+		TrxID:           "TRX001",
+		Amount:          decimal.NewFromInt(1000),
+		Type:            models.TransactionTypeCredit,
+		TransactionTime: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+	}
+	bankStmtLine := models.BankStatementLine{
+		UniqueIdentifier: "BCA-001",
+		BankName:         "bank_bca",
+		Amount:           decimal.NewFromInt(1000),
+		Type:             models.TransactionTypeCredit,
+		Date:             time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		Description:      "PAYMENT TRX001",
+	}
+
+	for name := range conditionVars {
+		t.Run(name, func(t *testing.T) {
+			condition, err := NewMatchCondition(name + " == " + name)
+			if err != nil {
+				t.Fatalf("Invalid condition: %v", err)
+			}
+			if reason := condition.ExplainMismatch(sysTrx, bankStmtLine); reason != "" {
+				t.Errorf("Expected %s to equal itself, got %q", name, reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/firmannf/recon/internal/calendar"
+	"github.com/firmannf/recon/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// CandidateScorer is implemented by strategies that rank the candidates of a system transaction.
+// Greedy assignment then picks the highest-scoring candidate instead of the first one in file order.
+type CandidateScorer interface {
+	Score(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) float64
+}
+
+// CounterpartyMatchStrategy matches by exact type and amount within a T+Days date window and
+// breaks ties between same-amount candidates by counterparty name similarity. Pairs whose
+// similarity is below MinSimilarity are rejected; with 0 the name only ranks the candidates.
+type CounterpartyMatchStrategy struct {
+	Days          int
+	MinSimilarity float64
+}
+
+func NewCounterpartyMatchStrategy(days int, minSimilarity float64) *CounterpartyMatchStrategy {
+	return &CounterpartyMatchStrategy{Days: days, MinSimilarity: minSimilarity}
+}
+
+func (s *CounterpartyMatchStrategy) BuildKey(trxType models.TransactionType, amount decimal.Decimal, date time.Time, id string) string {
+	return fmt.Sprintf("%s_%s", trxType, amount.String())
+}
+
+func (s *CounterpartyMatchStrategy) IsMatch(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) bool {
+	return s.ExplainMismatch(sysTrx, bankStmtLine) == ""
+}
+
+func (s *CounterpartyMatchStrategy) ExplainMismatch(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) string {
+	if days := calendar.DaysBetween(sysTrx.TransactionTime, bankStmtLine.Date); days < 0 || days > s.Days {
+		return fmt.Sprintf("bank date is %d days after the transaction date, expected 0 to %d", days, s.Days)
+	}
+	if s.MinSimilarity > 0 {
+		if similarity := s.Score(sysTrx, bankStmtLine); similarity < s.MinSimilarity {
+			return fmt.Sprintf("counterparty similarity %.2f is below %.2f", similarity, s.MinSimilarity)
+		}
+	}
+	return ""
+}
+
+// Score returns the similarity of the transaction counterparty with the bank statement line,
+// 0 when the transaction has no counterparty
+func (s *CounterpartyMatchStrategy) Score(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) float64 {
+	return counterpartySimilarity(sysTrx.Counterparty, bankStmtLine)
+}
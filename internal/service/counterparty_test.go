@@ -0,0 +1,149 @@
+package service_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/firmannf/recon/internal/models"
+	"github.com/firmannf/recon/internal/service"
+)
+
+func TestCounterpartyMatchStrategy_Score(t *testing.T) {
+	strategy := service.NewCounterpartyMatchStrategy(0, 0)
+
+	tests := []struct {
+		name         string
+		counterparty string
+		bankStmtLine models.BankStatementLine
+		minScore     float64
+		maxScore     float64
+	}{
+		{
+			name:         "legal form stripped",
+			counterparty: "PT. Maju Jaya",
+			bankStmtLine: models.BankStatementLine{Counterparty: "MAJU JAYA TBK"},
+			minScore:     1,
+			maxScore:     1,
+		},
+		{
+			name:         "honorifics stripped",
+			counterparty: "Budi Santoso",
+			bankStmtLine: models.BankStatementLine{Counterparty: "BPK H. BUDI SANTOSO"},
+			minScore:     1,
+			maxScore:     1,
+		},
+		{
+			name:         "typo",
+			counterparty: "Budi Santoso",
+			bankStmtLine: models.BankStatementLine{Counterparty: "BUDI SANTOSA"},
+			minScore:     0.9,
+			maxScore:     0.99,
+		},
+		{
+			name:         "name found in description",
+			counterparty: "CV Sinar Abadi",
+			bankStmtLine: models.BankStatementLine{Description: "TRF 0012 SINAR ABADI INV-001"},
+			minScore:     1,
+			maxScore:     1,
+		},
+		{
+			name:         "different name",
+			counterparty: "Budi Santoso",
+			bankStmtLine: models.BankStatementLine{Counterparty: "SITI AMINAH"},
+			maxScore:     0.6,
+		},
+		{
+			name:         "no counterparty on the transaction",
+			bankStmtLine: models.BankStatementLine{Counterparty: "SITI AMINAH"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := strategy.Score(models.Transaction{Counterparty: tt.counterparty}, tt.bankStmtLine)
+			if score < tt.minScore || score > tt.maxScore {
+				t.Errorf("Expected score between %.2f and %.2f, got %.4f", tt.minScore, tt.maxScore, score)
+			}
+		})
+	}
+}
+
+func TestReconciliation_CounterpartyStrategy(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime,counterparty
+TRX001,1000000.00,CREDIT,2024-01-15 10:00:00,PT Maju Jaya
+TRX002,1000000.00,CREDIT,2024-01-15 11:00:00,Bpk. Budi Santoso
+TRX003,1000000.00,CREDIT,2024-01-15 12:00:00,Siti Aminah`)
+
+	// Same amounts on every line, only the names tell the lines apart
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date,description,counterparty
+BCA-001,1000000.00,2024-01-16,TRF DARI BUDI SANTOSO,
+BCA-002,1000000.00,2024-01-15,,MAJU JAYA TBK
+BCA-003,1000000.00,2024-01-15,,DEWI LESTARI`)
+
+	tests := []struct {
+		name              string
+		strategy          service.MatchStrategy
+		assignmentMode    service.AssignmentMode
+		expectedPairs     map[string]string
+		expectedUnmatched []string
+	}{
+		{
+			name:           "names break the tie",
+			strategy:       service.NewCounterpartyMatchStrategy(1, 0),
+			assignmentMode: service.AssignmentGreedy,
+			expectedPairs:  map[string]string{"TRX001": "BCA-002", "TRX002": "BCA-001", "TRX003": "BCA-003"},
+		},
+		{
+			name:              "minimum similarity rejects other names",
+			strategy:          service.NewCounterpartyMatchStrategy(1, 0.85),
+			assignmentMode:    service.AssignmentGreedy,
+			expectedPairs:     map[string]string{"TRX001": "BCA-002", "TRX002": "BCA-001"},
+			expectedUnmatched: []string{"TRX003"},
+		},
+		{
+			name:           "optimal assignment scores names",
+			strategy:       service.NewDateWindowMatchStrategy(1),
+			assignmentMode: service.AssignmentOptimal,
+			expectedPairs:  map[string]string{"TRX001": "BCA-002", "TRX002": "BCA-001", "TRX003": "BCA-003"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconService := service.NewReconciliationService()
+			result, err := reconService.Reconcile(service.ReconciliationInput{
+				SystemTransactionFile: systemCSV,
+				BankStatementFiles:    []string{bcaCSV},
+				StartDate:             mustParseTime("2024-01-01 00:00:00"),
+				EndDate:               mustParseTime("2024-12-31 23:59:59"),
+				MatchStrategy:         tt.strategy,
+				AssignmentMode:        tt.assignmentMode,
+			})
+			if err != nil {
+				t.Fatalf("Reconciliation failed: %v", err)
+			}
+
+			if len(result.Matches) != len(tt.expectedPairs) {
+				t.Fatalf("Expected %d matches, got %d", len(tt.expectedPairs), len(result.Matches))
+			}
+			for _, match := range result.Matches {
+				expected := tt.expectedPairs[match.SystemTransaction.TrxID]
+				if match.BankStatementLine.UniqueIdentifier != expected {
+					t.Errorf("Expected %s paired with %s, got %s", match.SystemTransaction.TrxID, expected, match.BankStatementLine.UniqueIdentifier)
+				}
+			}
+			if len(result.UnmatchedSystemTransactions) != len(tt.expectedUnmatched) {
+				t.Fatalf("Expected %d unmatched system transactions, got %d", len(tt.expectedUnmatched), len(result.UnmatchedSystemTransactions))
+			}
+			for i, trxID := range tt.expectedUnmatched {
+				if result.UnmatchedSystemTransactions[i].TrxID != trxID {
+					t.Errorf("Expected %s unmatched, got %s", trxID, result.UnmatchedSystemTransactions[i].TrxID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/firmannf/recon/internal/calendar"
+	"github.com/firmannf/recon/internal/models"
+)
+
+// CoveragePolicy selects what happens to unmatched system transactions outside every bank's coverage
+type CoveragePolicy string
+
+const (
+	// CoverageWarn reports the parts of the period the bank statements do not cover
+	CoverageWarn CoveragePolicy = "warn"
+	// CoverageMark also moves uncovered unmatched system transactions to NotYetReconcilable
+	CoverageMark CoveragePolicy = "mark"
+)
+
+// CoverageConfig configures the comparison of the dates covered by each bank statement file with
+// the reconciliation period. A bank file ending before the period does would otherwise leave every
+// later system transaction unmatched although its bank line simply is not available yet.
+type CoverageConfig struct {
+	Policy         CoveragePolicy
+	SettlementDays int // Optional, days after a transaction its bank line may be booked, the bank file must cover them too
+}
+
+// validate checks that the configuration can be used for coverage checks
+func (c CoverageConfig) validate() error {
+	if c.Policy != CoverageWarn && c.Policy != CoverageMark {
+		return fmt.Errorf("unknown coverage policy %q, expected %s or %s", c.Policy, CoverageWarn, CoverageMark)
+	}
+	if c.SettlementDays < 0 {
+		return fmt.Errorf("coverage settlement days must not be negative (got %d)", c.SettlementDays)
+	}
+	return nil
+}
+
+// bankCoverage returns the first and last date of the lines of each bank, ordered by bank name
+func bankCoverage(bankStmtLines []models.BankStatementLine) []models.BankCoverage {
+	coverageByBank := make(map[string]*models.BankCoverage)
+	for _, bankStmtLine := range bankStmtLines {
+		coverage, exists := coverageByBank[bankStmtLine.BankName]
+		if !exists {
+			coverageByBank[bankStmtLine.BankName] = &models.BankCoverage{
+				BankName:  bankStmtLine.BankName,
+				FirstDate: bankStmtLine.Date,
+				LastDate:  bankStmtLine.Date,
+			}
+			continue
+		}
+		if bankStmtLine.Date.Before(coverage.FirstDate) {
+			coverage.FirstDate = bankStmtLine.Date
+		}
+		if bankStmtLine.Date.After(coverage.LastDate) {
+			coverage.LastDate = bankStmtLine.Date
+		}
+	}
+
+	coverages := make([]models.BankCoverage, 0, len(coverageByBank))
+	for _, coverage := range coverageByBank {
+		coverages = append(coverages, *coverage)
+	}
+	sort.Slice(coverages, func(i, j int) bool {
+		return coverages[i].BankName < coverages[j].BankName
+	})
+	return coverages
+}
+
+// findCoverageGaps returns, for each bank, the days of the period before its first line and after its
+// last line. Days are calendar days in the timezone of the start date.
+func findCoverageGaps(coverages []models.BankCoverage, startDate, endDate time.Time) []models.CoverageGap {
+	periodStart := calendar.StartOfDay(startDate)
+	periodEnd := calendar.StartOfDay(endDate.In(startDate.Location()))
+
+	var gaps []models.CoverageGap
+	for _, coverage := range coverages {
+		first := calendar.StartOfDay(coverage.FirstDate.In(startDate.Location()))
+		last := calendar.StartOfDay(coverage.LastDate.In(startDate.Location()))
+
+		if first.After(periodEnd) || last.Before(periodStart) {
+			gaps = append(gaps, models.CoverageGap{BankName: coverage.BankName, From: periodStart, To: periodEnd})
+			continue
+		}
+		if first.After(periodStart) {
+			gaps = append(gaps, models.CoverageGap{BankName: coverage.BankName, From: periodStart, To: first.AddDate(0, 0, -1)})
+		}
+		if last.Before(periodEnd) {
+			gaps = append(gaps, models.CoverageGap{BankName: coverage.BankName, From: last.AddDate(0, 0, 1), To: periodEnd})
+		}
+	}
+	return gaps
+}
+
+// isCovered reports whether some bank statement file covers the transaction day and the
+// settlement days after it
+func isCovered(sysTrx models.Transaction, coverages []models.BankCoverage, settlementDays int) bool {
+	for _, coverage := range coverages {
+		if calendar.DaysBetween(coverage.FirstDate, sysTrx.TransactionTime) >= 0 && calendar.DaysBetween(sysTrx.TransactionTime, coverage.LastDate) >= settlementDays {
+			return true
+		}
+	}
+	return false
+}
+
+// markNotYetReconcilable moves the unmatched system transactions no bank statement file covers to
+// NotYetReconcilable and no longer counts them as unmatched
+func markNotYetReconcilable(result *models.ReconciliationResult, coverages []models.BankCoverage, settlementDays int) {
+	unmatched := make([]models.Transaction, 0, len(result.UnmatchedSystemTransactions))
+	for _, sysTrx := range result.UnmatchedSystemTransactions {
+		if !isCovered(sysTrx, coverages, settlementDays) {
+			result.NotYetReconcilable = append(result.NotYetReconcilable, sysTrx)
+			continue
+		}
+		unmatched = append(unmatched, sysTrx)
+	}
+
+	result.TotalUnmatchedTransactions -= len(result.NotYetReconcilable)
+	result.UnmatchedSystemTransactions = unmatched
+}
@@ -0,0 +1,197 @@
+package service_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/firmannf/recon/internal/models"
+	"github.com/firmannf/recon/internal/service"
+)
+
+func TestReconciliation_CoverageCheck(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-01 10:00:00
+TRX002,2000.00,CREDIT,2024-01-04 10:00:00
+TRX003,3000.00,CREDIT,2024-01-25 10:00:00
+TRX004,4000.00,CREDIT,2024-01-21 10:00:00`)
+
+	// BCA covers January 1-20, BRI covers January 5-22
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date
+BCA-001,1000.00,2024-01-01
+BCA-002,500.00,2024-01-20`)
+
+	briCSV := filepath.Join(tmpDir, "bank_bri.csv")
+	writeTestFile(t, briCSV, `unique_identifier,amount,date
+BRI-001,600.00,2024-01-05
+BRI-002,700.00,2024-01-22`)
+
+	expectedGaps := []struct {
+		bank, from, to string
+	}{
+		{"bank_bca", "2024-01-21", "2024-01-31"},
+		{"bank_bri", "2024-01-01", "2024-01-04"},
+		{"bank_bri", "2024-01-23", "2024-01-31"},
+	}
+
+	tests := []struct {
+		name                 string
+		cfg                  service.CoverageConfig
+		expectedUnmatched    []string
+		expectedNotYet       []string
+		expectedTotalUnmatch int
+	}{
+		{
+			name:                 "warn keeps uncovered transactions unmatched",
+			cfg:                  service.CoverageConfig{Policy: service.CoverageWarn},
+			expectedUnmatched:    []string{"TRX002", "TRX003", "TRX004"},
+			expectedTotalUnmatch: 6,
+		},
+		{
+			name:                 "mark moves uncovered transactions",
+			cfg:                  service.CoverageConfig{Policy: service.CoverageMark},
+			expectedUnmatched:    []string{"TRX002", "TRX004"},
+			expectedNotYet:       []string{"TRX003"},
+			expectedTotalUnmatch: 5,
+		},
+		{
+			name:                 "settlement days must be covered too",
+			cfg:                  service.CoverageConfig{Policy: service.CoverageMark, SettlementDays: 2},
+			expectedUnmatched:    []string{"TRX002"},
+			expectedNotYet:       []string{"TRX003", "TRX004"},
+			expectedTotalUnmatch: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconService := service.NewReconciliationService()
+			result, err := reconService.Reconcile(service.ReconciliationInput{
+				SystemTransactionFile: systemCSV,
+				BankStatementFiles:    []string{bcaCSV, briCSV},
+				StartDate:             mustParseTime("2024-01-01 00:00:00"),
+				EndDate:               mustParseTime("2024-01-31 23:59:59"),
+				MatchStrategy:         service.NewExactMatchStrategy(),
+				NearMisses:            &service.NearMissConfig{Limit: 1},
+				CoverageCheck:         &tt.cfg,
+			})
+			if err != nil {
+				t.Fatalf("Reconciliation failed: %v", err)
+			}
+
+			if len(result.CoverageGaps) != len(expectedGaps) {
+				t.Fatalf("Expected %d coverage gaps, got %d: %v", len(expectedGaps), len(result.CoverageGaps), result.CoverageGaps)
+			}
+			for i, expected := range expectedGaps {
+				got := result.CoverageGaps[i]
+				if got.BankName != expected.bank || got.From.Format("2006-01-02") != expected.from || got.To.Format("2006-01-02") != expected.to {
+					t.Errorf("Expected gap in %s from %s to %s, got %s from %s to %s",
+						expected.bank, expected.from, expected.to, got.BankName, got.From.Format("2006-01-02"), got.To.Format("2006-01-02"))
+				}
+			}
+
+			assertTrxIDs(t, "unmatched", result.UnmatchedSystemTransactions, tt.expectedUnmatched)
+			assertTrxIDs(t, "not yet reconcilable", result.NotYetReconcilable, tt.expectedNotYet)
+			if len(result.SystemNearMisses) != len(result.UnmatchedSystemTransactions) {
+				t.Errorf("Expected near misses for %d unmatched transactions, got %d", len(result.UnmatchedSystemTransactions), len(result.SystemNearMisses))
+			}
+			if result.TotalUnmatchedTransactions != tt.expectedTotalUnmatch {
+				t.Errorf("Expected %d unmatched transactions, got %d", tt.expectedTotalUnmatch, result.TotalUnmatchedTransactions)
+			}
+		})
+	}
+}
+
+func TestReconciliation_CoverageMarkNearMisses(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// TRX001 is booked after the BCA file ends, TRX002 is covered
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-22 10:00:00
+TRX002,500.00,CREDIT,2024-01-11 10:00:00`)
+
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date
+BCA-001,1000.00,2024-01-20
+BCA-002,500.00,2024-01-10`)
+
+	tests := []struct {
+		name               string
+		policy             service.CoveragePolicy
+		expectedBCA001Miss []string // TrxIDs suggested for BCA-001
+	}{
+		{name: "warn suggests uncovered transactions", policy: service.CoverageWarn, expectedBCA001Miss: []string{"TRX001"}},
+		{name: "mark leaves them out", policy: service.CoverageMark},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconService := service.NewReconciliationService()
+			result, err := reconService.Reconcile(service.ReconciliationInput{
+				SystemTransactionFile: systemCSV,
+				BankStatementFiles:    []string{bcaCSV},
+				StartDate:             mustParseTime("2024-01-01 00:00:00"),
+				EndDate:               mustParseTime("2024-01-31 23:59:59"),
+				MatchStrategy:         service.NewExactMatchStrategy(),
+				NearMisses:            &service.NearMissConfig{Limit: 3, WindowDays: 3},
+				CoverageCheck:         &service.CoverageConfig{Policy: tt.policy},
+			})
+			if err != nil {
+				t.Fatalf("Reconciliation failed: %v", err)
+			}
+
+			bankLines := result.UnmatchedBankStatementLines["bank_bca"]
+			bankNearMisses := result.BankNearMisses["bank_bca"]
+			if len(bankNearMisses) != len(bankLines) {
+				t.Fatalf("Expected near misses for %d unmatched bank lines, got %d", len(bankLines), len(bankNearMisses))
+			}
+			for bankIdx, bankStmtLine := range bankLines {
+				var suggested []models.Transaction
+				for _, nearMiss := range bankNearMisses[bankIdx] {
+					suggested = append(suggested, nearMiss.SystemTransaction)
+				}
+				switch bankStmtLine.UniqueIdentifier {
+				case "BCA-001":
+					assertTrxIDs(t, "BCA-001 near miss", suggested, tt.expectedBCA001Miss)
+				case "BCA-002":
+					assertTrxIDs(t, "BCA-002 near miss", suggested, []string{"TRX002"})
+				}
+			}
+			if len(result.SystemNearMisses) != len(result.UnmatchedSystemTransactions) {
+				t.Errorf("Expected near misses for %d unmatched transactions, got %d", len(result.UnmatchedSystemTransactions), len(result.SystemNearMisses))
+			}
+		})
+	}
+}
+
+func TestReconciliation_CoverageCheckValidation(t *testing.T) {
+	reconService := service.NewReconciliationService()
+	for _, cfg := range []service.CoverageConfig{
+		{Policy: "ignore"},
+		{Policy: service.CoverageWarn, SettlementDays: -1},
+	} {
+		_, err := reconService.Reconcile(service.ReconciliationInput{
+			StartDate:     mustParseTime("2024-01-01 00:00:00"),
+			MatchStrategy: service.NewExactMatchStrategy(),
+			CoverageCheck: &cfg,
+		})
+		if err == nil {
+			t.Errorf("Expected an error for %+v", cfg)
+		}
+	}
+}
+
+func assertTrxIDs(t *testing.T, label string, trxs []models.Transaction, expected []string) {
+	t.Helper()
+	if len(trxs) != len(expected) {
+		t.Fatalf("Expected %d %s transactions, got %d: %v", len(expected), label, len(trxs), trxs)
+	}
+	for i, trxID := range expected {
+		if trxs[i].TrxID != trxID {
+			t.Errorf("Expected %s transaction %d to be %s, got %s", label, i, trxID, trxs[i].TrxID)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/firmannf/recon/internal/models"
+)
+
+// DuplicatePolicy selects what happens to rows repeating an identifier of their source
+type DuplicatePolicy string
+
+const (
+	// DuplicateWarn reports duplicates and matches all rows as they are
+	DuplicateWarn DuplicatePolicy = "warn"
+	// DuplicateDedupe drops exact repeats of a row before matching and reports the rest
+	DuplicateDedupe DuplicatePolicy = "dedupe"
+	// DuplicateReject fails the reconciliation when an identifier is repeated
+	DuplicateReject DuplicatePolicy = "reject"
+)
+
+// maxRejectedDuplicates caps the duplicates listed in the error of the reject policy
+const maxRejectedDuplicates = 5
+
+// DuplicateConfig configures the detection of repeated rows within the system file and within
+// each bank statement file. Rows sharing an identifier are handled by Policy. Rows with different
+// identifiers but the same type, amount and time are only reported, they may be genuine.
+type DuplicateConfig struct {
+	Policy DuplicatePolicy
+}
+
+// validate checks that the configuration can be used for duplicate detection
+func (c DuplicateConfig) validate() error {
+	switch c.Policy {
+	case DuplicateWarn, DuplicateDedupe, DuplicateReject:
+		return nil
+	default:
+		return fmt.Errorf("unknown duplicate policy %q, expected %s, %s or %s", c.Policy, DuplicateWarn, DuplicateDedupe, DuplicateReject)
+	}
+}
+
+// duplicateReport holds the duplicates found in the parsed files
+type duplicateReport struct {
+	system []models.SystemDuplicate
+	bank   []models.BankDuplicate
+}
+
+// detectDuplicates finds repeated rows and applies the policy. It returns the system transactions
+// and bank statement lines to reconcile, without the dropped repeats under DuplicateDedupe.
+func detectDuplicates(
+	systemTrxs []models.Transaction,
+	bankStmtLines []models.BankStatementLine,
+	cfg DuplicateConfig,
+) ([]models.Transaction, []models.BankStatementLine, duplicateReport, error) {
+	var report duplicateReport
+
+	// Repeated TrxIDs, the system file is a single source
+	removedSystemTrxs := make([]bool, len(systemTrxs))
+	for _, group := range groupIndexes(len(systemTrxs), func(i int) (string, bool) {
+		return systemTrxs[i].TrxID, true
+	}) {
+		duplicate := models.SystemDuplicate{Kind: models.DuplicateSameIdentifier}
+		for _, sysIdx := range group {
+			duplicate.Transactions = append(duplicate.Transactions, systemTrxs[sysIdx])
+			if cfg.Policy == DuplicateDedupe && sysIdx != group[0] && sameTransaction(systemTrxs[group[0]], systemTrxs[sysIdx]) {
+				removedSystemTrxs[sysIdx] = true
+				duplicate.Removed++
+			}
+		}
+		report.system = append(report.system, duplicate)
+	}
+
+	// Repeated unique identifiers within each bank
+	removedBankStmtLines := make([]bool, len(bankStmtLines))
+	for _, group := range groupIndexes(len(bankStmtLines), func(i int) (string, bool) {
+		return bankStmtLines[i].BankName + "_" + bankStmtLines[i].UniqueIdentifier, true
+	}) {
+		duplicate := models.BankDuplicate{Kind: models.DuplicateSameIdentifier}
+		for _, bankIdx := range group {
+			duplicate.BankStatementLines = append(duplicate.BankStatementLines, bankStmtLines[bankIdx])
+			if cfg.Policy == DuplicateDedupe && bankIdx != group[0] && sameBankStatementLine(bankStmtLines[group[0]], bankStmtLines[bankIdx]) {
+				removedBankStmtLines[bankIdx] = true
+				duplicate.Removed++
+			}
+		}
+		report.bank = append(report.bank, duplicate)
+	}
+
+	if cfg.Policy == DuplicateReject && (len(report.system) > 0 || len(report.bank) > 0) {
+		return nil, nil, report, rejectDuplicates(report)
+	}
+
+	// Different identifiers with the same amount and time to the second
+	for _, group := range groupIndexes(len(systemTrxs), func(i int) (string, bool) {
+		sysTrx := systemTrxs[i]
+		return fmt.Sprintf("%s_%s_%d", sysTrx.Type, sysTrx.Amount, sysTrx.TransactionTime.Unix()), !removedSystemTrxs[i]
+	}) {
+		duplicate := models.SystemDuplicate{Kind: models.DuplicateSameAmountTime}
+		for _, sysIdx := range group {
+			duplicate.Transactions = append(duplicate.Transactions, systemTrxs[sysIdx])
+		}
+		if distinctIdentifiers(len(group), func(i int) string { return systemTrxs[group[i]].TrxID }) > 1 {
+			report.system = append(report.system, duplicate)
+		}
+	}
+
+	// Date-only bank lines of the same amount on the same day are common, only timed lines are compared
+	for _, group := range groupIndexes(len(bankStmtLines), func(i int) (string, bool) {
+		bankStmtLine := bankStmtLines[i]
+		key := fmt.Sprintf("%s_%s_%s_%d", bankStmtLine.BankName, bankStmtLine.Type, bankStmtLine.GetAbsoluteAmount(), bankStmtLine.Date.Unix())
+		return key, !removedBankStmtLines[i] && hasBookingTime(bankStmtLine)
+	}) {
+		duplicate := models.BankDuplicate{Kind: models.DuplicateSameAmountTime}
+		for _, bankIdx := range group {
+			duplicate.BankStatementLines = append(duplicate.BankStatementLines, bankStmtLines[bankIdx])
+		}
+		if distinctIdentifiers(len(group), func(i int) string { return bankStmtLines[group[i]].UniqueIdentifier }) > 1 {
+			report.bank = append(report.bank, duplicate)
+		}
+	}
+
+	keptSystemTrxs := make([]models.Transaction, 0, len(systemTrxs))
+	for sysIdx, sysTrx := range systemTrxs {
+		if !removedSystemTrxs[sysIdx] {
+			keptSystemTrxs = append(keptSystemTrxs, sysTrx)
+		}
+	}
+	keptBankStmtLines := make([]models.BankStatementLine, 0, len(bankStmtLines))
+	for bankIdx, bankStmtLine := range bankStmtLines {
+		if !removedBankStmtLines[bankIdx] {
+			keptBankStmtLines = append(keptBankStmtLines, bankStmtLine)
+		}
+	}
+	return keptSystemTrxs, keptBankStmtLines, report, nil
+}
+
+// groupIndexes groups the indexes 0..n-1 by key, skipping those for which key reports false.
+// Only groups of two or more are returned, in order of their first index.
+func groupIndexes(n int, key func(i int) (string, bool)) [][]int {
+	var keys []string
+	groups := make(map[string][]int)
+	for i := 0; i < n; i++ {
+		k, ok := key(i)
+		if !ok {
+			continue
+		}
+		if _, exists := groups[k]; !exists {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], i)
+	}
+
+	var result [][]int
+	for _, k := range keys {
+		if len(groups[k]) > 1 {
+			result = append(result, groups[k])
+		}
+	}
+	return result
+}
+
+// distinctIdentifiers counts the different identifiers among n entries
+func distinctIdentifiers(n int, identifier func(i int) string) int {
+	seen := make(map[string]bool)
+	for i := 0; i < n; i++ {
+		seen[identifier(i)] = true
+	}
+	return len(seen)
+}
+
+// sameTransaction reports whether b repeats a in every field
+func sameTransaction(a, b models.Transaction) bool {
+	return a.TrxID == b.TrxID && a.Type == b.Type && a.Amount.Equal(b.Amount) && a.TransactionTime.Equal(b.TransactionTime) &&
+		a.Channel == b.Channel && a.Counterparty == b.Counterparty
+}
+
+// sameBankStatementLine reports whether b repeats a in every field
+func sameBankStatementLine(a, b models.BankStatementLine) bool {
+	return a.UniqueIdentifier == b.UniqueIdentifier && a.BankName == b.BankName && a.Amount.Equal(b.Amount) && a.Date.Equal(b.Date) &&
+		a.HasTime == b.HasTime && a.Description == b.Description && a.Counterparty == b.Counterparty &&
+		a.HasBalance == b.HasBalance && a.Balance.Equal(b.Balance)
+}
+
+// rejectDuplicates describes the repeated identifiers found under DuplicateReject
+func rejectDuplicates(report duplicateReport) error {
+	var descriptions []string
+	for _, duplicate := range report.system {
+		descriptions = append(descriptions, fmt.Sprintf("TrxID %s appears %d times in the system transactions", duplicate.Transactions[0].TrxID, len(duplicate.Transactions)))
+	}
+	for _, duplicate := range report.bank {
+		stmtLine := duplicate.BankStatementLines[0]
+		descriptions = append(descriptions, fmt.Sprintf("unique identifier %s appears %d times in %s", stmtLine.UniqueIdentifier, len(duplicate.BankStatementLines), stmtLine.BankName))
+	}
+
+	total := len(descriptions)
+	if total > maxRejectedDuplicates {
+		descriptions = append(descriptions[:maxRejectedDuplicates], fmt.Sprintf("and %d more", total-maxRejectedDuplicates))
+	}
+	return fmt.Errorf("duplicate identifiers found (%d): %s", total, strings.Join(descriptions, "; "))
+}
@@ -0,0 +1,181 @@
+package service_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/firmannf/recon/internal/models"
+	"github.com/firmannf/recon/internal/service"
+)
+
+func TestReconciliation_DuplicateDetection(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// TRX001 was loaded twice, TRX002 and TRX003 share amount and time
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-15 10:00:00
+TRX001,1000.00,CREDIT,2024-01-15 10:00:00
+TRX002,2000.00,CREDIT,2024-01-15 11:00:00
+TRX003,2000.00,CREDIT,2024-01-15 11:00:00`)
+
+	// BCA-004 was resent, the two BCA-005 lines conflict
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date
+BCA-001,1000.00,2024-01-15
+BCA-002,2000.00,2024-01-15
+BCA-003,2000.00,2024-01-15
+BCA-004,500.00,2024-01-15
+BCA-004,500.00,2024-01-15
+BCA-005,500.00,2024-01-15
+BCA-005,700.00,2024-01-15`)
+
+	type duplicate struct {
+		kind    models.DuplicateKind
+		size    int
+		removed int
+	}
+
+	tests := []struct {
+		name                    string
+		policy                  service.DuplicatePolicy
+		expectedSystemDups      []duplicate
+		expectedBankDups        []duplicate
+		expectedUnmatchedSystem int
+		expectedUnmatchedBank   int
+		expectedError           string
+	}{
+		{
+			name:   "warn",
+			policy: service.DuplicateWarn,
+			expectedSystemDups: []duplicate{
+				{kind: models.DuplicateSameIdentifier, size: 2},
+				{kind: models.DuplicateSameAmountTime, size: 2},
+			},
+			expectedBankDups: []duplicate{
+				{kind: models.DuplicateSameIdentifier, size: 2},
+				{kind: models.DuplicateSameIdentifier, size: 2},
+			},
+			expectedUnmatchedSystem: 1,
+			expectedUnmatchedBank:   4,
+		},
+		{
+			name:   "dedupe drops exact repeats only",
+			policy: service.DuplicateDedupe,
+			expectedSystemDups: []duplicate{
+				{kind: models.DuplicateSameIdentifier, size: 2, removed: 1},
+				{kind: models.DuplicateSameAmountTime, size: 2},
+			},
+			expectedBankDups: []duplicate{
+				{kind: models.DuplicateSameIdentifier, size: 2, removed: 1},
+				{kind: models.DuplicateSameIdentifier, size: 2},
+			},
+			expectedUnmatchedSystem: 0,
+			expectedUnmatchedBank:   3,
+		},
+		{
+			name:          "reject",
+			policy:        service.DuplicateReject,
+			expectedError: "TrxID TRX001 appears 2 times",
+		},
+		{
+			name:          "unknown policy",
+			policy:        "ignore",
+			expectedError: "unknown duplicate policy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconService := service.NewReconciliationService()
+			result, err := reconService.Reconcile(service.ReconciliationInput{
+				SystemTransactionFile: systemCSV,
+				BankStatementFiles:    []string{bcaCSV},
+				StartDate:             mustParseTime("2024-01-01 00:00:00"),
+				EndDate:               mustParseTime("2024-12-31 23:59:59"),
+				MatchStrategy:         service.NewExactMatchStrategy(),
+				DuplicateDetection:    &service.DuplicateConfig{Policy: tt.policy},
+			})
+
+			if tt.expectedError != "" {
+				if err == nil {
+					t.Fatal("Expected error but got nil")
+				}
+				if !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tt.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Reconciliation failed: %v", err)
+			}
+
+			if len(result.SystemDuplicates) != len(tt.expectedSystemDups) {
+				t.Fatalf("Expected %d system duplicate groups, got %d", len(tt.expectedSystemDups), len(result.SystemDuplicates))
+			}
+			for i, expected := range tt.expectedSystemDups {
+				got := result.SystemDuplicates[i]
+				if got.Kind != expected.kind || len(got.Transactions) != expected.size || got.Removed != expected.removed {
+					t.Errorf("Expected system group %d to be %s of %d removing %d, got %s of %d removing %d", i+1, expected.kind, expected.size, expected.removed, got.Kind, len(got.Transactions), got.Removed)
+				}
+			}
+			if len(result.BankDuplicates) != len(tt.expectedBankDups) {
+				t.Fatalf("Expected %d bank duplicate groups, got %d", len(tt.expectedBankDups), len(result.BankDuplicates))
+			}
+			for i, expected := range tt.expectedBankDups {
+				got := result.BankDuplicates[i]
+				if got.Kind != expected.kind || len(got.BankStatementLines) != expected.size || got.Removed != expected.removed {
+					t.Errorf("Expected bank group %d to be %s of %d removing %d, got %s of %d removing %d", i+1, expected.kind, expected.size, expected.removed, got.Kind, len(got.BankStatementLines), got.Removed)
+				}
+			}
+
+			if len(result.UnmatchedSystemTransactions) != tt.expectedUnmatchedSystem {
+				t.Errorf("Expected %d unmatched system transactions, got %d", tt.expectedUnmatchedSystem, len(result.UnmatchedSystemTransactions))
+			}
+			if len(result.UnmatchedBankStatementLines["bank_bca"]) != tt.expectedUnmatchedBank {
+				t.Errorf("Expected %d unmatched bank statement lines, got %d", tt.expectedUnmatchedBank, len(result.UnmatchedBankStatementLines["bank_bca"]))
+			}
+		})
+	}
+}
+
+func TestReconciliation_DuplicateTimedBankLines(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-15 10:00:00`)
+
+	// Same amount on the same day is normal, at the same second it is suspicious
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date
+BCA-001,1000.00,2024-01-15 10:00:05
+BCA-002,1000.00,2024-01-15 10:00:05
+BCA-003,300.00,2024-01-16
+BCA-004,300.00,2024-01-16`)
+
+	reconService := service.NewReconciliationService()
+	result, err := reconService.Reconcile(service.ReconciliationInput{
+		SystemTransactionFile: systemCSV,
+		BankStatementFiles:    []string{bcaCSV},
+		StartDate:             mustParseTime("2024-01-01 00:00:00"),
+		EndDate:               mustParseTime("2024-12-31 23:59:59"),
+		MatchStrategy:         service.NewExactMatchStrategy(),
+		DuplicateDetection:    &service.DuplicateConfig{Policy: service.DuplicateReject},
+	})
+	if err != nil {
+		t.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	if len(result.BankDuplicates) != 1 {
+		t.Fatalf("Expected 1 bank duplicate group, got %d", len(result.BankDuplicates))
+	}
+	group := result.BankDuplicates[0]
+	if group.Kind != models.DuplicateSameAmountTime || group.BankStatementLines[0].UniqueIdentifier != "BCA-001" || group.BankStatementLines[1].UniqueIdentifier != "BCA-002" {
+		t.Errorf("Expected BCA-001 and BCA-002 with the same amount and time, got %v", group)
+	}
+	if result.TotalMatchedTransactions != 1 {
+		t.Errorf("Expected 1 match, got %d", result.TotalMatchedTransactions)
+	}
+}
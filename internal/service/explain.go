@@ -0,0 +1,193 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/firmannf/recon/internal/models"
+)
+
+// MatchExplainer is implemented by strategies that can tell why IsMatch rejected a pair
+type MatchExplainer interface {
+	ExplainMismatch(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) string
+}
+
+// matchDecider is implemented by strategies that answer IsMatch and ExplainMismatch with the same
+// costly call, tracing then asks them once per pair
+type matchDecider interface {
+	decide(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) (bool, string)
+}
+
+// Explain runs the reconciliation with tracing and describes how every system transaction
+// with the given TrxID went through matching: the key built in each pass, the candidates
+// found for it and why each of them was or was not paired with it
+func (s *ReconciliationService) Explain(input ReconciliationInput, trxID string) ([]models.MatchExplanation, error) {
+	tracer := newMatchTracer(trxID)
+	input.tracer = tracer
+	if _, err := s.Reconcile(input); err != nil {
+		return nil, err
+	}
+
+	if len(tracer.sysIdxs) == 0 {
+		return nil, fmt.Errorf("transaction %s not found in the system transactions within the date range", trxID)
+	}
+
+	explanations := make([]models.MatchExplanation, len(tracer.sysIdxs))
+	for i, sysIdx := range tracer.sysIdxs {
+		explanations[i] = *tracer.explanations[sysIdx]
+	}
+	return explanations, nil
+}
+
+// matchTracer records how the system transactions with a given TrxID go through matching
+type matchTracer struct {
+	trxID        string
+	sysIdxs      []int                            // Traced system transactions in file order
+	explanations map[int]*models.MatchExplanation // Keyed by system transaction index
+	consumedBy   map[int]string                   // What consumed a bank statement line, keyed by its index
+	matchedWith  map[int]int                      // Bank statement line paired with a traced system transaction
+	eligible     map[int][]tracedCandidate        // Candidates of the current pass awaiting assignment
+}
+
+// tracedCandidate links a candidate explanation to its bank statement line
+type tracedCandidate struct {
+	bankIdx     int
+	explanation *models.CandidateExplanation
+}
+
+func newMatchTracer(trxID string) *matchTracer {
+	return &matchTracer{
+		trxID:        trxID,
+		explanations: make(map[int]*models.MatchExplanation),
+		consumedBy:   make(map[int]string),
+		matchedWith:  make(map[int]int),
+	}
+}
+
+// start selects the system transactions to trace
+func (t *matchTracer) start(systemTrxs []models.Transaction) {
+	for sysIdx, sysTrx := range systemTrxs {
+		if sysTrx.TrxID == t.trxID {
+			t.sysIdxs = append(t.sysIdxs, sysIdx)
+			t.explanations[sysIdx] = &models.MatchExplanation{SystemTransaction: sysTrx, Outcome: "unmatched"}
+		}
+	}
+}
+
+// traceCandidates records the key and candidates of every traced system transaction that is
+// still unmatched at the start of the pass, before any assignment takes place
+func (m *matchState) traceCandidates() {
+	if m.tracer == nil {
+		return
+	}
+
+	m.tracer.eligible = make(map[int][]tracedCandidate)
+	for _, sysIdx := range m.tracer.sysIdxs {
+		if m.matchedSystemTrxs[sysIdx] {
+			continue
+		}
+		sysTrx := m.systemTrxs[sysIdx]
+		key := m.systemKey(sysTrx)
+
+		explanation := m.tracer.explanations[sysIdx]
+		explanation.Passes = append(explanation.Passes, models.PassExplanation{Pass: m.passName, Key: key})
+		passExplanation := &explanation.Passes[len(explanation.Passes)-1]
+
+		// The index only holds unmatched lines, so every line is checked to also show consumed candidates
+		var candidateIdxs []int
+		for bankIdx, bankStmtLine := range m.bankStmtLines {
+			if !m.pass.inScope(bankStmtLine.BankName) || !slices.Contains(m.bankKeys(bankIdx), key) {
+				continue
+			}
+
+			candidate := models.CandidateExplanation{BankStatementLine: bankStmtLine}
+			if m.matchedBankStmtLines[bankIdx] {
+				candidate.Status = models.CandidateConsumed
+				candidate.Reason = m.tracer.consumedBy[bankIdx]
+			} else if reason := m.explainMismatch(sysTrx, bankStmtLine); reason != "" {
+				candidate.Status = models.CandidateRejected
+				candidate.Reason = reason
+			} else {
+				candidate.Status = models.CandidateNotSelected
+			}
+			passExplanation.Candidates = append(passExplanation.Candidates, candidate)
+			candidateIdxs = append(candidateIdxs, bankIdx)
+		}
+
+		for i, bankIdx := range candidateIdxs {
+			if passExplanation.Candidates[i].Status == models.CandidateNotSelected {
+				m.tracer.eligible[sysIdx] = append(m.tracer.eligible[sysIdx], tracedCandidate{bankIdx: bankIdx, explanation: &passExplanation.Candidates[i]})
+			}
+		}
+	}
+}
+
+// traceAssignments resolves the eligible candidates of the pass once the assignment is done
+func (m *matchState) traceAssignments() {
+	if m.tracer == nil {
+		return
+	}
+
+	for sysIdx, candidates := range m.tracer.eligible {
+		matchedIdx, matched := m.tracer.matchedWith[sysIdx]
+		for _, traced := range candidates {
+			bankIdx, candidate := traced.bankIdx, traced.explanation
+			switch {
+			case matched && bankIdx == matchedIdx:
+				candidate.Status = models.CandidateMatched
+				candidate.Reason = ""
+			case m.matchedBankStmtLines[bankIdx]:
+				candidate.Status = models.CandidateConsumed
+				candidate.Reason = m.tracer.consumedBy[bankIdx]
+			case matched:
+				candidate.Reason = "another candidate was paired with the transaction"
+			default:
+				candidate.Reason = "left unassigned"
+			}
+		}
+	}
+	m.tracer.eligible = nil
+}
+
+// traceConsumed records what consumed a bank statement line and the outcome of traced system transactions
+func (m *matchState) traceConsumed(sysIdxs, bankIdxs []int, description string) {
+	if m.tracer == nil {
+		return
+	}
+
+	for _, bankIdx := range bankIdxs {
+		m.tracer.consumedBy[bankIdx] = description
+	}
+	for _, sysIdx := range sysIdxs {
+		if explanation, traced := m.tracer.explanations[sysIdx]; traced {
+			explanation.Outcome = description
+			if len(bankIdxs) == 1 {
+				m.tracer.matchedWith[sysIdx] = bankIdxs[0]
+			}
+		}
+	}
+}
+
+// explainMismatch describes why the pass strategy or the match condition rejects the pair, or
+// returns an empty string when isMatch accepts it. The strategy is asked once, so a plugin
+// receives a single request per pair.
+func (m *matchState) explainMismatch(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) string {
+	if decider, ok := m.strategy.(matchDecider); ok {
+		if match, reason := decider.decide(sysTrx, bankStmtLine); !match {
+			return reason
+		}
+	} else if !m.strategy.IsMatch(sysTrx, bankStmtLine) {
+		if explainer, ok := m.strategy.(MatchExplainer); ok {
+			return explainer.ExplainMismatch(sysTrx, bankStmtLine)
+		}
+		return fmt.Sprintf("rejected by %s", StrategyName(m.strategy))
+	}
+	if m.timeProximity != nil && m.timeProximity.exceedsMaxGap(sysTrx, bankStmtLine) {
+		gap, _ := timeGap(sysTrx, bankStmtLine)
+		return fmt.Sprintf("bank time is %s from the transaction time, more than the maximum gap of %s", gap, m.timeProximity.MaxGap)
+	}
+	if m.condition == nil {
+		return ""
+	}
+	return m.condition.ExplainMismatch(sysTrx, bankStmtLine)
+}
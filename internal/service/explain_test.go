@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/firmannf/recon/internal/models"
+	"github.com/firmannf/recon/internal/service"
+)
+
+func TestReconciliation_Explain(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-15 10:00:00
+TRX002,1000.00,CREDIT,2024-01-15 11:00:00
+TRX003,5000.00,CREDIT,2024-01-16 09:00:00`)
+
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date
+BCA-001,1000.00,2024-01-15
+BCA-002,1000.00,2024-01-18
+BCA-003,5000.00,2024-01-17`)
+
+	input := service.ReconciliationInput{
+		SystemTransactionFile: systemCSV,
+		BankStatementFiles:    []string{bcaCSV},
+		StartDate:             mustParseTime("2024-01-01 00:00:00"),
+		EndDate:               mustParseTime("2024-12-31 23:59:59"),
+		MatchPipeline: service.MatchPipeline{
+			{Strategy: service.NewExactMatchStrategy()},
+			{Strategy: service.NewDateWindowMatchStrategy(1)},
+		},
+	}
+	reconService := service.NewReconciliationService()
+
+	t.Run("candidate consumed and rejected", func(t *testing.T) {
+		explanations, err := reconService.Explain(input, "TRX002")
+		if err != nil {
+			t.Fatalf("Explain failed: %v", err)
+		}
+		if len(explanations) != 1 {
+			t.Fatalf("Expected 1 explanation, got %d", len(explanations))
+		}
+
+		explanation := explanations[0]
+		if explanation.Outcome != "unmatched" {
+			t.Errorf("Expected outcome 'unmatched', got '%s'", explanation.Outcome)
+		}
+		if len(explanation.Passes) != 2 {
+			t.Fatalf("Expected 2 passes, got %d", len(explanation.Passes))
+		}
+
+		exact := explanation.Passes[0]
+		if len(exact.Candidates) != 1 || exact.Candidates[0].Status != models.CandidateConsumed {
+			t.Fatalf("Expected BCA-001 consumed in the exact pass, got %+v", exact.Candidates)
+		}
+		if !strings.Contains(exact.Candidates[0].Reason, "TRX001") {
+			t.Errorf("Expected the reason to name TRX001, got '%s'", exact.Candidates[0].Reason)
+		}
+
+		window := explanation.Passes[1]
+		if len(window.Candidates) != 2 {
+			t.Fatalf("Expected 2 candidates in the window pass, got %d", len(window.Candidates))
+		}
+		rejected := window.Candidates[1]
+		if rejected.BankStatementLine.UniqueIdentifier != "BCA-002" || rejected.Status != models.CandidateRejected {
+			t.Fatalf("Expected BCA-002 rejected, got %s %s", rejected.BankStatementLine.UniqueIdentifier, rejected.Status)
+		}
+		if !strings.Contains(rejected.Reason, "3 days") {
+			t.Errorf("Expected the reason to mention the 3 days gap, got '%s'", rejected.Reason)
+		}
+	})
+
+	t.Run("matched by a later pass", func(t *testing.T) {
+		explanations, err := reconService.Explain(input, "TRX003")
+		if err != nil {
+			t.Fatalf("Explain failed: %v", err)
+		}
+
+		explanation := explanations[0]
+		if len(explanation.Passes[0].Candidates) != 0 {
+			t.Errorf("Expected no candidates in the exact pass, got %d", len(explanation.Passes[0].Candidates))
+		}
+		window := explanation.Passes[1]
+		if len(window.Candidates) != 1 || window.Candidates[0].Status != models.CandidateMatched {
+			t.Fatalf("Expected BCA-003 matched in the window pass, got %+v", window.Candidates)
+		}
+		if !strings.Contains(explanation.Outcome, "BCA-003") {
+			t.Errorf("Expected the outcome to name BCA-003, got '%s'", explanation.Outcome)
+		}
+	})
+
+	t.Run("transaction not found", func(t *testing.T) {
+		if _, err := reconService.Explain(input, "TRX999"); err == nil {
+			t.Error("Expected error but got nil")
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/models"
+)
+
+// FeeRule describes the fee a bank charges on a transaction. Credits settle net of the fee
+// (e.g. QRIS or card MDR) and debits are booked with the fee added.
+type FeeRule struct {
+	BankName string          // Optional, the rule only applies to bank statement lines of this bank
+	Channel  string          // Optional, the rule only applies to system transactions of this channel
+	Percent  decimal.Decimal // Percentage of the gross amount, e.g. 0.7 for 0.7%
+	Fixed    decimal.Decimal // Fixed fee per transaction
+}
+
+// Fee returns the fee for a gross amount, rounded to 2 decimal places
+func (r FeeRule) Fee(amount decimal.Decimal) decimal.Decimal {
+	return amount.Mul(r.Percent).Div(decimal.NewFromInt(100)).Add(r.Fixed).Round(2)
+}
+
+// appliesTo reports whether the rule covers the pair
+func (r FeeRule) appliesTo(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) bool {
+	return (r.BankName == "" || r.BankName == bankStmtLine.BankName) &&
+		(r.Channel == "" || r.Channel == sysTrx.Channel)
+}
+
+// specificity ranks rules so that bank and channel specific rules win over general ones
+func (r FeeRule) specificity() int {
+	specificity := 0
+	if r.BankName != "" {
+		specificity += 2
+	}
+	if r.Channel != "" {
+		specificity++
+	}
+	return specificity
+}
+
+// FeeCalculator is implemented by strategies that match gross system amounts to net bank amounts.
+// The fee of a matched pair is reported separately from its discrepancy.
+type FeeCalculator interface {
+	Fee(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) decimal.Decimal
+}
+
+// FeeMatchStrategy matches by exact type and date when the bank amount equals the system
+// amount adjusted by the most specific fee rule that applies to the pair
+type FeeMatchStrategy struct {
+	Rules []FeeRule
+}
+
+func NewFeeMatchStrategy(rules []FeeRule) (*FeeMatchStrategy, error) {
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("at least one fee rule is required")
+	}
+	for i, rule := range rules {
+		if rule.Percent.IsNegative() || rule.Percent.GreaterThan(decimal.NewFromInt(100)) {
+			return nil, fmt.Errorf("fee rule %d: percent must be between 0 and 100 (got %s)", i+1, rule.Percent)
+		}
+		if rule.Fixed.IsNegative() {
+			return nil, fmt.Errorf("fee rule %d: fixed fee must not be negative (got %s)", i+1, rule.Fixed)
+		}
+	}
+	return &FeeMatchStrategy{Rules: rules}, nil
+}
+
+func (s *FeeMatchStrategy) BuildKey(trxType models.TransactionType, amount decimal.Decimal, date time.Time, id string) string {
+	return fmt.Sprintf("%s_%s", trxType, date.Format("2006-01-02"))
+}
+
+func (s *FeeMatchStrategy) IsMatch(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) bool {
+	rule, found := s.ruleFor(sysTrx, bankStmtLine)
+	if !found {
+		return false
+	}
+	return netAmount(sysTrx, rule.Fee(sysTrx.Amount)).Equal(bankStmtLine.GetAbsoluteAmount())
+}
+
+func (s *FeeMatchStrategy) ExplainMismatch(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) string {
+	rule, found := s.ruleFor(sysTrx, bankStmtLine)
+	if !found {
+		return fmt.Sprintf("no fee rule applies to bank %s and channel %q", bankStmtLine.BankName, sysTrx.Channel)
+	}
+	fee := rule.Fee(sysTrx.Amount)
+	return fmt.Sprintf("expected %s after a fee of %s, bank amount is %s", netAmount(sysTrx, fee), fee, bankStmtLine.GetAbsoluteAmount())
+}
+
+// Fee returns the fee of the pair, or zero when no rule applies
+func (s *FeeMatchStrategy) Fee(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) decimal.Decimal {
+	rule, found := s.ruleFor(sysTrx, bankStmtLine)
+	if !found {
+		return decimal.Zero
+	}
+	return rule.Fee(sysTrx.Amount)
+}
+
+// ruleFor returns the most specific rule for the pair, the first one wins on ties
+func (s *FeeMatchStrategy) ruleFor(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) (FeeRule, bool) {
+	var (
+		best  FeeRule
+		found bool
+	)
+	for _, rule := range s.Rules {
+		if rule.appliesTo(sysTrx, bankStmtLine) && (!found || rule.specificity() > best.specificity()) {
+			best = rule
+			found = true
+		}
+	}
+	return best, found
+}
+
+// netAmount returns the amount the bank is expected to book for a system transaction with the given fee
+func netAmount(sysTrx models.Transaction, fee decimal.Decimal) decimal.Decimal {
+	if sysTrx.Type == models.TransactionTypeDebit {
+		return sysTrx.Amount.Add(fee)
+	}
+	return sysTrx.Amount.Sub(fee)
+}
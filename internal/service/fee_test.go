@@ -0,0 +1,128 @@
+package service_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/models"
+	"github.com/firmannf/recon/internal/service"
+)
+
+func TestFeeMatchStrategy_IsMatch(t *testing.T) {
+	strategy, err := service.NewFeeMatchStrategy([]service.FeeRule{
+		{Percent: decimal.RequireFromString("0.7")},
+		{BankName: "bank_bca", Fixed: decimal.NewFromInt(2500)},
+		{BankName: "bank_bca", Channel: "CARD", Percent: decimal.NewFromInt(2), Fixed: decimal.NewFromInt(1000)},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create strategy: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		trxType     models.TransactionType
+		channel     string
+		bankName    string
+		bankAmount  string
+		expected    bool
+		expectedFee string
+	}{
+		{"general percentage rule", models.TransactionTypeCredit, "QRIS", "bank_bri", "99300", true, "700"},
+		{"bank rule wins over general rule", models.TransactionTypeCredit, "QRIS", "bank_bca", "97500", true, "2500"},
+		{"bank and channel rule wins over bank rule", models.TransactionTypeCredit, "CARD", "bank_bca", "97000", true, "3000"},
+		{"debit is booked with the fee added", models.TransactionTypeDebit, "", "bank_bri", "-100700", true, "700"},
+		{"gross amount is not a fee match", models.TransactionTypeCredit, "QRIS", "bank_bri", "100000", false, "700"},
+		{"wrong fee", models.TransactionTypeCredit, "QRIS", "bank_bca", "99300", false, "2500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sysTrx := models.Transaction{Amount: decimal.NewFromInt(100000), Type: tt.trxType, Channel: tt.channel}
+			bankStmtLine := models.BankStatementLine{Amount: decimal.RequireFromString(tt.bankAmount), Type: tt.trxType, BankName: tt.bankName}
+
+			if strategy.IsMatch(sysTrx, bankStmtLine) != tt.expected {
+				t.Errorf("Expected IsMatch %v", tt.expected)
+			}
+			if fee := strategy.Fee(sysTrx, bankStmtLine); !fee.Equal(decimal.RequireFromString(tt.expectedFee)) {
+				t.Errorf("Expected fee %s, got %s", tt.expectedFee, fee)
+			}
+		})
+	}
+}
+
+func TestNewFeeMatchStrategy_InvalidRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []service.FeeRule
+	}{
+		{"no rules", nil},
+		{"negative percent", []service.FeeRule{{Percent: decimal.NewFromInt(-1)}}},
+		{"percent above 100", []service.FeeRule{{Percent: decimal.NewFromInt(101)}}},
+		{"negative fixed fee", []service.FeeRule{{Fixed: decimal.NewFromInt(-1)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.NewFeeMatchStrategy(tt.rules); err == nil {
+				t.Error("Expected error but got nil")
+			}
+		})
+	}
+}
+
+func TestReconciliation_FeeMatching(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime,channel
+TRX001,100000.00,CREDIT,2024-01-15 10:00:00,QRIS
+TRX002,50000.00,CREDIT,2024-01-15 11:00:00,TRANSFER
+TRX003,200000.00,CREDIT,2024-01-15 12:00:00,QRIS`)
+
+	bankCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bankCSV, `unique_identifier,amount,date
+BCA-001,99300.00,2024-01-15
+BCA-002,50000.00,2024-01-15
+BCA-003,198000.00,2024-01-15`)
+
+	fee, err := service.NewFeeMatchStrategy([]service.FeeRule{{Channel: "QRIS", Percent: decimal.RequireFromString("0.7")}})
+	if err != nil {
+		t.Fatalf("Failed to create strategy: %v", err)
+	}
+
+	reconService := service.NewReconciliationService()
+	result, err := reconService.Reconcile(service.ReconciliationInput{
+		SystemTransactionFile: systemCSV,
+		BankStatementFiles:    []string{bankCSV},
+		StartDate:             mustParseTime("2024-01-01 00:00:00"),
+		EndDate:               mustParseTime("2024-12-31 23:59:59"),
+		MatchPipeline: service.MatchPipeline{
+			{Strategy: service.NewExactMatchStrategy()},
+			{Strategy: fee},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	// TRX003 would need a 1% fee, so it stays unmatched
+	if result.TotalMatchedTransactions != 2 {
+		t.Errorf("Expected 2 matches, got %d", result.TotalMatchedTransactions)
+	}
+	if !result.TotalFees.Equal(decimal.NewFromInt(700)) {
+		t.Errorf("Expected total fees of 700, got %s", result.TotalFees)
+	}
+	if !result.TotalDiscrepancies.IsZero() {
+		t.Errorf("Expected fees not to be reported as discrepancies, got %s", result.TotalDiscrepancies)
+	}
+	for _, match := range result.Matches {
+		if match.SystemTransaction.TrxID == "TRX001" && (match.Pass != "fee" || !match.Fee.Equal(decimal.NewFromInt(700))) {
+			t.Errorf("Expected TRX001 matched by the fee pass with a fee of 700, got %s with %s", match.Pass, match.Fee)
+		}
+	}
+	if len(result.UnmatchedSystemTransactions) != 1 || result.UnmatchedSystemTransactions[0].TrxID != "TRX003" {
+		t.Errorf("Expected only TRX003 unmatched, got %v", result.UnmatchedSystemTransactions)
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/firmannf/recon/internal/models"
+)
+
+// defaultMinFileOverlap is the share of shared rows above which two inputs are reported
+const defaultMinFileOverlap = 0.8
+
+// FileCheckConfig configures the detection of bank statement files that would be counted twice:
+// two inputs of the run sharing most of their rows, or a file already processed by a previous run
+type FileCheckConfig struct {
+	Policy     DuplicatePolicy // DuplicateWarn reports the files, DuplicateReject fails the reconciliation
+	MinOverlap float64         // Optional, share of the rows of the smaller file two inputs must share, defaults to 0.8
+	History    *RunHistory     // Optional, files processed by previous runs
+}
+
+// validate checks that the configuration can be used for file checks
+func (c FileCheckConfig) validate() error {
+	if c.Policy != DuplicateWarn && c.Policy != DuplicateReject {
+		return fmt.Errorf("unknown file check policy %q, expected %s or %s", c.Policy, DuplicateWarn, DuplicateReject)
+	}
+	if c.MinOverlap < 0 || c.MinOverlap > 1 {
+		return fmt.Errorf("minimum file overlap must be between 0 and 1 (got %g)", c.MinOverlap)
+	}
+	return nil
+}
+
+// bankFile is a fingerprinted bank statement file with the keys of its rows
+type bankFile struct {
+	fingerprint models.FileFingerprint
+	rowKeys     []string // Sorted
+}
+
+// checkBankFiles fingerprints the bank statement files from their parsed lines, stmtLinesPerFile[i]
+// holding the lines of filePaths[i], and reports the files overlapping another input or a previous
+// run. Under DuplicateReject any overlap is returned as an error.
+func checkBankFiles(filePaths []string, stmtLinesPerFile [][]models.BankStatementLine, cfg FileCheckConfig) ([]models.FileFingerprint, []models.FileOverlap, error) {
+	minOverlap := cfg.MinOverlap
+	if minOverlap == 0 {
+		minOverlap = defaultMinFileOverlap
+	}
+
+	files := make([]bankFile, len(filePaths))
+	fingerprints := make([]models.FileFingerprint, len(filePaths))
+	for i, filePath := range filePaths {
+		file, err := fingerprintBankFile(filePath, stmtLinesPerFile[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fingerprint %s: %w", filePath, err)
+		}
+		files[i] = file
+		fingerprints[i] = file.fingerprint
+	}
+
+	var overlaps []models.FileOverlap
+	for j := range files {
+		for i := 0; i < j; i++ {
+			overlap := rowOverlap(files[i], files[j])
+			if overlap >= minOverlap {
+				overlaps = append(overlaps, models.FileOverlap{File: files[j].fingerprint, Other: files[i].fingerprint, Overlap: overlap})
+			}
+		}
+		if cfg.History != nil {
+			if previous, runTime, found := cfg.History.find(files[j].fingerprint); found {
+				overlaps = append(overlaps, models.FileOverlap{File: files[j].fingerprint, Other: previous, Overlap: 1, PreviousRun: runTime})
+			}
+		}
+	}
+
+	if cfg.Policy == DuplicateReject && len(overlaps) > 0 {
+		var descriptions []string
+		for _, overlap := range overlaps {
+			descriptions = append(descriptions, describeFileOverlap(overlap))
+		}
+		return nil, nil, fmt.Errorf("bank statement files would be counted twice: %s", strings.Join(descriptions, "; "))
+	}
+	return fingerprints, overlaps, nil
+}
+
+// fingerprintBankFile hashes the file content and takes the row count, date span and row keys
+// from its parsed lines, which the parser guarantees are not empty
+func fingerprintBankFile(filePath string, stmtLines []models.BankStatementLine) (bankFile, error) {
+	contentHash, err := hashFile(filePath)
+	if err != nil {
+		return bankFile{}, err
+	}
+
+	file := bankFile{
+		fingerprint: models.FileFingerprint{
+			Path:        filePath,
+			Account:     stmtLines[0].BankName,
+			ContentHash: contentHash,
+			Rows:        len(stmtLines),
+			FirstDate:   stmtLines[0].Date,
+			LastDate:    stmtLines[0].Date,
+		},
+	}
+
+	// Keys ignore the bank name and the formatting of amounts and dates, so the same statement
+	// saved under another name or exported again compares equal
+	for _, stmtLine := range stmtLines {
+		file.rowKeys = append(file.rowKeys, fmt.Sprintf("%s|%s|%s", stmtLine.UniqueIdentifier, stmtLine.Amount, stmtLine.Date.Format(time.RFC3339)))
+		if stmtLine.Date.Before(file.fingerprint.FirstDate) {
+			file.fingerprint.FirstDate = stmtLine.Date
+		}
+		if stmtLine.Date.After(file.fingerprint.LastDate) {
+			file.fingerprint.LastDate = stmtLine.Date
+		}
+	}
+	sort.Strings(file.rowKeys)
+	rowsHash := sha256.Sum256([]byte(strings.Join(file.rowKeys, "\n")))
+	file.fingerprint.RowsHash = hex.EncodeToString(rowsHash[:])
+
+	return file, nil
+}
+
+// hashFile returns the hex SHA-256 of the file, streamed so that large files are not held in memory
+func hashFile(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// rowOverlap returns the share of the rows of the smaller file that are also in the other one
+func rowOverlap(a, b bankFile) float64 {
+	if a.fingerprint.ContentHash == b.fingerprint.ContentHash {
+		return 1
+	}
+
+	// Both key lists are sorted, walk them together counting rows present in both
+	shared := 0
+	for i, j := 0, 0; i < len(a.rowKeys) && j < len(b.rowKeys); {
+		switch {
+		case a.rowKeys[i] == b.rowKeys[j]:
+			shared++
+			i++
+			j++
+		case a.rowKeys[i] < b.rowKeys[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return float64(shared) / float64(min(len(a.rowKeys), len(b.rowKeys)))
+}
+
+// describeFileOverlap describes an overlap for error messages and reports
+func describeFileOverlap(overlap models.FileOverlap) string {
+	if !overlap.PreviousRun.IsZero() {
+		return fmt.Sprintf("%s was already processed as %s on %s", overlap.File.Path, overlap.Other.Path, overlap.PreviousRun.Format("2006-01-02 15:04:05"))
+	}
+	return fmt.Sprintf("%s shares %.0f%% of its rows with %s", overlap.File.Path, overlap.Overlap*100, overlap.Other.Path)
+}
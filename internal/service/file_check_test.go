@@ -0,0 +1,193 @@
+package service_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/firmannf/recon/internal/service"
+)
+
+func TestReconciliation_FileCheck(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-15 10:00:00
+TRX002,2000.00,CREDIT,2024-01-16 10:00:00`)
+
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date
+BCA-001,1000.00,2024-01-15
+BCA-002,2000.00,2024-01-16`)
+
+	// The same statement uploaded again under another name
+	copyCSV := filepath.Join(tmpDir, "bca_january.csv")
+	writeTestFile(t, copyCSV, `unique_identifier,amount,date
+BCA-001,1000.00,2024-01-15
+BCA-002,2000.00,2024-01-16`)
+
+	// The same statement exported again in another row order and date format
+	exportCSV := filepath.Join(tmpDir, "bca_export.csv")
+	writeTestFile(t, exportCSV, `unique_identifier,amount,date
+BCA-002,2000,16/01/2024
+BCA-001,1000.0,15/01/2024`)
+
+	// A different statement sharing one line
+	otherCSV := filepath.Join(tmpDir, "bank_bri.csv")
+	writeTestFile(t, otherCSV, `unique_identifier,amount,date
+BCA-001,1000.00,2024-01-15
+BRI-002,3000.00,2024-01-16
+BRI-003,4000.00,2024-01-17`)
+
+	tests := []struct {
+		name             string
+		bankFiles        []string
+		policy           service.DuplicatePolicy
+		minOverlap       float64
+		expectedOverlaps []string
+		expectedError    string
+	}{
+		{
+			name:             "copy under another name",
+			bankFiles:        []string{bcaCSV, copyCSV},
+			policy:           service.DuplicateWarn,
+			expectedOverlaps: []string{"bca_january.csv~bank_bca.csv"},
+		},
+		{
+			name:             "export in another format",
+			bankFiles:        []string{bcaCSV, exportCSV},
+			policy:           service.DuplicateWarn,
+			expectedOverlaps: []string{"bca_export.csv~bank_bca.csv"},
+		},
+		{
+			name:      "partial overlap below the minimum",
+			bankFiles: []string{bcaCSV, otherCSV},
+			policy:    service.DuplicateWarn,
+		},
+		{
+			name:             "partial overlap above a lower minimum",
+			bankFiles:        []string{bcaCSV, otherCSV},
+			policy:           service.DuplicateWarn,
+			minOverlap:       0.5,
+			expectedOverlaps: []string{"bank_bri.csv~bank_bca.csv"},
+		},
+		{
+			name:          "reject",
+			bankFiles:     []string{bcaCSV, copyCSV},
+			policy:        service.DuplicateReject,
+			expectedError: "shares 100% of its rows with",
+		},
+		{
+			name:          "dedupe is not a file policy",
+			bankFiles:     []string{bcaCSV},
+			policy:        service.DuplicateDedupe,
+			expectedError: "unknown file check policy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconService := service.NewReconciliationService()
+			result, err := reconService.Reconcile(service.ReconciliationInput{
+				SystemTransactionFile: systemCSV,
+				BankStatementFiles:    tt.bankFiles,
+				StartDate:             mustParseTime("2024-01-01 00:00:00"),
+				EndDate:               mustParseTime("2024-12-31 23:59:59"),
+				MatchStrategy:         service.NewExactMatchStrategy(),
+				FileCheck:             &service.FileCheckConfig{Policy: tt.policy, MinOverlap: tt.minOverlap},
+			})
+
+			if tt.expectedError != "" {
+				if err == nil {
+					t.Fatal("Expected error but got nil")
+				}
+				if !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tt.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Reconciliation failed: %v", err)
+			}
+
+			if len(result.FileFingerprints) != len(tt.bankFiles) {
+				t.Errorf("Expected %d fingerprints, got %d", len(tt.bankFiles), len(result.FileFingerprints))
+			}
+			if len(result.FileOverlaps) != len(tt.expectedOverlaps) {
+				t.Fatalf("Expected %d file overlaps, got %d", len(tt.expectedOverlaps), len(result.FileOverlaps))
+			}
+			for i, expected := range tt.expectedOverlaps {
+				overlap := result.FileOverlaps[i]
+				got := filepath.Base(overlap.File.Path) + "~" + filepath.Base(overlap.Other.Path)
+				if got != expected {
+					t.Errorf("Expected overlap %s, got %s", expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestReconciliation_FileCheckHistory(t *testing.T) {
+	tmpDir := t.TempDir()
+	historyPath := filepath.Join(tmpDir, "history", "runs.json")
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-15 10:00:00`)
+
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date
+BCA-001,1000.00,2024-01-15`)
+
+	renamedCSV := filepath.Join(tmpDir, "bca_again.csv")
+	writeTestFile(t, renamedCSV, `unique_identifier,amount,date
+BCA-001,1000.00,2024-01-15`)
+
+	reconcile := func(bankFile string, policy service.DuplicatePolicy) (*service.RunHistory, error) {
+		history, err := service.OpenRunHistory(historyPath)
+		if err != nil {
+			t.Fatalf("Failed to open history: %v", err)
+		}
+		reconService := service.NewReconciliationService()
+		result, err := reconService.Reconcile(service.ReconciliationInput{
+			SystemTransactionFile: systemCSV,
+			BankStatementFiles:    []string{bankFile},
+			StartDate:             mustParseTime("2024-01-01 00:00:00"),
+			EndDate:               mustParseTime("2024-12-31 23:59:59"),
+			MatchStrategy:         service.NewExactMatchStrategy(),
+			FileCheck:             &service.FileCheckConfig{Policy: policy, History: history},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(result.FileOverlaps) > 0 && result.FileOverlaps[0].PreviousRun.IsZero() {
+			t.Error("Expected the overlap to come from a previous run")
+		}
+		if err := history.Record(mustParseTime("2024-02-01 09:00:00"), result.FileFingerprints); err != nil {
+			t.Fatalf("Failed to record run: %v", err)
+		}
+		return history, nil
+	}
+
+	if _, err := reconcile(bcaCSV, service.DuplicateReject); err != nil {
+		t.Fatalf("Expected the first run to pass, got %v", err)
+	}
+
+	_, err := reconcile(renamedCSV, service.DuplicateReject)
+	if err == nil {
+		t.Fatal("Expected the renamed statement to be rejected but got nil")
+	}
+	if !strings.Contains(err.Error(), "was already processed as "+bcaCSV+" on 2024-02-01 09:00:00") {
+		t.Errorf("Expected error naming the previous run, got %q", err.Error())
+	}
+
+	if _, err := reconcile(renamedCSV, service.DuplicateWarn); err != nil {
+		t.Errorf("Expected a warning only, got %v", err)
+	}
+
+	writeTestFile(t, historyPath, "not json")
+	if _, err := service.OpenRunHistory(historyPath); err == nil {
+		t.Error("Expected error for a corrupt history but got nil")
+	}
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/models"
+)
+
+// defaultMaxGroupSize is the largest group searched when GroupMatchConfig.MaxGroupSize is not set
+const defaultMaxGroupSize = 30
+
+// Names of the group matching steps in pass summaries
+const (
+	batchPassName = "batch"
+	splitPassName = "split"
+)
+
+// GroupMatchConfig configures matching a single item against a group of items on the other side
+type GroupMatchConfig struct {
+	WindowDays   int             // The bank date may be up to WindowDays days after the system transaction dates
+	Tolerance    decimal.Decimal // Maximum absolute difference between the group total and the single item
+	MaxGroupSize int             // Optional, maximum number of items in a group, defaults to defaultMaxGroupSize
+}
+
+// validate checks that the configuration can be used for group matching
+func (c GroupMatchConfig) validate() error {
+	if c.WindowDays < 0 {
+		return fmt.Errorf("group match window must not be negative (got %d)", c.WindowDays)
+	}
+	if c.Tolerance.IsNegative() {
+		return fmt.Errorf("group match tolerance must not be negative (got %s)", c.Tolerance)
+	}
+	if c.MaxGroupSize != 0 && c.MaxGroupSize < 2 {
+		return fmt.Errorf("group match size must be at least 2 (got %d)", c.MaxGroupSize)
+	}
+	return nil
+}
+
+func (c GroupMatchConfig) maxGroupSize() int {
+	if c.MaxGroupSize == 0 {
+		return defaultMaxGroupSize
+	}
+	return c.MaxGroupSize
+}
+
+// matchBatches matches each unmatched bank statement line against a group of unmatched
+// system transactions of the same type, dated within the window, whose amounts add up to it.
+// It returns the number of groups found.
+func (m *matchState) matchBatches(cfg GroupMatchConfig) int {
+	// Index the remaining system transactions by type and day
+	systemTrxsByDay := make(map[string][]int)
+	for sysIdx, sysTrx := range m.systemTrxs {
+		if !m.matchedSystemTrxs[sysIdx] && sysTrx.Amount.IsPositive() {
+			key := groupDayKey(sysTrx.Type, sysTrx.TransactionTime)
+			systemTrxsByDay[key] = append(systemTrxsByDay[key], sysIdx)
+		}
+	}
+
+	m.passName = batchPassName
+	tolerance := toCents(cfg.Tolerance)
+	matched := 0
+	for bankIdx, bankStmtLine := range m.bankStmtLines {
+		if m.matchedBankStmtLines[bankIdx] || bankStmtLine.Amount.IsZero() {
+			continue
+		}
+
+		var candidates []int
+		var amounts []int64
+		for days := 0; days <= cfg.WindowDays; days++ {
+			day := bankStmtLine.Date.AddDate(0, 0, -days)
+			for _, sysIdx := range systemTrxsByDay[groupDayKey(bankStmtLine.Type, day)] {
+				if !m.matchedSystemTrxs[sysIdx] {
+					candidates = append(candidates, sysIdx)
+					amounts = append(amounts, toCents(m.systemTrxs[sysIdx].Amount))
+				}
+			}
+		}
+		if len(candidates) < 2 {
+			continue
+		}
+
+		chosen := findSubsetSum(amounts, toCents(bankStmtLine.GetAbsoluteAmount()), tolerance, 2, cfg.maxGroupSize())
+		if chosen == nil {
+			continue
+		}
+
+		sysIdxs := make([]int, len(chosen))
+		for i, c := range chosen {
+			sysIdxs[i] = candidates[c]
+		}
+		m.result.BatchMatches = append(m.result.BatchMatches, m.recordGroup(sysIdxs, []int{bankIdx}))
+		matched++
+	}
+	return matched
+}
+
+// matchSplits matches each unmatched system transaction against a group of unmatched
+// bank statement lines from a single bank, of the same type and dated within the window,
+// whose amounts add up to it. It returns the number of groups found.
+func (m *matchState) matchSplits(cfg GroupMatchConfig) int {
+	// Index the remaining bank statement lines by bank, type and day
+	bankStmtLinesByDay := make(map[string][]int)
+	for bankIdx, bankStmtLine := range m.bankStmtLines {
+		if !m.matchedBankStmtLines[bankIdx] && !bankStmtLine.Amount.IsZero() {
+			key := groupDayKey(bankStmtLine.Type, bankStmtLine.Date)
+			bankStmtLinesByDay[key] = append(bankStmtLinesByDay[key], bankIdx)
+		}
+	}
+
+	m.passName = splitPassName
+	tolerance := toCents(cfg.Tolerance)
+	matched := 0
+	for sysIdx, sysTrx := range m.systemTrxs {
+		if m.matchedSystemTrxs[sysIdx] || !sysTrx.Amount.IsPositive() {
+			continue
+		}
+
+		// Split postings come from the bank that executed the payout, so candidates are grouped per bank
+		var bankNames []string
+		candidatesByBank := make(map[string][]int)
+		for days := 0; days <= cfg.WindowDays; days++ {
+			day := sysTrx.TransactionTime.AddDate(0, 0, days)
+			for _, bankIdx := range bankStmtLinesByDay[groupDayKey(sysTrx.Type, day)] {
+				if m.matchedBankStmtLines[bankIdx] {
+					continue
+				}
+				bankName := m.bankStmtLines[bankIdx].BankName
+				if _, exists := candidatesByBank[bankName]; !exists {
+					bankNames = append(bankNames, bankName)
+				}
+				candidatesByBank[bankName] = append(candidatesByBank[bankName], bankIdx)
+			}
+		}
+
+		for _, bankName := range bankNames {
+			candidates := candidatesByBank[bankName]
+			if len(candidates) < 2 {
+				continue
+			}
+
+			amounts := make([]int64, len(candidates))
+			for i, bankIdx := range candidates {
+				amounts[i] = toCents(m.bankStmtLines[bankIdx].GetAbsoluteAmount())
+			}
+			chosen := findSubsetSum(amounts, toCents(sysTrx.Amount), tolerance, 2, cfg.maxGroupSize())
+			if chosen == nil {
+				continue
+			}
+
+			bankIdxs := make([]int, len(chosen))
+			for i, c := range chosen {
+				bankIdxs[i] = candidates[c]
+			}
+			m.result.SplitMatches = append(m.result.SplitMatches, m.recordGroup([]int{sysIdx}, bankIdxs))
+			matched++
+			break
+		}
+	}
+	return matched
+}
+
+// recordGroup marks every member of the group as matched and counts the group as one match
+func (m *matchState) recordGroup(sysIdxs, bankIdxs []int) models.GroupedMatch {
+	group := models.GroupedMatch{}
+	systemTotal := decimal.Zero
+	bankTotal := decimal.Zero
+	for _, sysIdx := range sysIdxs {
+		m.matchedSystemTrxs[sysIdx] = true
+		group.SystemTransactions = append(group.SystemTransactions, m.systemTrxs[sysIdx])
+		systemTotal = systemTotal.Add(m.systemTrxs[sysIdx].Amount)
+	}
+	for _, bankIdx := range bankIdxs {
+		m.matchedBankStmtLines[bankIdx] = true
+		group.BankStatementLines = append(group.BankStatementLines, m.bankStmtLines[bankIdx])
+		bankTotal = bankTotal.Add(m.bankStmtLines[bankIdx].GetAbsoluteAmount())
+	}
+
+	m.result.TotalMatchedTransactions++
+	m.traceConsumed(sysIdxs, bankIdxs, fmt.Sprintf("grouped %d system transactions with %d bank statement lines by %s", len(sysIdxs), len(bankIdxs), m.passName))
+	group.Discrepancy = systemTotal.Sub(bankTotal).Abs()
+	if !group.Discrepancy.IsZero() {
+		m.result.TotalDiscrepancies = m.result.TotalDiscrepancies.Add(group.Discrepancy)
+	}
+	return group
+}
+
+func groupDayKey(trxType models.TransactionType, day time.Time) string {
+	return fmt.Sprintf("%s_%s", trxType, day.Format("2006-01-02"))
+}
+
+// toCents converts an amount with at most 2 decimal places to the smallest currency unit
+func toCents(amount decimal.Decimal) int64 {
+	return amount.Shift(2).Round(0).IntPart()
+}
@@ -0,0 +1,205 @@
+package service_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/service"
+)
+
+func TestReconciliation_BatchMatching(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-15 08:00:00
+TRX002,2000.00,CREDIT,2024-01-15 09:00:00
+TRX003,3000.00,CREDIT,2024-01-15 10:00:00
+TRX004,1500.00,CREDIT,2024-01-15 11:00:00
+TRX005,500.00,DEBIT,2024-01-15 12:00:00
+TRX006,700.00,CREDIT,2024-01-15 13:00:00`)
+
+	bankCSV := filepath.Join(tmpDir, "bank_gateway.csv")
+	writeTestFile(t, bankCSV, `unique_identifier,amount,date
+GW-001,1500.00,2024-01-15
+GW-002,5999.00,2024-01-16
+GW-003,-500.00,2024-01-15`)
+
+	tests := []struct {
+		name              string
+		batchMatching     *service.GroupMatchConfig
+		expectedMatched   int
+		expectedBatches   int
+		expectedUnmatched int
+		expectedDiff      string
+	}{
+		{
+			name:              "disabled",
+			expectedMatched:   2,
+			expectedUnmatched: 5,
+			expectedDiff:      "0",
+		},
+		{
+			name:              "settlement outside window",
+			batchMatching:     &service.GroupMatchConfig{Tolerance: decimal.NewFromInt(1)},
+			expectedMatched:   2,
+			expectedUnmatched: 5,
+			expectedDiff:      "0",
+		},
+		{
+			name:              "settlement outside tolerance",
+			batchMatching:     &service.GroupMatchConfig{WindowDays: 1},
+			expectedMatched:   2,
+			expectedUnmatched: 5,
+			expectedDiff:      "0",
+		},
+		{
+			name:              "settlement within window and tolerance",
+			batchMatching:     &service.GroupMatchConfig{WindowDays: 1, Tolerance: decimal.NewFromInt(1)},
+			expectedMatched:   3,
+			expectedBatches:   1,
+			expectedUnmatched: 1,
+			expectedDiff:      "1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconService := service.NewReconciliationService()
+			result, err := reconService.Reconcile(service.ReconciliationInput{
+				SystemTransactionFile: systemCSV,
+				BankStatementFiles:    []string{bankCSV},
+				StartDate:             mustParseTime("2024-01-01 00:00:00"),
+				EndDate:               mustParseTime("2024-12-31 23:59:59"),
+				MatchStrategy:         service.NewExactMatchStrategy(),
+				BatchMatching:         tt.batchMatching,
+			})
+			if err != nil {
+				t.Fatalf("Reconciliation failed: %v", err)
+			}
+
+			if result.TotalMatchedTransactions != tt.expectedMatched {
+				t.Errorf("Expected %d matches, got %d", tt.expectedMatched, result.TotalMatchedTransactions)
+			}
+			if len(result.BatchMatches) != tt.expectedBatches {
+				t.Fatalf("Expected %d batch matches, got %d", tt.expectedBatches, len(result.BatchMatches))
+			}
+			if result.TotalUnmatchedTransactions != tt.expectedUnmatched {
+				t.Errorf("Expected %d unmatched, got %d", tt.expectedUnmatched, result.TotalUnmatchedTransactions)
+			}
+			if !result.TotalDiscrepancies.Equal(decimal.RequireFromString(tt.expectedDiff)) {
+				t.Errorf("Expected discrepancies of %s, got %s", tt.expectedDiff, result.TotalDiscrepancies)
+			}
+
+			if tt.expectedBatches > 0 {
+				batch := result.BatchMatches[0]
+				// TRX004 is matched one-to-one with GW-001 before batching
+				var trxIDs []string
+				for _, trx := range batch.SystemTransactions {
+					trxIDs = append(trxIDs, trx.TrxID)
+				}
+				if len(trxIDs) != 3 || trxIDs[0] != "TRX001" || trxIDs[1] != "TRX002" || trxIDs[2] != "TRX003" {
+					t.Errorf("Expected batch of TRX001, TRX002 and TRX003, got %v", trxIDs)
+				}
+				if len(batch.BankStatementLines) != 1 || batch.BankStatementLines[0].UniqueIdentifier != "GW-002" {
+					t.Errorf("Expected batch settled by GW-002, got %v", batch.BankStatementLines)
+				}
+			}
+		})
+	}
+}
+
+func TestReconciliation_InvalidGroupMatchConfig(t *testing.T) {
+	_, err := service.NewReconciliationService().Reconcile(service.ReconciliationInput{
+		StartDate:     mustParseTime("2024-01-01 00:00:00"),
+		MatchStrategy: service.NewExactMatchStrategy(),
+		BatchMatching: &service.GroupMatchConfig{MaxGroupSize: 1},
+	})
+	if err == nil {
+		t.Error("Expected error but got nil")
+	}
+}
+
+func TestReconciliation_SplitMatching(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,25000000.00,DEBIT,2024-01-15 08:00:00
+TRX002,15000000.00,DEBIT,2024-01-15 09:00:00`)
+
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date
+BCA-001,-10000000.00,2024-01-15
+BCA-002,-10000000.00,2024-01-15
+BCA-003,-5000000.00,2024-01-16
+BCA-004,-10000000.00,2024-01-20`)
+
+	briCSV := filepath.Join(tmpDir, "bank_bri.csv")
+	writeTestFile(t, briCSV, `unique_identifier,amount,date
+BRI-001,-5000000.00,2024-01-15`)
+
+	tests := []struct {
+		name           string
+		splitMatching  *service.GroupMatchConfig
+		expectedSplits int
+	}{
+		{
+			name: "disabled",
+		},
+		{
+			name:          "postings outside window",
+			splitMatching: &service.GroupMatchConfig{},
+		},
+		{
+			name:           "postings within window",
+			splitMatching:  &service.GroupMatchConfig{WindowDays: 1},
+			expectedSplits: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconService := service.NewReconciliationService()
+			result, err := reconService.Reconcile(service.ReconciliationInput{
+				SystemTransactionFile: systemCSV,
+				BankStatementFiles:    []string{bcaCSV, briCSV},
+				StartDate:             mustParseTime("2024-01-01 00:00:00"),
+				EndDate:               mustParseTime("2024-12-31 23:59:59"),
+				MatchStrategy:         service.NewExactMatchStrategy(),
+				SplitMatching:         tt.splitMatching,
+			})
+			if err != nil {
+				t.Fatalf("Reconciliation failed: %v", err)
+			}
+
+			if len(result.SplitMatches) != tt.expectedSplits {
+				t.Fatalf("Expected %d split matches, got %d", tt.expectedSplits, len(result.SplitMatches))
+			}
+			if tt.expectedSplits == 0 {
+				return
+			}
+
+			// TRX002 is not matched because BCA-002 and BRI-001 come from different banks
+			split := result.SplitMatches[0]
+			if len(split.SystemTransactions) != 1 || split.SystemTransactions[0].TrxID != "TRX001" {
+				t.Errorf("Expected TRX001 to be split, got %v", split.SystemTransactions)
+			}
+			var identifiers []string
+			for _, stmtLine := range split.BankStatementLines {
+				identifiers = append(identifiers, stmtLine.UniqueIdentifier)
+			}
+			if len(identifiers) != 3 || identifiers[0] != "BCA-001" || identifiers[1] != "BCA-002" || identifiers[2] != "BCA-003" {
+				t.Errorf("Expected split over BCA-001, BCA-002 and BCA-003, got %v", identifiers)
+			}
+			if result.TotalMatchedTransactions != 1 {
+				t.Errorf("Expected 1 match, got %d", result.TotalMatchedTransactions)
+			}
+			if len(result.UnmatchedSystemTransactions) != 1 || result.UnmatchedSystemTransactions[0].TrxID != "TRX002" {
+				t.Errorf("Expected only TRX002 unmatched, got %v", result.UnmatchedSystemTransactions)
+			}
+		})
+	}
+}
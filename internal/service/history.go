@@ -0,0 +1,109 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/firmannf/recon/internal/models"
+)
+
+// RunHistory is a local store of the bank statement files processed by previous runs, kept as
+// a JSON file so that a statement fed again in a later run can be recognised
+type RunHistory struct {
+	path string
+	runs []historyRun
+}
+
+type historyRun struct {
+	Time  time.Time     `json:"time"`
+	Files []historyFile `json:"files"`
+}
+
+type historyFile struct {
+	Path        string    `json:"path"`
+	Account     string    `json:"account"`
+	ContentHash string    `json:"content_sha256"`
+	RowsHash    string    `json:"rows_sha256"`
+	Rows        int       `json:"rows"`
+	FirstDate   time.Time `json:"first_date"`
+	LastDate    time.Time `json:"last_date"`
+}
+
+// OpenRunHistory loads the history stored at path, a missing file is an empty history
+func OpenRunHistory(path string) (*RunHistory, error) {
+	history := &RunHistory{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run history: %w", err)
+	}
+	if err := json.Unmarshal(data, &history.runs); err != nil {
+		return nil, fmt.Errorf("invalid run history %s: %w", path, err)
+	}
+	return history, nil
+}
+
+// Record adds a run with its bank statement files and saves the history
+func (h *RunHistory) Record(runTime time.Time, fingerprints []models.FileFingerprint) error {
+	run := historyRun{Time: runTime, Files: []historyFile{}}
+	for _, fingerprint := range fingerprints {
+		run.Files = append(run.Files, historyFile{
+			Path:        fingerprint.Path,
+			Account:     fingerprint.Account,
+			ContentHash: fingerprint.ContentHash,
+			RowsHash:    fingerprint.RowsHash,
+			Rows:        fingerprint.Rows,
+			FirstDate:   fingerprint.FirstDate,
+			LastDate:    fingerprint.LastDate,
+		})
+	}
+	h.runs = append(h.runs, run)
+
+	data, err := json.MarshalIndent(h.runs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run history: %w", err)
+	}
+
+	// Write to a temporary file first so that an interrupted save keeps the previous history
+	if dir := filepath.Dir(h.path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create run history directory: %w", err)
+		}
+	}
+	tmpPath := h.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write run history: %w", err)
+	}
+	if err := os.Rename(tmpPath, h.path); err != nil {
+		return fmt.Errorf("failed to write run history: %w", err)
+	}
+	return nil
+}
+
+// find returns the most recent previously processed file with the same content or rows
+func (h *RunHistory) find(fingerprint models.FileFingerprint) (models.FileFingerprint, time.Time, bool) {
+	for i := len(h.runs) - 1; i >= 0; i-- {
+		for _, file := range h.runs[i].Files {
+			if file.ContentHash != fingerprint.ContentHash && file.RowsHash != fingerprint.RowsHash {
+				continue
+			}
+			return models.FileFingerprint{
+				Path:        file.Path,
+				Account:     file.Account,
+				ContentHash: file.ContentHash,
+				RowsHash:    file.RowsHash,
+				Rows:        file.Rows,
+				FirstDate:   file.FirstDate,
+				LastDate:    file.LastDate,
+			}, h.runs[i].Time, true
+		}
+	}
+	return models.FileFingerprint{}, time.Time{}, false
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"time"
+
+	"github.com/firmannf/recon/internal/calendar"
+	"github.com/firmannf/recon/internal/models"
+)
+
+// MatchScorer rates how likely a candidate pair belongs together, from 0 (unlikely) to 1 (certain)
+type MatchScorer interface {
+	Score(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) float64
+}
+
+// DefaultMatchScorer combines time proximity, reference similarity, amount difference and,
+// for transactions that carry one, counterparty name similarity
+type DefaultMatchScorer struct {
+	TimeWeight         float64
+	ReferenceWeight    float64
+	AmountWeight       float64
+	CounterpartyWeight float64       // Only applied when the system transaction has a counterparty
+	TimeScale          time.Duration // Time gap at which the time proximity score halves
+}
+
+func NewDefaultMatchScorer() *DefaultMatchScorer {
+	return &DefaultMatchScorer{
+		TimeWeight:         0.5,
+		ReferenceWeight:    0.3,
+		AmountWeight:       0.2,
+		CounterpartyWeight: 0.3,
+		TimeScale:          24 * time.Hour,
+	}
+}
+
+func (s *DefaultMatchScorer) Score(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) float64 {
+	totalWeight := s.TimeWeight + s.ReferenceWeight + s.AmountWeight
+	score := s.TimeWeight*s.timeProximity(sysTrx, bankStmtLine) +
+		s.ReferenceWeight*referenceSimilarity(sysTrx.TrxID, bankStmtLine.UniqueIdentifier) +
+		s.AmountWeight*amountSimilarity(sysTrx, bankStmtLine)
+
+	if sysTrx.Counterparty != "" {
+		totalWeight += s.CounterpartyWeight
+		score += s.CounterpartyWeight * counterpartySimilarity(sysTrx.Counterparty, bankStmtLine)
+	}
+
+	if totalWeight <= 0 {
+		return 0
+	}
+	return score / totalWeight
+}
+
+// timeProximity is 1 for the same instant and decreases with the gap between the transactions.
+// Bank lines without a time component are compared by calendar day.
+func (s *DefaultMatchScorer) timeProximity(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) float64 {
+	sysTime := sysTrx.TransactionTime
+	if !hasBookingTime(bankStmtLine) {
+		sysTime = calendar.StartOfDay(sysTime.In(bankStmtLine.Date.Location()))
+	}
+
+	gap := sysTime.Sub(bankStmtLine.Date).Abs()
+	if s.TimeScale <= 0 {
+		if gap == 0 {
+			return 1
+		}
+		return 0
+	}
+	return 1 / (1 + float64(gap)/float64(s.TimeScale))
+}
+
+// amountSimilarity is 1 for equal amounts and decreases with the relative difference
+func amountSimilarity(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) float64 {
+	bankAmount := bankStmtLine.GetAbsoluteAmount()
+	largest := sysTrx.Amount.Abs()
+	if bankAmount.GreaterThan(largest) {
+		largest = bankAmount
+	}
+	if largest.IsZero() {
+		return 1
+	}
+
+	relativeDiff, _ := sysTrx.Amount.Sub(bankAmount).Abs().Div(largest).Float64()
+	return max(0, 1-relativeDiff)
+}
+
+// isDateOnly reports whether the time has no time-of-day component
+func isDateOnly(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
@@ -2,8 +2,10 @@ package service
 
 import (
 	"fmt"
+	"regexp"
 	"time"
 
+	"github.com/firmannf/recon/internal/calendar"
 	"github.com/firmannf/recon/internal/models"
 	"github.com/shopspring/decimal"
 )
@@ -32,3 +34,149 @@ func (s *ExactMatchStrategy) IsMatch(sysTrx models.Transaction, bankStmtLine mod
 	// No additional validation needed for exact match
 	return true
 }
+
+// DateWindowMatchStrategy matches by exact type and amount when the bank line is booked
+// on the transaction date or up to Days days later (T+N settlement)
+type DateWindowMatchStrategy struct {
+	Days int
+}
+
+func NewDateWindowMatchStrategy(days int) *DateWindowMatchStrategy {
+	return &DateWindowMatchStrategy{Days: days}
+}
+
+func (s *DateWindowMatchStrategy) BuildKey(trxType models.TransactionType, amount decimal.Decimal, date time.Time, id string) string {
+	return fmt.Sprintf("%s_%s", trxType, amount.String())
+}
+
+func (s *DateWindowMatchStrategy) IsMatch(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) bool {
+	days := calendar.DaysBetween(sysTrx.TransactionTime, bankStmtLine.Date)
+	return days >= 0 && days <= s.Days
+}
+
+func (s *DateWindowMatchStrategy) ExplainMismatch(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) string {
+	return fmt.Sprintf("bank date is %d days after the transaction date, expected 0 to %d", calendar.DaysBetween(sysTrx.TransactionTime, bankStmtLine.Date), s.Days)
+}
+
+// AmountToleranceMatchStrategy matches by exact type and date when the amounts differ by at most Tolerance
+type AmountToleranceMatchStrategy struct {
+	Tolerance decimal.Decimal
+}
+
+func NewAmountToleranceMatchStrategy(tolerance decimal.Decimal) *AmountToleranceMatchStrategy {
+	return &AmountToleranceMatchStrategy{Tolerance: tolerance.Abs()}
+}
+
+func (s *AmountToleranceMatchStrategy) BuildKey(trxType models.TransactionType, amount decimal.Decimal, date time.Time, id string) string {
+	return fmt.Sprintf("%s_%s", trxType, date.Format("2006-01-02"))
+}
+
+func (s *AmountToleranceMatchStrategy) IsMatch(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) bool {
+	return sysTrx.Amount.Sub(bankStmtLine.GetAbsoluteAmount()).Abs().LessThanOrEqual(s.Tolerance)
+}
+
+func (s *AmountToleranceMatchStrategy) ExplainMismatch(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) string {
+	return fmt.Sprintf("amount difference %s exceeds the tolerance of %s", sysTrx.Amount.Sub(bankStmtLine.GetAbsoluteAmount()).Abs(), s.Tolerance)
+}
+
+// ReferenceExtractor is implemented by strategies that index bank statement lines by the
+// references found in them instead of BuildKey on the unique identifier
+type ReferenceExtractor interface {
+	ExtractReferences(bankStmtLine models.BankStatementLine) []string
+}
+
+// FallbackProvider is implemented by strategies that hand transactions they could not match to another strategy
+type FallbackProvider interface {
+	Fallback() MatchStrategy
+}
+
+// DefaultReferencePatterns matches ID-like tokens such as TRX001, INV-2024-0001 or PO/123
+var DefaultReferencePatterns = []string{`[A-Za-z]+(?:[-_/]?[0-9]+)+`}
+
+// DefaultReferenceWindowDays is the number of days a reference match may be booked before or after the transaction
+const DefaultReferenceWindowDays = 7
+
+// DefaultReferenceMaxDifferencePercent is the largest amount difference of a reference match, in percent of the transaction amount
+var DefaultReferenceMaxDifferencePercent = decimal.NewFromInt(10)
+
+// ReferenceMatchStrategy matches bank statement lines whose identifier or description contains
+// a system TrxID. Amounts may differ, the difference is reported as a discrepancy. The bank line
+// must still be booked near the transaction with a similar amount, so a reference quoted in an
+// unrelated narrative months later is not matched.
+type ReferenceMatchStrategy struct {
+	WindowDays           int             // Days the bank date may be before or after the transaction date
+	MaxDifferencePercent decimal.Decimal // Largest amount difference in percent of the transaction amount
+
+	patterns []*regexp.Regexp
+	fallback MatchStrategy
+}
+
+// NewReferenceMatchStrategy compiles the reference patterns, using the first capture group of a
+// pattern as the reference when present. Transactions without a reference match are passed to fallback (optional).
+// The window and amount difference start at DefaultReferenceWindowDays and DefaultReferenceMaxDifferencePercent.
+func NewReferenceMatchStrategy(patterns []string, fallback MatchStrategy) (*ReferenceMatchStrategy, error) {
+	if len(patterns) == 0 {
+		patterns = DefaultReferencePatterns
+	}
+
+	s := &ReferenceMatchStrategy{
+		WindowDays:           DefaultReferenceWindowDays,
+		MaxDifferencePercent: DefaultReferenceMaxDifferencePercent,
+		fallback:             fallback,
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reference pattern %q: %w", pattern, err)
+		}
+		s.patterns = append(s.patterns, re)
+	}
+	return s, nil
+}
+
+func (s *ReferenceMatchStrategy) BuildKey(trxType models.TransactionType, amount decimal.Decimal, date time.Time, id string) string {
+	return "REF_" + normalizeReference(id)
+}
+
+func (s *ReferenceMatchStrategy) IsMatch(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) bool {
+	return s.ExplainMismatch(sysTrx, bankStmtLine) == ""
+}
+
+func (s *ReferenceMatchStrategy) ExplainMismatch(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) string {
+	if sysTrx.Type != bankStmtLine.Type {
+		return fmt.Sprintf("bank statement line is a %s, the transaction a %s", bankStmtLine.Type, sysTrx.Type)
+	}
+	if days := calendar.DaysBetween(sysTrx.TransactionTime, bankStmtLine.Date); days < -s.WindowDays || days > s.WindowDays {
+		return fmt.Sprintf("bank date is %d days from the transaction date, expected at most %d", days, s.WindowDays)
+	}
+	difference := sysTrx.Amount.Sub(bankStmtLine.GetAbsoluteAmount()).Abs()
+	if maxDifference := sysTrx.Amount.Abs().Mul(s.MaxDifferencePercent).Div(decimal.NewFromInt(100)); difference.GreaterThan(maxDifference) {
+		return fmt.Sprintf("amount difference %s exceeds %s%% of the transaction amount", difference, s.MaxDifferencePercent)
+	}
+	return ""
+}
+
+// ExtractReferences returns the distinct references found in the identifier and description
+func (s *ReferenceMatchStrategy) ExtractReferences(bankStmtLine models.BankStatementLine) []string {
+	var references []string
+	seen := make(map[string]bool)
+	for _, text := range []string{bankStmtLine.UniqueIdentifier, bankStmtLine.Description} {
+		for _, re := range s.patterns {
+			for _, submatches := range re.FindAllStringSubmatch(text, -1) {
+				reference := submatches[0]
+				if len(submatches) > 1 {
+					reference = submatches[1]
+				}
+				if normalized := normalizeReference(reference); normalized != "" && !seen[normalized] {
+					seen[normalized] = true
+					references = append(references, reference)
+				}
+			}
+		}
+	}
+	return references
+}
+
+func (s *ReferenceMatchStrategy) Fallback() MatchStrategy {
+	return s.fallback
+}
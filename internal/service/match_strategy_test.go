@@ -0,0 +1,274 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/models"
+	"github.com/firmannf/recon/internal/service"
+)
+
+func TestReferenceMatchStrategy_ExtractReferences(t *testing.T) {
+	tests := []struct {
+		name          string
+		patterns      []string
+		stmtLine      models.BankStatementLine
+		expectedRefs  []string
+		expectedError bool
+	}{
+		{
+			name:         "default pattern finds IDs in identifier and description",
+			stmtLine:     models.BankStatementLine{UniqueIdentifier: "BCA20240115", Description: "TRF INV-2024-001 from PT Maju"},
+			expectedRefs: []string{"BCA20240115", "INV-2024-001"},
+		},
+		{
+			name:         "capture group is used as reference",
+			patterns:     []string{`ORDER#(\d+)`},
+			stmtLine:     models.BankStatementLine{UniqueIdentifier: "BCA-001", Description: "QRIS ORDER#778899 ORDER#778899"},
+			expectedRefs: []string{"778899"},
+		},
+		{
+			name:         "no reference found",
+			patterns:     []string{`TRX\d+`},
+			stmtLine:     models.BankStatementLine{UniqueIdentifier: "BCA-001", Description: "cash deposit"},
+			expectedRefs: nil,
+		},
+		{
+			name:          "invalid pattern",
+			patterns:      []string{`TRX(\d+`},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy, err := service.NewReferenceMatchStrategy(tt.patterns, nil)
+			if tt.expectedError {
+				if err == nil {
+					t.Error("Expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			refs := strategy.ExtractReferences(tt.stmtLine)
+			if len(refs) != len(tt.expectedRefs) {
+				t.Fatalf("Expected references %v, got %v", tt.expectedRefs, refs)
+			}
+			for i := range refs {
+				if refs[i] != tt.expectedRefs[i] {
+					t.Errorf("Expected references %v, got %v", tt.expectedRefs, refs)
+				}
+			}
+		})
+	}
+}
+
+func TestReconciliation_ReferenceMatching(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX-001,1000.00,CREDIT,2024-01-15 10:30:00
+TRX-002,2000.00,CREDIT,2024-01-15 11:30:00
+TRX-003,500.00,DEBIT,2024-01-16 09:00:00`)
+
+	bankCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bankCSV, `unique_identifier,amount,date,description
+BCA-001,2000.00,2024-01-15,TRANSFER FROM PT MAJU
+BCA-002,999.00,2024-01-16,PAYMENT trx001 INV 9
+BCA-003,-500.00,2024-01-16,`)
+
+	strategy, err := service.NewReferenceMatchStrategy(nil, service.NewExactMatchStrategy())
+	if err != nil {
+		t.Fatalf("Failed to create strategy: %v", err)
+	}
+
+	reconService := service.NewReconciliationService()
+	result, err := reconService.Reconcile(service.ReconciliationInput{
+		SystemTransactionFile: systemCSV,
+		BankStatementFiles:    []string{bankCSV},
+		StartDate:             mustParseTime("2024-01-01 00:00:00"),
+		EndDate:               mustParseTime("2024-12-31 23:59:59"),
+		MatchStrategy:         strategy,
+	})
+	if err != nil {
+		t.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	if result.TotalMatchedTransactions != 3 {
+		t.Fatalf("Expected 3 matched transactions, got %d", result.TotalMatchedTransactions)
+	}
+	if result.TotalUnmatchedTransactions != 0 {
+		t.Errorf("Expected 0 unmatched transactions, got %d", result.TotalUnmatchedTransactions)
+	}
+
+	expected := map[string]struct {
+		bankID             string
+		referenceConfirmed bool
+	}{
+		"TRX-001": {"BCA-002", true},
+		"TRX-002": {"BCA-001", false},
+		"TRX-003": {"BCA-003", false},
+	}
+	for _, match := range result.Matches {
+		exp := expected[match.SystemTransaction.TrxID]
+		if match.BankStatementLine.UniqueIdentifier != exp.bankID {
+			t.Errorf("Expected %s to match %s, got %s", match.SystemTransaction.TrxID, exp.bankID, match.BankStatementLine.UniqueIdentifier)
+		}
+		if match.ReferenceConfirmed != exp.referenceConfirmed {
+			t.Errorf("Expected %s reference confirmed to be %v", match.SystemTransaction.TrxID, exp.referenceConfirmed)
+		}
+	}
+
+	// The reference-confirmed pair differs by 1.00
+	if !result.TotalDiscrepancies.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("Expected discrepancies of 1.00, got %s", result.TotalDiscrepancies)
+	}
+}
+
+func TestReferenceMatchStrategy_IsMatch(t *testing.T) {
+	strategy, err := service.NewReferenceMatchStrategy(nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create strategy: %v", err)
+	}
+	sysTrx := models.Transaction{
+		TrxID:           "INV-2024-001",
+		Amount:          decimal.NewFromInt(1000000),
+		Type:            models.TransactionTypeCredit,
+		TransactionTime: mustParseTime("2024-01-15 10:30:00"),
+	}
+
+	tests := []struct {
+		name     string
+		amount   string
+		date     string
+		expected bool
+	}{
+		{name: "same day and amount", amount: "1000000", date: "2024-01-15", expected: true},
+		{name: "amount within 10 percent", amount: "905000", date: "2024-01-15", expected: true},
+		{name: "booked at the end of the window", amount: "1000000", date: "2024-01-22", expected: true},
+		{name: "booked before the transaction", amount: "1000000", date: "2024-01-10", expected: true},
+		{name: "amount mismatch", amount: "250000", date: "2024-01-15", expected: false},
+		{name: "out of window", amount: "1000000", date: "2024-04-20", expected: false},
+		{name: "out of window before the transaction", amount: "1000000", date: "2024-01-01", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bankStmtLine := models.BankStatementLine{
+				UniqueIdentifier: "BCA-001",
+				Amount:           decimal.RequireFromString(tt.amount),
+				Type:             models.TransactionTypeCredit,
+				Date:             mustParseTime(tt.date + " 00:00:00"),
+				Description:      "PAYMENT INV-2024-001",
+			}
+			if got := strategy.IsMatch(sysTrx, bankStmtLine); got != tt.expected {
+				t.Errorf("Expected IsMatch %v, got %v (%s)", tt.expected, got, strategy.ExplainMismatch(sysTrx, bankStmtLine))
+			}
+		})
+	}
+}
+
+func TestReconciliation_ReferenceMatchingBounds(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	if err := os.WriteFile(systemCSV, []byte(`trxID,amount,type,transactionTime
+INV-001,1000000.00,CREDIT,2024-01-15 10:30:00
+INV-002,500000.00,CREDIT,2024-01-16 10:30:00`), 0644); err != nil {
+		t.Fatalf("Failed to write system file: %v", err)
+	}
+
+	// BCA-001 quotes INV-001 months later, BCA-002 quotes INV-002 with an unrelated amount
+	bankCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	if err := os.WriteFile(bankCSV, []byte(`unique_identifier,amount,date,description
+BCA-001,1000000.00,2024-04-20,REFUND RE INV-001
+BCA-002,75000.00,2024-01-16,ADMIN FEE INV-002
+BCA-003,1000000.00,2024-01-15,TRANSFER FROM PT MAJU`), 0644); err != nil {
+		t.Fatalf("Failed to write bank file: %v", err)
+	}
+
+	strategy, err := service.NewReferenceMatchStrategy(nil, service.NewExactMatchStrategy())
+	if err != nil {
+		t.Fatalf("Failed to create strategy: %v", err)
+	}
+
+	result, err := service.NewReconciliationService().Reconcile(service.ReconciliationInput{
+		SystemTransactionFile: systemCSV,
+		BankStatementFiles:    []string{bankCSV},
+		StartDate:             mustParseTime("2024-01-01 00:00:00"),
+		EndDate:               mustParseTime("2024-12-31 23:59:59"),
+		MatchStrategy:         strategy,
+	})
+	if err != nil {
+		t.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	// INV-001 falls back to the exact match, INV-002 stays unmatched
+	if len(result.Matches) != 1 {
+		t.Fatalf("Expected 1 match, got %d: %v", len(result.Matches), result.Matches)
+	}
+	match := result.Matches[0]
+	if match.SystemTransaction.TrxID != "INV-001" || match.BankStatementLine.UniqueIdentifier != "BCA-003" || match.ReferenceConfirmed {
+		t.Errorf("Expected INV-001 to match BCA-003 without a reference, got %s with %s", match.SystemTransaction.TrxID, match.BankStatementLine.UniqueIdentifier)
+	}
+	assertTrxIDs(t, "unmatched", result.UnmatchedSystemTransactions, []string{"INV-002"})
+	if len(result.UnmatchedBankStatementLines["bank_bca"]) != 2 {
+		t.Errorf("Expected 2 unmatched bank lines, got %v", result.UnmatchedBankStatementLines)
+	}
+}
+
+func TestDateWindowMatchStrategy_IsMatch(t *testing.T) {
+	strategy := service.NewDateWindowMatchStrategy(1)
+	sysTrx := models.Transaction{Type: models.TransactionTypeCredit, TransactionTime: mustParseTime("2024-01-15 23:30:00")}
+
+	tests := []struct {
+		bankDate string
+		expected bool
+	}{
+		{"2024-01-14 00:00:00", false},
+		{"2024-01-15 00:00:00", true},
+		{"2024-01-16 00:00:00", true},
+		{"2024-01-17 00:00:00", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bankDate, func(t *testing.T) {
+			bankStmtLine := models.BankStatementLine{Type: models.TransactionTypeCredit, Date: mustParseTime(tt.bankDate)}
+			if strategy.IsMatch(sysTrx, bankStmtLine) != tt.expected {
+				t.Errorf("Expected IsMatch %v for bank date %s", tt.expected, tt.bankDate)
+			}
+		})
+	}
+}
+
+func TestAmountToleranceMatchStrategy_IsMatch(t *testing.T) {
+	strategy := service.NewAmountToleranceMatchStrategy(decimal.NewFromInt(10))
+	sysTrx := models.Transaction{Amount: decimal.NewFromInt(1000), Type: models.TransactionTypeDebit}
+
+	tests := []struct {
+		bankAmount string
+		expected   bool
+	}{
+		{"-1000", true},
+		{"-990", true},
+		{"-1010", true},
+		{"-989.99", false},
+		{"-1010.01", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bankAmount, func(t *testing.T) {
+			bankStmtLine := models.BankStatementLine{Amount: decimal.RequireFromString(tt.bankAmount), Type: models.TransactionTypeDebit}
+			if strategy.IsMatch(sysTrx, bankStmtLine) != tt.expected {
+				t.Errorf("Expected IsMatch %v for bank amount %s", tt.expected, tt.bankAmount)
+			}
+		})
+	}
+}
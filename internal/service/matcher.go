@@ -0,0 +1,298 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/models"
+)
+
+// AssignmentMode selects how system transactions are paired with candidates sharing the same key
+type AssignmentMode string
+
+const (
+	// AssignmentGreedy pairs each system transaction with the first available candidate in file order
+	AssignmentGreedy AssignmentMode = "greedy"
+	// AssignmentOptimal pairs transactions within each key bucket so the total match score is maximal
+	AssignmentOptimal AssignmentMode = "optimal"
+)
+
+// maxOptimalBucketSize caps the bucket size solved optimally, larger buckets fall back to greedy
+// because the assignment is O(n^3)
+const maxOptimalBucketSize = 200
+
+// matchState holds the matched flags shared by all passes and the index of the current pass
+type matchState struct {
+	systemTrxs           []models.Transaction
+	bankStmtLines        []models.BankStatementLine
+	matchedSystemTrxs    []bool
+	matchedBankStmtLines []bool
+	result               *models.ReconciliationResult
+	progress             ProgressReporter
+	tracer               *matchTracer         // Optional, set when explaining a transaction
+	condition            *MatchCondition      // Optional, every pair of every pass must satisfy it
+	timeProximity        *TimeProximityConfig // Optional, orders candidates by time gap and rejects distant ones
+
+	// Current pass
+	passName           string
+	pass               scheduledPass
+	strategy           MatchStrategy
+	bankStmtLineIndex  map[string][]int
+	referenceConfirmed bool
+	pending            int
+	processed          int
+}
+
+func newMatchState(
+	systemTrxs []models.Transaction,
+	bankStmtLines []models.BankStatementLine,
+	result *models.ReconciliationResult,
+	progress ProgressReporter,
+) *matchState {
+	return &matchState{
+		systemTrxs:           systemTrxs,
+		bankStmtLines:        bankStmtLines,
+		matchedSystemTrxs:    make([]bool, len(systemTrxs)),
+		matchedBankStmtLines: make([]bool, len(bankStmtLines)),
+		result:               result,
+		progress:             progress,
+	}
+}
+
+// startPass indexes the bank statement lines that are still unmatched using the pass strategy
+func (m *matchState) startPass(pass scheduledPass) {
+	m.passName = pass.Name
+	m.pass = pass
+	m.strategy = pass.Strategy
+	m.bankStmtLineIndex = make(map[string][]int)
+	m.processed = 0
+	m.pending = 0
+	for sysIdx := range m.systemTrxs {
+		if !m.matchedSystemTrxs[sysIdx] {
+			m.pending++
+		}
+	}
+	_, isExtractor := pass.Strategy.(ReferenceExtractor)
+	m.referenceConfirmed = isExtractor
+
+	for bankIdx, bankStmtLine := range m.bankStmtLines {
+		if m.matchedBankStmtLines[bankIdx] || !pass.inScope(bankStmtLine.BankName) {
+			continue
+		}
+
+		if isExtractor {
+			for _, key := range m.bankKeys(bankIdx) {
+				m.bankStmtLineIndex[key] = append(m.bankStmtLineIndex[key], bankIdx)
+			}
+			continue
+		}
+
+		key := m.strategy.BuildKey(bankStmtLine.Type, bankStmtLine.GetAbsoluteAmount(), bankStmtLine.Date, bankStmtLine.UniqueIdentifier)
+		m.bankStmtLineIndex[key] = append(m.bankStmtLineIndex[key], bankIdx)
+	}
+}
+
+// bankKeys builds the index keys of a bank statement line for the current pass
+func (m *matchState) bankKeys(bankIdx int) []string {
+	bankStmtLine := m.bankStmtLines[bankIdx]
+
+	// Reference strategies index each line under every reference found in it
+	if extractor, isExtractor := m.strategy.(ReferenceExtractor); isExtractor {
+		var keys []string
+		for _, reference := range extractor.ExtractReferences(bankStmtLine) {
+			keys = append(keys, m.strategy.BuildKey(bankStmtLine.Type, bankStmtLine.GetAbsoluteAmount(), bankStmtLine.Date, reference))
+		}
+		return keys
+	}
+
+	return []string{m.strategy.BuildKey(bankStmtLine.Type, bankStmtLine.GetAbsoluteAmount(), bankStmtLine.Date, bankStmtLine.UniqueIdentifier)}
+}
+
+// isMatch validates a candidate pair with the pass strategy, the maximum time gap and the match condition
+func (m *matchState) isMatch(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) bool {
+	if !m.strategy.IsMatch(sysTrx, bankStmtLine) {
+		return false
+	}
+	if m.timeProximity != nil && m.timeProximity.exceedsMaxGap(sysTrx, bankStmtLine) {
+		return false
+	}
+	return m.condition == nil || m.condition.Matches(sysTrx, bankStmtLine)
+}
+
+// systemKey builds the index key of a system transaction
+func (m *matchState) systemKey(sysTrx models.Transaction) string {
+	return m.strategy.BuildKey(sysTrx.Type, sysTrx.Amount, sysTrx.TransactionTime, sysTrx.TrxID)
+}
+
+// reportProgress counts processed system transactions and reports periodically
+func (m *matchState) reportProgress(n int) {
+	for i := 0; i < n; i++ {
+		if m.processed%matchProgressInterval == 0 {
+			m.progress.MatchProgress(m.processed, m.pending)
+		}
+		m.processed++
+	}
+}
+
+// assignGreedy matches each system transaction with the first available candidate in file order,
+// or the best ranked one when the strategy scores candidates or time proximity is enabled
+func (m *matchState) assignGreedy() {
+	for sysIdx, sysTrx := range m.systemTrxs {
+		if m.matchedSystemTrxs[sysIdx] {
+			continue
+		}
+		m.reportProgress(1)
+
+		// Look up potential matches using index - O(1) instead of O(m)
+		candidates := m.bankStmtLineIndex[m.systemKey(sysTrx)]
+		if bankIdx := m.pickCandidate(sysIdx, candidates); bankIdx >= 0 {
+			m.recordMatch(sysIdx, bankIdx)
+		}
+	}
+}
+
+// pickCandidate returns the first available candidate that passes validation, or -1.
+// Candidates are ranked by the strategy score when it implements CandidateScorer and by
+// time gap when time proximity is enabled, ties keep file order.
+func (m *matchState) pickCandidate(sysIdx int, candidates []int) int {
+	sysTrx := m.systemTrxs[sysIdx]
+	scorer, isScorer := m.strategy.(CandidateScorer)
+	byTime := m.timeProximity != nil
+
+	best, bestRank := -1, candidateRank{}
+	for _, bankIdx := range candidates {
+		// Skip already matched bank statements
+		if m.matchedBankStmtLines[bankIdx] {
+			continue
+		}
+
+		// Validate match using strategy (for tolerance checking, etc.)
+		bankStmtLine := m.bankStmtLines[bankIdx]
+		if !m.isMatch(sysTrx, bankStmtLine) {
+			continue
+		}
+
+		if !isScorer && !byTime {
+			return bankIdx
+		}
+		var rank candidateRank
+		if isScorer {
+			rank.score = scorer.Score(sysTrx, bankStmtLine)
+		}
+		rank.gap, rank.hasTime = timeGap(sysTrx, bankStmtLine)
+		if best < 0 || rank.better(bestRank, byTime) {
+			best, bestRank = bankIdx, rank
+		}
+	}
+	return best
+}
+
+// assignOptimal groups system transactions by key and solves each bucket as a
+// maximum-score one-to-one assignment against the bank candidates of that key
+func (m *matchState) assignOptimal(scorer MatchScorer) {
+	var keys []string
+	buckets := make(map[string][]int)
+	for sysIdx, sysTrx := range m.systemTrxs {
+		if m.matchedSystemTrxs[sysIdx] {
+			continue
+		}
+		key := m.systemKey(sysTrx)
+		if _, exists := buckets[key]; !exists {
+			keys = append(keys, key)
+		}
+		buckets[key] = append(buckets[key], sysIdx)
+	}
+
+	for _, key := range keys {
+		sysIdxs := buckets[key]
+		var candidates []int
+		for _, bankIdx := range m.bankStmtLineIndex[key] {
+			if !m.matchedBankStmtLines[bankIdx] {
+				candidates = append(candidates, bankIdx)
+			}
+		}
+
+		if len(candidates) > 0 {
+			if max(len(sysIdxs), len(candidates)) > maxOptimalBucketSize {
+				m.assignBucketGreedy(sysIdxs, candidates)
+			} else {
+				m.assignBucketOptimal(sysIdxs, candidates, scorer)
+			}
+		}
+		m.reportProgress(len(sysIdxs))
+	}
+}
+
+// assignBucketGreedy pairs a bucket in file order, used when the bucket is too large to solve optimally
+func (m *matchState) assignBucketGreedy(sysIdxs, candidates []int) {
+	for _, sysIdx := range sysIdxs {
+		if bankIdx := m.pickCandidate(sysIdx, candidates); bankIdx >= 0 {
+			m.recordMatch(sysIdx, bankIdx)
+		}
+	}
+}
+
+// assignBucketOptimal pairs a bucket so that the number of matches is maximal and,
+// among those, the total score is maximal. With time proximity the score is averaged
+// with the time closeness of the pair, so that the time gaps of the bucket count as a whole.
+func (m *matchState) assignBucketOptimal(sysIdxs, candidates []int, scorer MatchScorer) {
+	allowed := make([][]bool, len(sysIdxs))
+	scores := make([][]float64, len(sysIdxs))
+	for i, sysIdx := range sysIdxs {
+		allowed[i] = make([]bool, len(candidates))
+		scores[i] = make([]float64, len(candidates))
+		for j, bankIdx := range candidates {
+			sysTrx, bankStmtLine := m.systemTrxs[sysIdx], m.bankStmtLines[bankIdx]
+			if !m.isMatch(sysTrx, bankStmtLine) {
+				continue
+			}
+			allowed[i][j] = true
+			scores[i][j] = scorer.Score(sysTrx, bankStmtLine)
+			if m.timeProximity != nil {
+				scores[i][j] = (scores[i][j] + timeCloseness(sysTrx, bankStmtLine)) / 2
+			}
+		}
+	}
+
+	for i, j := range maxScoreAssignment(allowed, scores) {
+		if j >= 0 {
+			m.recordMatch(sysIdxs[i], candidates[j])
+		}
+	}
+}
+
+// recordMatch marks the pair as matched and adds it to the result
+func (m *matchState) recordMatch(sysIdx, bankIdx int) {
+	sysTrx := m.systemTrxs[sysIdx]
+	bankStmtLine := m.bankStmtLines[bankIdx]
+
+	m.matchedSystemTrxs[sysIdx] = true
+	m.matchedBankStmtLines[bankIdx] = true
+	m.result.TotalMatchedTransactions++
+
+	// Fees charged by the bank explain part of the amount difference and are reported separately
+	fee := decimal.Zero
+	if calculator, ok := m.strategy.(FeeCalculator); ok {
+		fee = calculator.Fee(sysTrx, bankStmtLine)
+		m.result.TotalFees = m.result.TotalFees.Add(fee)
+	}
+
+	// Check for amount discrepancies
+	// This is always zero for the exact strategy, tolerance-based strategies may produce a difference
+	diff := netAmount(sysTrx, fee).Sub(bankStmtLine.GetAbsoluteAmount()).Abs()
+	if !diff.IsZero() {
+		m.result.TotalDiscrepancies = m.result.TotalDiscrepancies.Add(diff)
+	}
+
+	m.traceConsumed([]int{sysIdx}, []int{bankIdx}, fmt.Sprintf("paired %s with %s (%s) by pass %s", sysTrx.TrxID, bankStmtLine.UniqueIdentifier, bankStmtLine.BankName, m.passName))
+
+	m.result.Matches = append(m.result.Matches, models.MatchedTransaction{
+		SystemTransaction:  sysTrx,
+		BankStatementLine:  bankStmtLine,
+		Discrepancy:        diff,
+		Fee:                fee,
+		ReferenceConfirmed: m.referenceConfirmed,
+		Pass:               m.passName,
+	})
+}
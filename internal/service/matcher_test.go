@@ -0,0 +1,126 @@
+package service_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/models"
+	"github.com/firmannf/recon/internal/service"
+)
+
+// nextDayMatchStrategy matches same type and amount when the bank line is booked on the same or next day
+type nextDayMatchStrategy struct{}
+
+func (s *nextDayMatchStrategy) BuildKey(trxType models.TransactionType, amount decimal.Decimal, date time.Time, id string) string {
+	return string(trxType) + "_" + amount.String()
+}
+
+func (s *nextDayMatchStrategy) IsMatch(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) bool {
+	sysDate := sysTrx.TransactionTime.Format("2006-01-02")
+	return bankStmtLine.Date.Format("2006-01-02") == sysDate ||
+		bankStmtLine.Date.AddDate(0, 0, -1).Format("2006-01-02") == sysDate
+}
+
+func TestReconciliation_AssignmentModes(t *testing.T) {
+	tests := []struct {
+		name              string
+		systemCSV         string
+		bankCSV           string
+		strategy          service.MatchStrategy
+		assignmentMode    service.AssignmentMode
+		expectedPairs     map[string]string // TrxID -> UniqueIdentifier
+		expectedUnmatched int
+	}{
+		{
+			name: "greedy links first candidate in file order",
+			systemCSV: `trxID,amount,type,transactionTime
+INV-100,1000.00,CREDIT,2024-01-15 10:30:00
+INV-200,1000.00,CREDIT,2024-01-15 11:30:00`,
+			bankCSV: `unique_identifier,amount,date
+TRF INV-200,1000.00,2024-01-15
+TRF INV-100,1000.00,2024-01-15`,
+			strategy:       service.NewExactMatchStrategy(),
+			assignmentMode: service.AssignmentGreedy,
+			expectedPairs:  map[string]string{"INV-100": "TRF INV-200", "INV-200": "TRF INV-100"},
+		},
+		{
+			name: "optimal links candidates by reference similarity",
+			systemCSV: `trxID,amount,type,transactionTime
+INV-100,1000.00,CREDIT,2024-01-15 10:30:00
+INV-200,1000.00,CREDIT,2024-01-15 11:30:00`,
+			bankCSV: `unique_identifier,amount,date
+TRF INV-200,1000.00,2024-01-15
+TRF INV-100,1000.00,2024-01-15`,
+			strategy:       service.NewExactMatchStrategy(),
+			assignmentMode: service.AssignmentOptimal,
+			expectedPairs:  map[string]string{"INV-100": "TRF INV-100", "INV-200": "TRF INV-200"},
+		},
+		{
+			name: "greedy strands a transaction under a date window",
+			systemCSV: `trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-15 10:30:00
+TRX002,1000.00,CREDIT,2024-01-16 10:30:00`,
+			bankCSV: `unique_identifier,amount,date
+BANK-001,1000.00,2024-01-16
+BANK-002,1000.00,2024-01-15`,
+			strategy:          &nextDayMatchStrategy{},
+			assignmentMode:    service.AssignmentGreedy,
+			expectedPairs:     map[string]string{"TRX001": "BANK-001"},
+			expectedUnmatched: 2,
+		},
+		{
+			name: "optimal matches every transaction under a date window",
+			systemCSV: `trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-15 10:30:00
+TRX002,1000.00,CREDIT,2024-01-16 10:30:00`,
+			bankCSV: `unique_identifier,amount,date
+BANK-001,1000.00,2024-01-16
+BANK-002,1000.00,2024-01-15`,
+			strategy:       &nextDayMatchStrategy{},
+			assignmentMode: service.AssignmentOptimal,
+			expectedPairs:  map[string]string{"TRX001": "BANK-002", "TRX002": "BANK-001"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			systemFile := filepath.Join(tmpDir, "transactions.csv")
+			writeTestFile(t, systemFile, tt.systemCSV)
+			bankFile := filepath.Join(tmpDir, "bank.csv")
+			writeTestFile(t, bankFile, tt.bankCSV)
+
+			reconService := service.NewReconciliationService()
+			result, err := reconService.Reconcile(service.ReconciliationInput{
+				SystemTransactionFile: systemFile,
+				BankStatementFiles:    []string{bankFile},
+				StartDate:             mustParseTime("2024-01-01 00:00:00"),
+				EndDate:               mustParseTime("2024-12-31 23:59:59"),
+				MatchStrategy:         tt.strategy,
+				AssignmentMode:        tt.assignmentMode,
+			})
+			if err != nil {
+				t.Fatalf("Reconciliation failed: %v", err)
+			}
+
+			if len(result.Matches) != len(tt.expectedPairs) {
+				t.Fatalf("Expected %d matches, got %d", len(tt.expectedPairs), len(result.Matches))
+			}
+			for _, match := range result.Matches {
+				expected := tt.expectedPairs[match.SystemTransaction.TrxID]
+				if match.BankStatementLine.UniqueIdentifier != expected {
+					t.Errorf("Expected %s to match %s, got %s", match.SystemTransaction.TrxID, expected, match.BankStatementLine.UniqueIdentifier)
+				}
+			}
+			if result.TotalMatchedTransactions != len(tt.expectedPairs) {
+				t.Errorf("Expected %d matched transactions, got %d", len(tt.expectedPairs), result.TotalMatchedTransactions)
+			}
+			if result.TotalUnmatchedTransactions != tt.expectedUnmatched {
+				t.Errorf("Expected %d unmatched transactions, got %d", tt.expectedUnmatched, result.TotalUnmatchedTransactions)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/calendar"
+	"github.com/firmannf/recon/internal/models"
+)
+
+// NearMissConfig configures the counterpart suggestions listed for unmatched items
+type NearMissConfig struct {
+	Limit      int             // Maximum number of suggestions per unmatched item
+	WindowDays int             // Maximum date difference for same amount suggestions
+	Tolerance  decimal.Decimal // Maximum amount difference for same date suggestions
+}
+
+// validate checks that the configuration can be used for suggestions
+func (c NearMissConfig) validate() error {
+	if c.Limit < 1 {
+		return fmt.Errorf("near miss limit must be at least 1 (got %d)", c.Limit)
+	}
+	if c.WindowDays < 0 {
+		return fmt.Errorf("near miss window must not be negative (got %d)", c.WindowDays)
+	}
+	if c.Tolerance.IsNegative() {
+		return fmt.Errorf("near miss tolerance must not be negative (got %s)", c.Tolerance)
+	}
+	return nil
+}
+
+// nearMissReason returns why the pair is a near miss, ranked by how close the pair is
+// (lower is closer), or false when the pair differs in more than one way
+func (c NearMissConfig) nearMissReason(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) (models.NearMissReason, float64, bool) {
+	days := calendar.DaysBetween(sysTrx.TransactionTime, bankStmtLine.Date)
+	amountDiff := sysTrx.Amount.Sub(bankStmtLine.GetAbsoluteAmount()).Abs()
+	sameType := sysTrx.Type == bankStmtLine.Type
+
+	switch {
+	case !sameType && days == 0 && amountDiff.IsZero():
+		return models.NearMissSwappedType, 0, true
+	case sameType && days != 0 && abs(days) <= c.WindowDays && amountDiff.IsZero():
+		return models.NearMissDateDifference, float64(abs(days)), true
+	case sameType && days == 0 && !amountDiff.IsZero() && amountDiff.LessThanOrEqual(c.Tolerance):
+		// Ranked after any date difference within the window
+		return models.NearMissAmountDifference, float64(c.WindowDays+1) + amountDiff.InexactFloat64(), true
+	default:
+		return "", 0, false
+	}
+}
+
+// suggestNearMisses lists up to Limit near misses for every unmatched item from the
+// unmatched items of the other side, closest first
+func suggestNearMisses(result *models.ReconciliationResult, cfg NearMissConfig) {
+	var unmatchedBank []models.BankStatementLine
+	var bankNames []string
+	for bankName := range result.UnmatchedBankStatementLines {
+		bankNames = append(bankNames, bankName)
+	}
+	sort.Strings(bankNames)
+	for _, bankName := range bankNames {
+		unmatchedBank = append(unmatchedBank, result.UnmatchedBankStatementLines[bankName]...)
+	}
+
+	// Candidates are looked up by amount and by day, which covers every kind of near miss
+	bankByAmount := make(map[string][]int)
+	bankByDay := make(map[string][]int)
+	for bankIdx, bankStmtLine := range unmatchedBank {
+		bankByAmount[bankStmtLine.GetAbsoluteAmount().String()] = append(bankByAmount[bankStmtLine.GetAbsoluteAmount().String()], bankIdx)
+		bankByDay[bankStmtLine.Date.Format("2006-01-02")] = append(bankByDay[bankStmtLine.Date.Format("2006-01-02")], bankIdx)
+	}
+	sysByAmount := make(map[string][]int)
+	sysByDay := make(map[string][]int)
+	for sysIdx, sysTrx := range result.UnmatchedSystemTransactions {
+		sysByAmount[sysTrx.Amount.String()] = append(sysByAmount[sysTrx.Amount.String()], sysIdx)
+		sysByDay[sysTrx.TransactionTime.Format("2006-01-02")] = append(sysByDay[sysTrx.TransactionTime.Format("2006-01-02")], sysIdx)
+	}
+
+	result.SystemNearMisses = make([][]models.NearMiss, len(result.UnmatchedSystemTransactions))
+	for sysIdx, sysTrx := range result.UnmatchedSystemTransactions {
+		candidates := mergeCandidates(bankByAmount[sysTrx.Amount.String()], bankByDay[sysTrx.TransactionTime.Format("2006-01-02")])
+		result.SystemNearMisses[sysIdx] = rankNearMisses(cfg, len(candidates), func(i int) (models.Transaction, models.BankStatementLine) {
+			return sysTrx, unmatchedBank[candidates[i]]
+		})
+	}
+
+	result.BankNearMisses = make(map[string][][]models.NearMiss)
+	for _, bankName := range bankNames {
+		stmtLines := result.UnmatchedBankStatementLines[bankName]
+		result.BankNearMisses[bankName] = make([][]models.NearMiss, len(stmtLines))
+		for bankIdx, bankStmtLine := range stmtLines {
+			candidates := mergeCandidates(sysByAmount[bankStmtLine.GetAbsoluteAmount().String()], sysByDay[bankStmtLine.Date.Format("2006-01-02")])
+			result.BankNearMisses[bankName][bankIdx] = rankNearMisses(cfg, len(candidates), func(i int) (models.Transaction, models.BankStatementLine) {
+				return result.UnmatchedSystemTransactions[candidates[i]], bankStmtLine
+			})
+		}
+	}
+}
+
+// rankNearMisses keeps the closest near misses among n candidate pairs, ties keep file order
+func rankNearMisses(cfg NearMissConfig, n int, pair func(i int) (models.Transaction, models.BankStatementLine)) []models.NearMiss {
+	type rankedNearMiss struct {
+		nearMiss models.NearMiss
+		rank     float64
+	}
+
+	var ranked []rankedNearMiss
+	for i := 0; i < n; i++ {
+		sysTrx, bankStmtLine := pair(i)
+		reason, rank, ok := cfg.nearMissReason(sysTrx, bankStmtLine)
+		if !ok {
+			continue
+		}
+		ranked = append(ranked, rankedNearMiss{
+			nearMiss: models.NearMiss{SystemTransaction: sysTrx, BankStatementLine: bankStmtLine, Reason: reason},
+			rank:     rank,
+		})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].rank < ranked[j].rank
+	})
+
+	var nearMisses []models.NearMiss
+	for i := 0; i < len(ranked) && i < cfg.Limit; i++ {
+		nearMisses = append(nearMisses, ranked[i].nearMiss)
+	}
+	return nearMisses
+}
+
+// mergeCandidates returns the union of two ascending index lists in ascending order
+func mergeCandidates(a, b []int) []int {
+	merged := make([]int, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || (i < len(a) && a[i] < b[j]):
+			merged = append(merged, a[i])
+			i++
+		case i == len(a) || b[j] < a[i]:
+			merged = append(merged, b[j])
+			j++
+		default:
+			merged = append(merged, a[i])
+			i++
+			j++
+		}
+	}
+	return merged
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
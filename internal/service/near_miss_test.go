@@ -0,0 +1,102 @@
+package service_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/models"
+	"github.com/firmannf/recon/internal/service"
+)
+
+func TestReconciliation_NearMisses(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-15 10:00:00
+TRX002,2000.00,CREDIT,2024-01-15 11:00:00
+TRX003,3000.00,DEBIT,2024-01-15 12:00:00`)
+
+	bankCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bankCSV, `unique_identifier,amount,date
+BCA-001,1000.00,2024-01-17
+BCA-002,1000.00,2024-01-16
+BCA-003,2005.00,2024-01-15
+BCA-004,3000.00,2024-01-15
+BCA-005,999999.00,2024-01-20`)
+
+	tests := []struct {
+		name                  string
+		limit                 int
+		expectedSystem        [][]string
+		expectedSystemReasons []models.NearMissReason
+		expectedBank          [][]string
+	}{
+		{
+			name:                  "ranked suggestions",
+			limit:                 2,
+			expectedSystem:        [][]string{{"BCA-002", "BCA-001"}, {"BCA-003"}, {"BCA-004"}},
+			expectedSystemReasons: []models.NearMissReason{models.NearMissDateDifference, models.NearMissAmountDifference, models.NearMissSwappedType},
+			expectedBank:          [][]string{{"TRX001"}, {"TRX001"}, {"TRX002"}, {"TRX003"}, nil},
+		},
+		{
+			name:                  "limited suggestions",
+			limit:                 1,
+			expectedSystem:        [][]string{{"BCA-002"}, {"BCA-003"}, {"BCA-004"}},
+			expectedSystemReasons: []models.NearMissReason{models.NearMissDateDifference, models.NearMissAmountDifference, models.NearMissSwappedType},
+			expectedBank:          [][]string{{"TRX001"}, {"TRX001"}, {"TRX002"}, {"TRX003"}, nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconService := service.NewReconciliationService()
+			result, err := reconService.Reconcile(service.ReconciliationInput{
+				SystemTransactionFile: systemCSV,
+				BankStatementFiles:    []string{bankCSV},
+				StartDate:             mustParseTime("2024-01-01 00:00:00"),
+				EndDate:               mustParseTime("2024-12-31 23:59:59"),
+				MatchStrategy:         service.NewExactMatchStrategy(),
+				NearMisses:            &service.NearMissConfig{Limit: tt.limit, WindowDays: 3, Tolerance: decimal.NewFromInt(10)},
+			})
+			if err != nil {
+				t.Fatalf("Reconciliation failed: %v", err)
+			}
+
+			if len(result.SystemNearMisses) != len(tt.expectedSystem) {
+				t.Fatalf("Expected near misses for %d system transactions, got %d", len(tt.expectedSystem), len(result.SystemNearMisses))
+			}
+			for i, expected := range tt.expectedSystem {
+				nearMisses := result.SystemNearMisses[i]
+				if len(nearMisses) != len(expected) {
+					t.Fatalf("Expected %d near misses for %s, got %d", len(expected), result.UnmatchedSystemTransactions[i].TrxID, len(nearMisses))
+				}
+				for j, identifier := range expected {
+					if nearMisses[j].BankStatementLine.UniqueIdentifier != identifier {
+						t.Errorf("Expected near miss %d of %s to be %s, got %s", j+1, result.UnmatchedSystemTransactions[i].TrxID, identifier, nearMisses[j].BankStatementLine.UniqueIdentifier)
+					}
+				}
+				if nearMisses[0].Reason != tt.expectedSystemReasons[i] {
+					t.Errorf("Expected reason %q for %s, got %q", tt.expectedSystemReasons[i], result.UnmatchedSystemTransactions[i].TrxID, nearMisses[0].Reason)
+				}
+			}
+
+			bankNearMisses := result.BankNearMisses["bank_bca"]
+			if len(bankNearMisses) != len(tt.expectedBank) {
+				t.Fatalf("Expected near misses for %d bank statement lines, got %d", len(tt.expectedBank), len(bankNearMisses))
+			}
+			for i, expected := range tt.expectedBank {
+				if len(bankNearMisses[i]) != len(expected) {
+					t.Fatalf("Expected %d near misses for bank line %d, got %d", len(expected), i+1, len(bankNearMisses[i]))
+				}
+				for j, trxID := range expected {
+					if bankNearMisses[i][j].SystemTransaction.TrxID != trxID {
+						t.Errorf("Expected near miss %d of bank line %d to be %s, got %s", j+1, i+1, trxID, bankNearMisses[i][j].SystemTransaction.TrxID)
+					}
+				}
+			}
+		})
+	}
+}
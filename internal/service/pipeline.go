@@ -0,0 +1,130 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MatchPass is one step of a matching pipeline
+type MatchPass struct {
+	Name     string
+	Strategy MatchStrategy
+}
+
+// MatchPipeline runs its passes in order, each on the transactions left unmatched by the previous passes
+type MatchPipeline []MatchPass
+
+// validate checks that every pass has a strategy
+func (p MatchPipeline) validate() error {
+	for i, pass := range p {
+		if pass.Strategy == nil {
+			return fmt.Errorf("match pass %d (%s) has no strategy", i+1, pass.Name)
+		}
+	}
+	return nil
+}
+
+// passesFor returns the passes to run: the configured pipeline, or else the
+// match strategy followed by its fallback strategies
+func passesFor(input ReconciliationInput) MatchPipeline {
+	if len(input.MatchPipeline) > 0 {
+		return namedPasses(input.MatchPipeline)
+	}
+
+	var passes MatchPipeline
+	for strategy := input.MatchStrategy; strategy != nil; strategy = fallbackOf(strategy) {
+		passes = append(passes, MatchPass{Name: StrategyName(strategy), Strategy: strategy})
+	}
+	return passes
+}
+
+// namedPasses returns a copy of the pipeline with unnamed passes named after their strategy
+func namedPasses(pipeline MatchPipeline) MatchPipeline {
+	passes := make(MatchPipeline, len(pipeline))
+	for i, pass := range pipeline {
+		if pass.Name == "" {
+			pass.Name = StrategyName(pass.Strategy)
+		}
+		passes[i] = pass
+	}
+	return passes
+}
+
+// scheduledPass is a pass restricted to the bank statement lines of some banks
+type scheduledPass struct {
+	MatchPass
+	bank          string          // Bank of a per-bank pipeline, empty for the default pipeline
+	excludedBanks map[string]bool // Banks with their own pipeline, skipped by the default pipeline
+}
+
+// inScope reports whether the pass matches lines of the bank
+func (p scheduledPass) inScope(bankName string) bool {
+	if p.bank != "" {
+		return bankName == p.bank
+	}
+	return !p.excludedBanks[bankName]
+}
+
+// schedulePasses interleaves the default pipeline with the per-bank pipelines step by step, so
+// that the first passes of all pipelines run before the second ones. Within a step the default
+// pipeline runs first, then the banks in name order.
+func schedulePasses(input ReconciliationInput) []scheduledPass {
+	defaultPasses := passesFor(input)
+	steps := len(defaultPasses)
+
+	banks := make([]string, 0, len(input.BankStrategies))
+	excludedBanks := make(map[string]bool, len(input.BankStrategies))
+	for bank, pipeline := range input.BankStrategies {
+		banks = append(banks, bank)
+		excludedBanks[bank] = true
+		steps = max(steps, len(pipeline))
+	}
+	sort.Strings(banks)
+
+	var scheduled []scheduledPass
+	for step := 0; step < steps; step++ {
+		if step < len(defaultPasses) {
+			scheduled = append(scheduled, scheduledPass{MatchPass: defaultPasses[step], excludedBanks: excludedBanks})
+		}
+		for _, bank := range banks {
+			if pipeline := input.BankStrategies[bank]; step < len(pipeline) {
+				scheduled = append(scheduled, scheduledPass{MatchPass: namedPasses(pipeline[step : step+1])[0], bank: bank})
+			}
+		}
+	}
+	return scheduled
+}
+
+// fallbackOf returns the strategy that handles transactions left unmatched by strategy, if any
+func fallbackOf(strategy MatchStrategy) MatchStrategy {
+	if provider, ok := strategy.(FallbackProvider); ok {
+		return provider.Fallback()
+	}
+	return nil
+}
+
+// StrategyName returns a short description of the strategy used in reports.
+// Custom strategies can provide their own by implementing Name() string.
+func StrategyName(strategy MatchStrategy) string {
+	switch s := strategy.(type) {
+	case interface{ Name() string }:
+		return s.Name()
+	case *ExactMatchStrategy:
+		return "exact"
+	case *ReferenceMatchStrategy:
+		return "reference"
+	case *DateWindowMatchStrategy:
+		return fmt.Sprintf("window:%d", s.Days)
+	case *AmountToleranceMatchStrategy:
+		return fmt.Sprintf("tolerance:%s", s.Tolerance)
+	case *FeeMatchStrategy:
+		return "fee"
+	case *CounterpartyMatchStrategy:
+		if s.MinSimilarity > 0 {
+			return fmt.Sprintf("counterparty:%d:%g", s.Days, s.MinSimilarity)
+		}
+		return fmt.Sprintf("counterparty:%d", s.Days)
+	default:
+		return fmt.Sprintf("%T", strategy)
+	}
+}
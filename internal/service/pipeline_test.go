@@ -0,0 +1,148 @@
+package service_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/service"
+)
+
+func TestReconciliation_MatchPipeline(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-15 10:30:00
+TRX002,2000.00,CREDIT,2024-01-15 11:30:00
+TRX003,3000.00,CREDIT,2024-01-15 12:30:00
+TRX004,4000.00,DEBIT,2024-01-15 13:30:00
+TRX005,5000.00,DEBIT,2024-01-15 14:30:00`)
+
+	bankCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bankCSV, `unique_identifier,amount,date,description
+BCA-001,1000.00,2024-01-20,LATE TRANSFER TRX001
+BCA-002,2000.00,2024-01-15,
+BCA-003,3000.00,2024-01-16,
+BCA-004,-3999.50,2024-01-15,
+BCA-005,-5000.00,2024-01-18,`)
+
+	reference, err := service.NewReferenceMatchStrategy(nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create strategy: %v", err)
+	}
+
+	reconService := service.NewReconciliationService()
+	result, err := reconService.Reconcile(service.ReconciliationInput{
+		SystemTransactionFile: systemCSV,
+		BankStatementFiles:    []string{bankCSV},
+		StartDate:             mustParseTime("2024-01-01 00:00:00"),
+		EndDate:               mustParseTime("2024-12-31 23:59:59"),
+		MatchPipeline: service.MatchPipeline{
+			{Name: "reference", Strategy: reference},
+			{Strategy: service.NewExactMatchStrategy()},
+			{Strategy: service.NewDateWindowMatchStrategy(1)},
+			{Name: "tolerance", Strategy: service.NewAmountToleranceMatchStrategy(decimal.NewFromInt(1))},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	expectedPasses := map[string]string{
+		"TRX001": "reference",
+		"TRX002": "exact",
+		"TRX003": "window:1",
+		"TRX004": "tolerance",
+	}
+	if len(result.Matches) != len(expectedPasses) {
+		t.Fatalf("Expected %d matches, got %d", len(expectedPasses), len(result.Matches))
+	}
+	for _, match := range result.Matches {
+		if match.Pass != expectedPasses[match.SystemTransaction.TrxID] {
+			t.Errorf("Expected %s matched by %s, got %s", match.SystemTransaction.TrxID, expectedPasses[match.SystemTransaction.TrxID], match.Pass)
+		}
+	}
+
+	expectedSummaries := []struct {
+		name    string
+		matched int
+	}{{"reference", 1}, {"exact", 1}, {"window:1", 1}, {"tolerance", 1}}
+	if len(result.PassSummaries) != len(expectedSummaries) {
+		t.Fatalf("Expected %d pass summaries, got %d", len(expectedSummaries), len(result.PassSummaries))
+	}
+	for i, expected := range expectedSummaries {
+		if result.PassSummaries[i].Name != expected.name || result.PassSummaries[i].Matched != expected.matched {
+			t.Errorf("Expected pass %d to be %s with %d matches, got %+v", i+1, expected.name, expected.matched, result.PassSummaries[i])
+		}
+	}
+
+	// TRX005 is booked 3 days later, outside every pass
+	if len(result.UnmatchedSystemTransactions) != 1 || result.UnmatchedSystemTransactions[0].TrxID != "TRX005" {
+		t.Errorf("Expected only TRX005 unmatched, got %v", result.UnmatchedSystemTransactions)
+	}
+	if !result.TotalDiscrepancies.Equal(decimal.NewFromFloat(0.5)) {
+		t.Errorf("Expected discrepancies of 0.50, got %s", result.TotalDiscrepancies)
+	}
+}
+
+func TestReconciliation_MatchConfigurationValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		input service.ReconciliationInput
+	}{
+		{
+			name:  "no strategy or pipeline",
+			input: service.ReconciliationInput{},
+		},
+		{
+			name: "bank pipeline without a default",
+			input: service.ReconciliationInput{
+				BankStrategies: map[string]service.MatchPipeline{"bank_bca": {{Strategy: service.NewExactMatchStrategy()}}},
+			},
+		},
+		{
+			name: "pipeline pass without strategy",
+			input: service.ReconciliationInput{
+				MatchPipeline: service.MatchPipeline{{Name: "broken"}},
+			},
+		},
+		{
+			name: "unknown duplicate policy",
+			input: service.ReconciliationInput{
+				MatchStrategy:      service.NewExactMatchStrategy(),
+				DuplicateDetection: &service.DuplicateConfig{Policy: "skip"},
+			},
+		},
+		{
+			name: "end date before start date",
+			input: service.ReconciliationInput{
+				EndDate:       mustParseTime("2023-12-31 23:59:59"),
+				MatchStrategy: service.NewExactMatchStrategy(),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.input.StartDate = mustParseTime("2024-01-01 00:00:00")
+			if err := tt.input.Validate(); err == nil {
+				t.Error("Expected Validate to fail but got nil")
+			}
+			_, err := service.NewReconciliationService().Reconcile(tt.input)
+			if err == nil {
+				t.Error("Expected error but got nil")
+			}
+		})
+	}
+
+	valid := service.ReconciliationInput{
+		StartDate:          mustParseTime("2024-01-01 00:00:00"),
+		MatchStrategy:      service.NewExactMatchStrategy(),
+		DuplicateDetection: &service.DuplicateConfig{Policy: service.DuplicateDedupe},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected a valid input, got %v", err)
+	}
+}
@@ -0,0 +1,416 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/firmannf/recon/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// PluginProtocolVersion is sent in the hello request, plugins should reject versions they do not know
+	PluginProtocolVersion = 1
+
+	defaultPluginTimeout     = 5 * time.Second
+	defaultPluginMaxRestarts = 3
+
+	// pluginStderrLimit is the number of trailing stderr bytes kept to report why a plugin failed
+	pluginStderrLimit = 2048
+	// pluginMaxLineSize is the maximum size of a response line
+	pluginMaxLineSize = 1024 * 1024
+)
+
+// FailureReporter is implemented by strategies that can fail while matching. A non-nil Err after
+// the passes fails the reconciliation, as the result would silently miss matches.
+type FailureReporter interface {
+	Err() error
+}
+
+// PluginConfig starts an external matcher
+type PluginConfig struct {
+	Command     string
+	Args        []string
+	Timeout     time.Duration // Optional, maximum time to answer a request, defaults to 5s
+	MaxRestarts *int          // Optional, restarts after a crash or timeout before the plugin is disabled, defaults to 3, 0 never restarts
+}
+
+func (c PluginConfig) timeout() time.Duration {
+	if c.Timeout <= 0 {
+		return defaultPluginTimeout
+	}
+	return c.Timeout
+}
+
+func (c PluginConfig) maxRestarts() int {
+	if c.MaxRestarts == nil {
+		return defaultPluginMaxRestarts
+	}
+	return *c.MaxRestarts
+}
+
+// PluginMatchStrategy delegates IsMatch to an external executable speaking line-delimited JSON on
+// stdin/stdout, one request and one response per line:
+//
+//	-> {"id":1,"method":"hello","protocol":1}
+//	<- {"id":1,"name":"my-matcher","key_fields":["type","amount"]}
+//	-> {"id":2,"method":"match","system":{...},"bank":{...}}
+//	<- {"id":2,"match":false,"reason":"different customer"}
+//
+// The hello response names the plugin and picks the key fields (type, amount and date) candidates
+// must share. A crash, timeout or malformed response rejects the pair at hand and restarts the
+// plugin on the next request; once MaxRestarts is exceeded the plugin is disabled and Err is set.
+type PluginMatchStrategy struct {
+	config PluginConfig
+	name   string
+	keys   *RuleMatchStrategy
+
+	mu       sync.Mutex
+	process  *pluginProcess
+	nextID   int
+	restarts int
+	err      error
+}
+
+type pluginProcess struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	responses chan pluginResponse
+	readErr   error // Set before responses is closed
+	stderr    *tailBuffer
+	done      chan struct{} // Closed when the process is stopped, so that a late response does not block the reader
+}
+
+type pluginRequest struct {
+	ID       int                `json:"id"`
+	Method   string             `json:"method"`
+	Protocol int                `json:"protocol,omitempty"`
+	System   *pluginTransaction `json:"system,omitempty"`
+	Bank     *pluginBankLine    `json:"bank,omitempty"`
+}
+
+type pluginResponse struct {
+	ID        int      `json:"id"`
+	Error     string   `json:"error"`
+	Name      string   `json:"name"`
+	KeyFields []string `json:"key_fields"`
+	Match     bool     `json:"match"`
+	Reason    string   `json:"reason"`
+}
+
+type pluginTransaction struct {
+	TrxID           string `json:"trx_id"`
+	Amount          string `json:"amount"`
+	Type            string `json:"type"`
+	TransactionTime string `json:"transaction_time"`
+	Channel         string `json:"channel,omitempty"`
+	Counterparty    string `json:"counterparty,omitempty"`
+}
+
+type pluginBankLine struct {
+	UniqueIdentifier string `json:"unique_identifier"`
+	Bank             string `json:"bank"`
+	Amount           string `json:"amount"`
+	Type             string `json:"type"`
+	Date             string `json:"date"`
+	Description      string `json:"description,omitempty"`
+	Counterparty     string `json:"counterparty,omitempty"`
+}
+
+// NewPluginMatchStrategy starts the plugin and performs the hello handshake. Close stops it.
+func NewPluginMatchStrategy(config PluginConfig) (*PluginMatchStrategy, error) {
+	if config.Command == "" {
+		return nil, fmt.Errorf("plugin command is required")
+	}
+
+	s := &PluginMatchStrategy{config: config}
+	hello, err := s.start()
+	if err != nil {
+		return nil, err
+	}
+
+	s.name = hello.Name
+	if s.name == "" {
+		s.name = "plugin:" + filepath.Base(config.Command)
+	}
+	for _, field := range hello.KeyFields {
+		if field != RuleKeyType && field != RuleKeyAmount && field != RuleKeyDate {
+			s.Close()
+			return nil, fmt.Errorf("plugin %s: unsupported key field %q, expected %s, %s or %s", s.name, field, RuleKeyType, RuleKeyAmount, RuleKeyDate)
+		}
+	}
+	keyFields := hello.KeyFields
+	if len(keyFields) == 0 {
+		keyFields = []string{RuleKeyType}
+	}
+	keys, err := NewRuleMatchStrategy(MatchRule{Name: s.name, KeyFields: keyFields})
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("plugin %s: %w", s.name, err)
+	}
+	s.keys = keys.(*RuleMatchStrategy)
+	return s, nil
+}
+
+func (s *PluginMatchStrategy) Name() string {
+	return s.name
+}
+
+func (s *PluginMatchStrategy) BuildKey(trxType models.TransactionType, amount decimal.Decimal, date time.Time, id string) string {
+	return s.keys.BuildKey(trxType, amount, date, id)
+}
+
+func (s *PluginMatchStrategy) IsMatch(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) bool {
+	match, _ := s.decide(sysTrx, bankStmtLine)
+	return match
+}
+
+func (s *PluginMatchStrategy) ExplainMismatch(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) string {
+	_, reason := s.decide(sysTrx, bankStmtLine)
+	return reason
+}
+
+// Err returns why the plugin was disabled, if it was
+func (s *PluginMatchStrategy) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close stops the plugin by closing its stdin, killing it if it does not exit within the timeout
+func (s *PluginMatchStrategy) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stop()
+	return nil
+}
+
+// decide asks the plugin whether the pair matches, a failed request rejects the pair
+func (s *PluginMatchStrategy) decide(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) (bool, string) {
+	if sysTrx.Type != bankStmtLine.Type {
+		return false, fmt.Sprintf("bank statement line is a %s, the transaction a %s", bankStmtLine.Type, sysTrx.Type)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return false, fmt.Sprintf("plugin %s is disabled: %v", s.name, s.err)
+	}
+	if s.process == nil {
+		if _, err := s.start(); err != nil {
+			return false, s.fail(err)
+		}
+	}
+
+	response, err := s.request(pluginRequest{
+		Method: "match",
+		System: &pluginTransaction{
+			TrxID:           sysTrx.TrxID,
+			Amount:          sysTrx.Amount.StringFixed(2),
+			Type:            string(sysTrx.Type),
+			TransactionTime: sysTrx.TransactionTime.Format(time.RFC3339),
+			Channel:         sysTrx.Channel,
+			Counterparty:    sysTrx.Counterparty,
+		},
+		Bank: &pluginBankLine{
+			UniqueIdentifier: bankStmtLine.UniqueIdentifier,
+			Bank:             bankStmtLine.BankName,
+			Amount:           bankStmtLine.Amount.StringFixed(2),
+			Type:             string(bankStmtLine.Type),
+			Date:             bankStmtLine.Date.Format("2006-01-02"),
+			Description:      bankStmtLine.Description,
+			Counterparty:     bankStmtLine.Counterparty,
+		},
+	})
+	if err != nil {
+		return false, s.fail(err)
+	}
+	if !response.Match && response.Reason == "" {
+		return false, fmt.Sprintf("rejected by plugin %s", s.name)
+	}
+	return response.Match, response.Reason
+}
+
+// fail stops the crashed or stuck plugin so that the next request restarts it,
+// and disables the plugin once it failed more often than allowed
+func (s *PluginMatchStrategy) fail(err error) string {
+	s.stop()
+	s.restarts++
+	if s.restarts > s.config.maxRestarts() {
+		s.err = fmt.Errorf("plugin %s failed %d times, last error: %w", s.name, s.restarts, err)
+	}
+	return fmt.Sprintf("plugin %s failed: %v", s.name, err)
+}
+
+// start launches the plugin process and performs the hello handshake
+func (s *PluginMatchStrategy) start() (pluginResponse, error) {
+	cmd := exec.Command(s.config.Command, s.config.Args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return pluginResponse{}, fmt.Errorf("failed to start plugin %s: %w", s.config.Command, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return pluginResponse{}, fmt.Errorf("failed to start plugin %s: %w", s.config.Command, err)
+	}
+	stderr := &tailBuffer{limit: pluginStderrLimit}
+	cmd.Stderr = stderr
+	// Wait also waits for the stderr copy, which a child process inheriting stderr could hold open
+	// forever, WaitDelay closes the pipes once the plugin itself has exited
+	cmd.WaitDelay = s.config.timeout()
+	if err := cmd.Start(); err != nil {
+		return pluginResponse{}, fmt.Errorf("failed to start plugin %s: %w", s.config.Command, err)
+	}
+
+	process := &pluginProcess{
+		cmd:       cmd,
+		stdin:     stdin,
+		responses: make(chan pluginResponse),
+		stderr:    stderr,
+		done:      make(chan struct{}),
+	}
+	go process.readResponses(stdout)
+	s.process = process
+
+	hello, err := s.request(pluginRequest{Method: "hello", Protocol: PluginProtocolVersion})
+	if err != nil {
+		s.stop()
+		return pluginResponse{}, fmt.Errorf("plugin %s handshake failed: %w", s.config.Command, err)
+	}
+	return hello, nil
+}
+
+// stop closes stdin and waits for the plugin to exit, killing it after the timeout
+func (s *PluginMatchStrategy) stop() {
+	process := s.process
+	if process == nil {
+		return
+	}
+	s.process = nil
+
+	close(process.done)
+	process.stdin.Close()
+	exited := make(chan struct{})
+	go func() {
+		process.cmd.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(s.config.timeout()):
+		process.cmd.Process.Kill()
+		<-exited
+	}
+}
+
+// request sends one request and waits for its response
+func (s *PluginMatchStrategy) request(req pluginRequest) (pluginResponse, error) {
+	s.nextID++
+	req.ID = s.nextID
+	process := s.process
+
+	line, err := json.Marshal(req)
+	if err != nil {
+		return pluginResponse{}, err
+	}
+	if _, err := process.stdin.Write(append(line, '\n')); err != nil {
+		s.stop()
+		return pluginResponse{}, process.crashError(fmt.Errorf("failed to write request: %w", err))
+	}
+
+	select {
+	case response, ok := <-process.responses:
+		if !ok {
+			// Stopping waits for the process, after which its stderr is complete
+			s.stop()
+			return pluginResponse{}, process.crashError(process.readErr)
+		}
+		if response.ID != req.ID {
+			return pluginResponse{}, fmt.Errorf("response id %d does not match request id %d", response.ID, req.ID)
+		}
+		if response.Error != "" {
+			return pluginResponse{}, fmt.Errorf("plugin error: %s", response.Error)
+		}
+		return response, nil
+	case <-time.After(s.config.timeout()):
+		return pluginResponse{}, fmt.Errorf("no response within %s", s.config.timeout())
+	}
+}
+
+// readResponses decodes response lines until stdout is closed or a line is malformed
+func (p *pluginProcess) readResponses(stdout io.Reader) {
+	defer close(p.responses)
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), pluginMaxLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var response pluginResponse
+		if err := json.Unmarshal([]byte(line), &response); err != nil {
+			p.readErr = fmt.Errorf("malformed response %q: %w", truncate(line, 200), err)
+			return
+		}
+		select {
+		case p.responses <- response:
+		case <-p.done:
+			return
+		}
+	}
+	p.readErr = scanner.Err()
+	if p.readErr == nil {
+		p.readErr = io.ErrUnexpectedEOF
+	}
+}
+
+// crashError adds the tail of the plugin stderr to err
+func (p *pluginProcess) crashError(err error) error {
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = errors.New("plugin exited")
+	}
+	if tail := strings.TrimSpace(p.stderr.String()); tail != "" {
+		return fmt.Errorf("%w, stderr: %s", err, tail)
+	}
+	return err
+}
+
+// tailBuffer keeps the last limit bytes written to it
+type tailBuffer struct {
+	mu    sync.Mutex
+	limit int
+	data  []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if len(b.data) > b.limit {
+		b.data = b.data[len(b.data)-b.limit:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.data)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
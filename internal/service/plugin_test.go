@@ -0,0 +1,374 @@
+package service_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/firmannf/recon/internal/models"
+	"github.com/firmannf/recon/internal/service"
+)
+
+// TestPluginHelperProcess is not a real test, it is started by the plugin tests as the plugin executable
+func TestPluginHelperProcess(t *testing.T) {
+	if os.Getenv("RECON_WANT_PLUGIN_HELPER") != "1" {
+		return
+	}
+	mode := os.Args[len(os.Args)-1]
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var request struct {
+			ID     int    `json:"id"`
+			Method string `json:"method"`
+			System struct {
+				TrxID        string `json:"trx_id"`
+				Counterparty string `json:"counterparty"`
+			} `json:"system"`
+			Bank struct {
+				Description  string `json:"description"`
+				Counterparty string `json:"counterparty"`
+			} `json:"bank"`
+		}
+		json.Unmarshal(scanner.Bytes(), &request)
+		if logFile := os.Getenv("RECON_PLUGIN_LOG"); logFile != "" && request.Method == "match" {
+			if f, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err == nil {
+				fmt.Fprintln(f, request.System.TrxID)
+				f.Close()
+			}
+		}
+
+		var response map[string]any
+		switch {
+		case request.Method == "hello" && mode == "bad-hello":
+			fmt.Println("not json")
+			continue
+		case request.Method == "hello" && mode == "bad-key":
+			response = map[string]any{"name": "helper", "key_fields": []string{"reference"}}
+		case request.Method == "hello":
+			response = map[string]any{"name": "helper", "key_fields": []string{"type", "amount"}}
+		case mode == "crash":
+			fmt.Fprintln(os.Stderr, "boom")
+			os.Exit(2)
+		case mode == "hang":
+			time.Sleep(time.Hour)
+		case mode == "counterparty" && request.System.Counterparty != "" && strings.EqualFold(request.System.Counterparty, request.Bank.Counterparty):
+			response = map[string]any{"match": true}
+		case mode == "counterparty":
+			response = map[string]any{"match": false, "reason": fmt.Sprintf("counterparty %q is not %q (%s)", request.Bank.Counterparty, request.System.Counterparty, request.Bank.Description)}
+		case mode == "orphan":
+			// The child inherits stderr and keeps it open after the plugin exited
+			child := exec.Command("sleep", "10")
+			child.Stderr = os.Stderr
+			child.Start()
+			os.Exit(2)
+		case strings.Contains(request.Bank.Description, request.System.TrxID):
+			response = map[string]any{"match": true}
+		default:
+			response = map[string]any{"match": false, "reason": "reference not found"}
+		}
+
+		response["id"] = request.ID
+		line, _ := json.Marshal(response)
+		fmt.Println(string(line))
+	}
+	os.Exit(0)
+}
+
+func newHelperPlugin(t *testing.T, mode string) (*service.PluginMatchStrategy, error) {
+	maxRestarts := 1
+	return newHelperPluginWithRestarts(t, mode, &maxRestarts)
+}
+
+func newHelperPluginWithRestarts(t *testing.T, mode string, maxRestarts *int) (*service.PluginMatchStrategy, error) {
+	t.Setenv("RECON_WANT_PLUGIN_HELPER", "1")
+	return service.NewPluginMatchStrategy(service.PluginConfig{
+		Command:     os.Args[0],
+		Args:        []string{"-test.run=TestPluginHelperProcess", "--", mode},
+		Timeout:     200 * time.Millisecond,
+		MaxRestarts: maxRestarts,
+	})
+}
+
+func TestReconciliation_PluginStrategy(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-15 10:00:00
+TRX002,1000.00,CREDIT,2024-01-15 11:00:00`)
+
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date,description
+BCA-001,1000.00,2024-01-16,PAYMENT TRX002
+BCA-002,1000.00,2024-01-17,PAYMENT TRX001`)
+
+	tests := []struct {
+		name            string
+		mode            string
+		expectedMatched int
+		expectedError   string
+	}{
+		{
+			name:            "plugin decides",
+			mode:            "match",
+			expectedMatched: 2,
+		},
+		{
+			name:          "plugin crashes",
+			mode:          "crash",
+			expectedError: "boom",
+		},
+		{
+			name:          "plugin hangs",
+			mode:          "hang",
+			expectedError: "no response within",
+		},
+		{
+			name:          "plugin exits leaving a child holding stderr",
+			mode:          "orphan",
+			expectedError: "plugin exited",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin, err := newHelperPlugin(t, tt.mode)
+			if err != nil {
+				t.Fatalf("Failed to start plugin: %v", err)
+			}
+			defer plugin.Close()
+
+			reconService := service.NewReconciliationService()
+			start := time.Now()
+			result, err := reconService.Reconcile(service.ReconciliationInput{
+				SystemTransactionFile: systemCSV,
+				BankStatementFiles:    []string{bcaCSV},
+				StartDate:             mustParseTime("2024-01-01 00:00:00"),
+				EndDate:               mustParseTime("2024-12-31 23:59:59"),
+				MatchStrategy:         plugin,
+			})
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("Expected reconciliation to finish within 5s, took %s", elapsed)
+			}
+
+			if tt.expectedError != "" {
+				if err == nil {
+					t.Fatal("Expected error but got nil")
+				}
+				if !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("Expected error containing %q, got %q", tt.expectedError, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Reconciliation failed: %v", err)
+			}
+			if result.TotalMatchedTransactions != tt.expectedMatched {
+				t.Errorf("Expected %d matches, got %d", tt.expectedMatched, result.TotalMatchedTransactions)
+			}
+			if result.PassSummaries[0].Name != "helper" {
+				t.Errorf("Expected pass named after the plugin, got %s", result.PassSummaries[0].Name)
+			}
+		})
+	}
+}
+
+func TestNewPluginMatchStrategy_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          string
+		expectedError string
+	}{
+		{name: "malformed hello", mode: "bad-hello", expectedError: "handshake failed"},
+		{name: "unsupported key field", mode: "bad-key", expectedError: `unsupported key field "reference"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newHelperPlugin(t, tt.mode)
+			if err == nil {
+				t.Fatal("Expected error but got nil")
+			}
+			if !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %q", tt.expectedError, err.Error())
+			}
+		})
+	}
+
+	if _, err := service.NewPluginMatchStrategy(service.PluginConfig{Command: "/nonexistent/plugin"}); err == nil {
+		t.Error("Expected error for a missing executable but got nil")
+	}
+}
+
+func TestPluginMatchStrategy_MaxRestarts(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	if err := os.WriteFile(systemCSV, []byte(`trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-15 10:00:00
+TRX002,1000.00,CREDIT,2024-01-15 11:00:00
+TRX003,1000.00,CREDIT,2024-01-15 12:00:00
+TRX004,1000.00,CREDIT,2024-01-15 13:00:00
+TRX005,1000.00,CREDIT,2024-01-15 14:00:00`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	if err := os.WriteFile(bcaCSV, []byte(`unique_identifier,amount,date
+BCA-001,1000.00,2024-01-15`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	never, once := 0, 1
+	tests := []struct {
+		name          string
+		maxRestarts   *int
+		expectedError string
+	}{
+		{name: "zero never restarts", maxRestarts: &never, expectedError: "failed 1 times"},
+		{name: "one restart", maxRestarts: &once, expectedError: "failed 2 times"},
+		{name: "default", expectedError: "failed 4 times"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin, err := newHelperPluginWithRestarts(t, "crash", tt.maxRestarts)
+			if err != nil {
+				t.Fatalf("Failed to start plugin: %v", err)
+			}
+			defer plugin.Close()
+
+			reconService := service.NewReconciliationService()
+			_, err = reconService.Reconcile(service.ReconciliationInput{
+				SystemTransactionFile: systemCSV,
+				BankStatementFiles:    []string{bcaCSV},
+				StartDate:             mustParseTime("2024-01-15 00:00:00"),
+				MatchStrategy:         plugin,
+			})
+			if err == nil {
+				t.Fatal("Expected error but got nil")
+			}
+			if !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %q", tt.expectedError, err.Error())
+			}
+		})
+	}
+}
+
+func TestPluginMatchStrategy_ExplainAsksOncePerCandidate(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-15 10:00:00`)
+
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date,description
+BCA-001,1000.00,2024-01-15,PAYMENT TRX999`)
+
+	countRequests := func(run func(service.ReconciliationInput) error) int {
+		logFile := filepath.Join(t.TempDir(), "requests.log")
+		t.Setenv("RECON_PLUGIN_LOG", logFile)
+		plugin, err := newHelperPlugin(t, "match")
+		if err != nil {
+			t.Fatalf("Failed to start plugin: %v", err)
+		}
+		err = run(service.ReconciliationInput{
+			SystemTransactionFile: systemCSV,
+			BankStatementFiles:    []string{bcaCSV},
+			StartDate:             mustParseTime("2024-01-01 00:00:00"),
+			EndDate:               mustParseTime("2024-12-31 23:59:59"),
+			MatchStrategy:         plugin,
+		})
+		plugin.Close()
+		if err != nil {
+			t.Fatalf("Reconciliation failed: %v", err)
+		}
+		data, err := os.ReadFile(logFile)
+		if err != nil {
+			t.Fatalf("Failed to read the request log: %v", err)
+		}
+		return strings.Count(string(data), "\n")
+	}
+
+	reconService := service.NewReconciliationService()
+	reconciled := countRequests(func(input service.ReconciliationInput) error {
+		_, err := reconService.Reconcile(input)
+		return err
+	})
+
+	var explanations []models.MatchExplanation
+	explained := countRequests(func(input service.ReconciliationInput) error {
+		var err error
+		explanations, err = reconService.Explain(input, "TRX001")
+		return err
+	})
+
+	// Tracing adds one request for the only candidate on top of the matching itself
+	if explained != reconciled+1 {
+		t.Errorf("Expected %d plugin requests when explaining, got %d", reconciled+1, explained)
+	}
+	candidates := explanations[0].Passes[0].Candidates
+	if len(candidates) != 1 || candidates[0].Status != models.CandidateRejected || candidates[0].Reason != "reference not found" {
+		t.Errorf("Expected BCA-001 rejected with the plugin reason, got %+v", candidates)
+	}
+}
+
+func TestPluginMatchStrategy_SendsCounterparties(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime,counterparty
+TRX001,1000.00,CREDIT,2024-01-15 10:00:00,Budi Santoso
+TRX002,1000.00,CREDIT,2024-01-15 11:00:00,PT Maju Jaya`)
+
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date,description,counterparty
+BCA-001,1000.00,2024-01-15,TRF 0001,PT MAJU JAYA
+BCA-002,1000.00,2024-01-15,TRF 0002,CV Sinar Abadi`)
+
+	plugin, err := newHelperPlugin(t, "counterparty")
+	if err != nil {
+		t.Fatalf("Failed to start plugin: %v", err)
+	}
+	defer plugin.Close()
+
+	input := service.ReconciliationInput{
+		SystemTransactionFile: systemCSV,
+		BankStatementFiles:    []string{bcaCSV},
+		StartDate:             mustParseTime("2024-01-01 00:00:00"),
+		EndDate:               mustParseTime("2024-12-31 23:59:59"),
+		MatchStrategy:         plugin,
+	}
+	reconService := service.NewReconciliationService()
+	result, err := reconService.Reconcile(input)
+	if err != nil {
+		t.Fatalf("Reconciliation failed: %v", err)
+	}
+	if len(result.Matches) != 1 || result.Matches[0].SystemTransaction.TrxID != "TRX002" || result.Matches[0].BankStatementLine.UniqueIdentifier != "BCA-001" {
+		t.Fatalf("Expected TRX002 matched with BCA-001 by counterparty, got %+v", result.Matches)
+	}
+
+	explanations, err := reconService.Explain(input, "TRX001")
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	expectedReason := `counterparty "CV Sinar Abadi" is not "Budi Santoso" (TRF 0002)`
+	var reason string
+	for _, candidate := range explanations[0].Passes[0].Candidates {
+		if candidate.BankStatementLine.UniqueIdentifier == "BCA-002" {
+			reason = candidate.Reason
+		}
+	}
+	if reason != expectedReason {
+		t.Errorf("Expected BCA-002 rejected with %q, got %q", expectedReason, reason)
+	}
+}
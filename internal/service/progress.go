@@ -0,0 +1,38 @@
+package service
+
+import "time"
+
+// Phase identifies a stage of the reconciliation process
+type Phase string
+
+const (
+	PhaseParse  Phase = "parse"
+	PhaseFilter Phase = "filter"
+	PhaseIndex  Phase = "index"
+	PhaseMatch  Phase = "match"
+)
+
+// matchProgressInterval is the number of system transactions processed between MatchProgress calls
+const matchProgressInterval = 1000
+
+// ProgressReporter receives progress events during a reconciliation run
+type ProgressReporter interface {
+	// FileParsed is called after a system or bank statement file has been parsed.
+	// Bank statement files are parsed concurrently, so it must be safe for concurrent use.
+	FileParsed(filePath string, rows int, elapsed time.Duration)
+
+	// MatchProgress is called periodically while system transactions are being matched
+	MatchProgress(done, total int)
+
+	// PhaseCompleted is called when a reconciliation phase finishes
+	PhaseCompleted(phase Phase, elapsed time.Duration)
+}
+
+// noopProgressReporter discards all progress events
+type noopProgressReporter struct{}
+
+func (noopProgressReporter) FileParsed(filePath string, rows int, elapsed time.Duration) {}
+
+func (noopProgressReporter) MatchProgress(done, total int) {}
+
+func (noopProgressReporter) PhaseCompleted(phase Phase, elapsed time.Duration) {}
@@ -0,0 +1,93 @@
+package service_test
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/firmannf/recon/internal/service"
+)
+
+type recordingProgressReporter struct {
+	mu          sync.Mutex
+	fileRows    map[string]int
+	phases      []service.Phase
+	lastDone    int
+	lastTotal   int
+	matchEvents int
+}
+
+func (r *recordingProgressReporter) FileParsed(filePath string, rows int, elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fileRows[filepath.Base(filePath)] = rows
+}
+
+func (r *recordingProgressReporter) MatchProgress(done, total int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastDone, r.lastTotal = done, total
+	r.matchEvents++
+}
+
+func (r *recordingProgressReporter) PhaseCompleted(phase service.Phase, elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.phases = append(r.phases, phase)
+}
+
+func TestReconciliation_ProgressReporting(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-15 10:30:00
+TRX002,500.50,DEBIT,2024-01-16 14:22:00
+TRX003,700.00,DEBIT,2025-01-16 14:22:00`)
+
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date
+BCA-001,1000.00,2024-01-15`)
+
+	mandiriCSV := filepath.Join(tmpDir, "bank_mandiri.csv")
+	writeTestFile(t, mandiriCSV, `unique_identifier,amount,date
+MDR-001,-500.50,2024-01-16
+MDR-002,-100.00,2024-01-17`)
+
+	reporter := &recordingProgressReporter{fileRows: make(map[string]int)}
+	reconService := service.NewReconciliationService()
+	_, err := reconService.Reconcile(service.ReconciliationInput{
+		SystemTransactionFile: systemCSV,
+		BankStatementFiles:    []string{bcaCSV, mandiriCSV},
+		StartDate:             mustParseTime("2024-01-01 00:00:00"),
+		EndDate:               mustParseTime("2024-12-31 23:59:59"),
+		MatchStrategy:         service.NewExactMatchStrategy(),
+		ProgressReporter:      reporter,
+	})
+	if err != nil {
+		t.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	expectedRows := map[string]int{"transactions.csv": 3, "bank_bca.csv": 1, "bank_mandiri.csv": 2}
+	for file, rows := range expectedRows {
+		if reporter.fileRows[file] != rows {
+			t.Errorf("Expected %d rows reported for %s, got %d", rows, file, reporter.fileRows[file])
+		}
+	}
+
+	expectedPhases := []service.Phase{service.PhaseParse, service.PhaseFilter, service.PhaseIndex, service.PhaseMatch}
+	if len(reporter.phases) != len(expectedPhases) {
+		t.Fatalf("Expected phases %v, got %v", expectedPhases, reporter.phases)
+	}
+	for i, phase := range expectedPhases {
+		if reporter.phases[i] != phase {
+			t.Errorf("Expected phase %d to be %s, got %s", i, phase, reporter.phases[i])
+		}
+	}
+
+	// Only the 2 system transactions in range are matched
+	if reporter.matchEvents == 0 || reporter.lastDone != 2 || reporter.lastTotal != 2 {
+		t.Errorf("Expected final match progress 2/2, got %d/%d", reporter.lastDone, reporter.lastTotal)
+	}
+}
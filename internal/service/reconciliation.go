@@ -1,7 +1,9 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/firmannf/recon/internal/models"
@@ -24,10 +26,105 @@ func NewReconciliationService() *ReconciliationService {
 type ReconciliationInput struct {
 	SystemTransactionFile string
 	BankStatementFiles    []string
+	StrictAmounts         bool // Optional, rejects files with more than 2 decimal places or 15 integer digits and negative system amounts
 	StartDate             time.Time
 	EndDate               time.Time
 	OutputFile            string
 	MatchStrategy         MatchStrategy
+	MatchPipeline         MatchPipeline            // Optional, runs these passes in order instead of MatchStrategy
+	BankStrategies        map[string]MatchPipeline // Optional, pipeline per bank name used for that bank's lines instead of the default, which is still required for the other banks
+	AssignmentMode        AssignmentMode           // Optional, defaults to AssignmentGreedy
+	MatchScorer           MatchScorer              // Optional, used by AssignmentOptimal, defaults to DefaultMatchScorer
+	BatchMatching         *GroupMatchConfig        // Optional, matches bank lines against groups of system transactions after the passes
+	SplitMatching         *GroupMatchConfig        // Optional, matches system transactions against groups of bank lines after the passes
+	ReversalDetection     *ReversalConfig          // Optional, excludes offsetting entries within the same source without a counterpart before the passes
+	TransferDetection     *TransferConfig          // Optional, pairs leftover bank lines moving funds between our own accounts
+	NearMisses            *NearMissConfig          // Optional, suggests likely counterparts for unmatched items
+	MatchCondition        *MatchCondition          // Optional, every pair matched by a pass must also satisfy it
+	TimeProximity         *TimeProximityConfig     // Optional, prefers the candidate closest in time over file order
+	DuplicateDetection    *DuplicateConfig         // Optional, reports repeated rows within each file and applies the policy
+	FileCheck             *FileCheckConfig         // Optional, reports bank statement files repeating another input or a previous run
+	BalanceCheck          *BalanceCheckConfig      // Optional, reports bank balances that do not add up
+	CoverageCheck         *CoverageConfig          // Optional, reports the parts of the period the bank statements do not cover
+	ProgressReporter      ProgressReporter         // Optional, receives progress events during the run
+	CollectStatistics     bool                     // Optional, records per-phase timings and samples the peak heap into the result statistics
+
+	tracer *matchTracer // Set by Explain to trace the matching of a transaction
+}
+
+// Validate checks the dates and the matching configuration of the input without reading any file.
+// Reconcile runs it first, callers building the input may run it to report errors early.
+func (input ReconciliationInput) Validate() error {
+	// Validate date range, a zero end date stands for the end of the start date
+	if !input.EndDate.IsZero() && input.StartDate.After(input.EndDate) {
+		return fmt.Errorf("start date must not be after end date")
+	}
+
+	// Validate matching configuration
+	// Lines of banks without their own pipeline would otherwise go through no pass at all
+	if input.MatchStrategy == nil && len(input.MatchPipeline) == 0 {
+		if len(input.BankStrategies) > 0 {
+			return fmt.Errorf("a match strategy or match pipeline is required for banks without their own pipeline")
+		}
+		return fmt.Errorf("a match strategy or match pipeline is required")
+	}
+	if err := input.MatchPipeline.validate(); err != nil {
+		return err
+	}
+	for bank, pipeline := range input.BankStrategies {
+		if len(pipeline) == 0 {
+			return fmt.Errorf("match pipeline for bank %s is empty", bank)
+		}
+		if err := pipeline.validate(); err != nil {
+			return fmt.Errorf("match pipeline for bank %s: %w", bank, err)
+		}
+	}
+	if input.BatchMatching != nil {
+		if err := input.BatchMatching.validate(); err != nil {
+			return err
+		}
+	}
+	if input.SplitMatching != nil {
+		if err := input.SplitMatching.validate(); err != nil {
+			return err
+		}
+	}
+	if input.ReversalDetection != nil {
+		if err := input.ReversalDetection.validate(); err != nil {
+			return err
+		}
+	}
+	if input.TransferDetection != nil {
+		if err := input.TransferDetection.validate(); err != nil {
+			return err
+		}
+	}
+	if input.NearMisses != nil {
+		if err := input.NearMisses.validate(); err != nil {
+			return err
+		}
+	}
+	if input.TimeProximity != nil {
+		if err := input.TimeProximity.validate(); err != nil {
+			return err
+		}
+	}
+	if input.DuplicateDetection != nil {
+		if err := input.DuplicateDetection.validate(); err != nil {
+			return err
+		}
+	}
+	if input.FileCheck != nil {
+		if err := input.FileCheck.validate(); err != nil {
+			return err
+		}
+	}
+	if input.CoverageCheck != nil {
+		if err := input.CoverageCheck.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Reconcile performs the reconciliation process
@@ -37,39 +134,145 @@ func (s *ReconciliationService) Reconcile(input ReconciliationInput) (*models.Re
 		input.EndDate = input.StartDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
 	}
 
-	// Validate date range
-	if input.StartDate.After(input.EndDate) {
-		return nil, fmt.Errorf("start date must not be after end date")
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	progress := input.ProgressReporter
+	if progress == nil {
+		progress = noopProgressReporter{}
+	}
+	var stats *statisticsCollector
+	if input.CollectStatistics {
+		stats = newStatisticsCollector(progress)
+		defer stats.stop()
+		progress = stats
+	}
+
+	// Parse system transactions concurrently with the bank statement files
+	s.transactionParser.SetStrictAmounts(input.StrictAmounts)
+	s.bankStatementParser.SetStrictAmounts(input.StrictAmounts)
+	var (
+		systemTransactions []models.Transaction
+		systemErr          error
+		wg                 sync.WaitGroup
+	)
+	phaseStart := time.Now()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		systemTransactions, systemErr = s.transactionParser.ParseCSV(input.SystemTransactionFile)
+		if systemErr == nil {
+			progress.FileParsed(input.SystemTransactionFile, len(systemTransactions), time.Since(phaseStart))
+		}
+	}()
+
+	// Parse bank statements from multiple files, kept per file for the file check
+	bankStatementsPerFile, bankErr := s.bankStatementParser.ParseCSVsPerFile(input.BankStatementFiles, progress.FileParsed)
+	wg.Wait()
+
+	var errs []error
+	if systemErr != nil {
+		errs = append(errs, fmt.Errorf("failed to parse system transactions: %w", systemErr))
+	}
+	if bankErr != nil {
+		errs = append(errs, fmt.Errorf("failed to parse bank statements: %w", bankErr))
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	progress.PhaseCompleted(PhaseParse, time.Since(phaseStart))
+
+	// The same statement fed twice would double count its lines, so it is caught before matching
+	var (
+		fingerprints []models.FileFingerprint
+		fileOverlaps []models.FileOverlap
+	)
+	if input.FileCheck != nil {
+		var err error
+		fingerprints, fileOverlaps, err = checkBankFiles(input.BankStatementFiles, bankStatementsPerFile, *input.FileCheck)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var bankStatements []models.BankStatementLine
+	for _, stmtLines := range bankStatementsPerFile {
+		bankStatements = append(bankStatements, stmtLines...)
 	}
 
-	// Parse system transactions
-	systemTransactions, err := s.transactionParser.ParseCSV(input.SystemTransactionFile)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse system transactions: %w", err)
+	// Balances run through the whole statement, so they are checked before filtering by date.
+	// Exact repeats dropped by the dedupe policy must not break the running balance either.
+	var balanceGaps []models.BalanceGap
+	if input.BalanceCheck != nil {
+		dedupe := input.DuplicateDetection != nil && input.DuplicateDetection.Policy == DuplicateDedupe
+		balanceGaps = checkBalances(bankStatements, *input.BalanceCheck, dedupe)
+	}
+
+	// Coverage is taken from the whole statement, lines outside the period still show it was covered
+	var (
+		coverages    []models.BankCoverage
+		coverageGaps []models.CoverageGap
+	)
+	if input.CoverageCheck != nil {
+		coverages = bankCoverage(bankStatements)
+		coverageGaps = findCoverageGaps(coverages, input.StartDate, input.EndDate)
 	}
 
 	// Filter system transactions by date range
+	phaseStart = time.Now()
 	systemTransactions = s.filterTransactionsByDateRange(
 		systemTransactions,
 		input.StartDate,
 		input.EndDate,
 	)
 
-	// Parse bank statements from multiple files
-	bankStatements, err := s.bankStatementParser.ParseMultipleCSVs(input.BankStatementFiles)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse bank statements: %w", err)
-	}
-
 	// Filter bank statements by date range
 	bankStatements = s.filterBankStatementsByDateRange(
 		bankStatements,
 		input.StartDate,
 		input.EndDate,
 	)
+	progress.PhaseCompleted(PhaseFilter, time.Since(phaseStart))
+
+	// Resent bank lines and repeated system rows would otherwise end up unmatched
+	var duplicates duplicateReport
+	if input.DuplicateDetection != nil {
+		var err error
+		systemTransactions, bankStatements, duplicates, err = detectDuplicates(systemTransactions, bankStatements, *input.DuplicateDetection)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// Perform reconciliation
-	result := s.performReconciliation(systemTransactions, bankStatements, input.MatchStrategy)
+	result := s.performReconciliation(systemTransactions, bankStatements, input, progress)
+	result.SystemDuplicates = duplicates.system
+	result.BankDuplicates = duplicates.bank
+	result.FileFingerprints = fingerprints
+	result.FileOverlaps = fileOverlaps
+	result.BalanceGaps = balanceGaps
+	result.BankCoverage = coverages
+	result.CoverageGaps = coverageGaps
+	if input.CoverageCheck != nil && input.CoverageCheck.Policy == CoverageMark {
+		markNotYetReconcilable(result, coverages, input.CoverageCheck.SettlementDays)
+	}
+	// Suggested after marking, so that transactions no bank file covers are not offered to bank lines either
+	if input.NearMisses != nil {
+		suggestNearMisses(result, *input.NearMisses)
+	}
+	if stats != nil {
+		stats.finish(result)
+	}
+
+	// A strategy that failed while matching left pairs unmatched that it should have decided on
+	for _, pass := range schedulePasses(input) {
+		if reporter, ok := pass.Strategy.(FailureReporter); ok {
+			if err := reporter.Err(); err != nil {
+				return nil, fmt.Errorf("match pass %s failed: %w", pass.Name, err)
+			}
+		}
+	}
 
 	return result, nil
 }
@@ -77,73 +280,101 @@ func (s *ReconciliationService) Reconcile(input ReconciliationInput) (*models.Re
 func (s *ReconciliationService) performReconciliation(
 	systemTrxs []models.Transaction,
 	bankStmtLines []models.BankStatementLine,
-	matchStrategy MatchStrategy,
+	input ReconciliationInput,
+	progress ProgressReporter,
 ) *models.ReconciliationResult {
 	result := &models.ReconciliationResult{
 		TotalSystemTransactions:     len(systemTrxs),
 		TotalBankStatementLines:     len(bankStmtLines),
 		UnmatchedBankStatementLines: make(map[string][]models.BankStatementLine),
 		TotalDiscrepancies:          decimal.Zero,
+		TotalFees:                   decimal.Zero,
 	}
 
-	// Build index of bank statements by matching key for O(1) lookup
-	// Key format depends on strategy (e.g., "TYPE_AMOUNT_DATE", "TYPE_DATE", "ID", etc.)
-	bankStmtLineIndex := make(map[string][]int)
-	for bankIdx, bankStmtLine := range bankStmtLines {
-		key := matchStrategy.BuildKey(bankStmtLine.Type, bankStmtLine.GetAbsoluteAmount(), bankStmtLine.Date, bankStmtLine.UniqueIdentifier)
-		bankStmtLineIndex[key] = append(bankStmtLineIndex[key], bankIdx)
+	state := newMatchState(systemTrxs, bankStmtLines, result, progress)
+	state.condition = input.MatchCondition
+	state.timeProximity = input.TimeProximity
+	if input.tracer != nil {
+		state.tracer = input.tracer
+		state.tracer.start(systemTrxs)
 	}
 
-	// Track which statements have been matched
-	matchedsystemTrxs := make(map[int]bool)
-	matchedBankStmtLines := make(map[int]bool)
+	// Each pass runs over the transactions left unmatched by the previous ones
+	passes := schedulePasses(input)
+	result.StrategiesByBank = strategiesByBank(bankStmtLines, passes)
 
-	// Try to match each system transaction with bank statements
-	for sysIdx, sysTrx := range systemTrxs {
-		matched := false
-
-		// Look up potential matches using index - O(1) instead of O(m)
-		key := matchStrategy.BuildKey(sysTrx.Type, sysTrx.Amount, sysTrx.TransactionTime, sysTrx.TrxID)
-		if candidates, exists := bankStmtLineIndex[key]; exists {
-			for _, bankIdx := range candidates {
-				// Skip already matched bank statements
-				if matchedBankStmtLines[bankIdx] {
-					continue
-				}
-
-				// Validate match using strategy (for tolerance checking, etc.)
-				if !matchStrategy.IsMatch(sysTrx, bankStmtLines[bankIdx]) {
-					continue
-				}
-
-				// Found a match (first available candidate)
-				matched = true
-				matchedsystemTrxs[sysIdx] = true
-				matchedBankStmtLines[bankIdx] = true
-				result.TotalMatchedTransactions++
-
-				// Check for amount discrepancies
-				bankAbsAmount := bankStmtLines[bankIdx].GetAbsoluteAmount()
-				diff := sysTrx.Amount.Sub(bankAbsAmount).Abs()
-
-				// This always zero since isMatch checks for exact amount match
-				// However, keeping this for future enhancements (e.g., tolerance amount)
-				if !diff.IsZero() {
-					result.TotalDiscrepancies = result.TotalDiscrepancies.Add(diff)
-				}
-
-				break // Move to next system transaction to avoid multiple matches
+	// Reversed entries cancel out within their own source and must not be matched to the other side
+	if input.ReversalDetection != nil {
+		state.excludeReversals(*input.ReversalDetection, passes)
+	}
+
+	for _, pass := range passes {
+		// Build index of bank statements by matching key for O(1) lookup
+		// Key format depends on strategy (e.g., "TYPE_AMOUNT_DATE", "TYPE_DATE", "ID", etc.)
+		phaseStart := time.Now()
+		matchedBefore := result.TotalMatchedTransactions
+		state.startPass(pass)
+		result.Statistics.IndexKeys += len(state.bankStmtLineIndex)
+		for _, candidates := range state.bankStmtLineIndex {
+			if len(candidates) > 1 {
+				result.Statistics.IndexCollisions++
+			}
+		}
+		progress.PhaseCompleted(PhaseIndex, time.Since(phaseStart))
+		state.traceCandidates()
+
+		// Try to match each system transaction with bank statements
+		phaseStart = time.Now()
+		switch input.AssignmentMode {
+		case AssignmentOptimal:
+			scorer := input.MatchScorer
+			if scorer == nil {
+				scorer = NewDefaultMatchScorer()
 			}
+			state.assignOptimal(scorer)
+		default:
+			state.assignGreedy()
 		}
+		progress.MatchProgress(state.pending, state.pending)
+		progress.PhaseCompleted(PhaseMatch, time.Since(phaseStart))
+		state.traceAssignments()
+
+		result.PassSummaries = append(result.PassSummaries, models.PassSummary{
+			Name:    pass.Name,
+			Bank:    pass.bank,
+			Matched: result.TotalMatchedTransactions - matchedBefore,
+		})
+	}
+
+	// Settlements and split postings can only be matched once the one-to-one passes are done
+	if input.BatchMatching != nil {
+		phaseStart := time.Now()
+		matched := state.matchBatches(*input.BatchMatching)
+		progress.PhaseCompleted(PhaseMatch, time.Since(phaseStart))
+		result.PassSummaries = append(result.PassSummaries, models.PassSummary{Name: batchPassName, Matched: matched})
+	}
+	if input.SplitMatching != nil {
+		phaseStart := time.Now()
+		matched := state.matchSplits(*input.SplitMatching)
+		progress.PhaseCompleted(PhaseMatch, time.Since(phaseStart))
+		result.PassSummaries = append(result.PassSummaries, models.PassSummary{Name: splitPassName, Matched: matched})
+	}
+
+	// Bank lines without a system counterpart may be transfers between our own accounts
+	if input.TransferDetection != nil {
+		state.pairInternalTransfers(*input.TransferDetection)
+	}
 
-		if !matched {
+	// Collect unmatched system transactions in file order
+	for sysIdx, sysTrx := range systemTrxs {
+		if !state.matchedSystemTrxs[sysIdx] {
 			result.UnmatchedSystemTransactions = append(result.UnmatchedSystemTransactions, sysTrx)
 		}
 	}
 
 	// Collect unmatched bank statement lines grouped by bank
 	for bankIdx, bankStmtLine := range bankStmtLines {
-		if !matchedBankStmtLines[bankIdx] {
+		if !state.matchedBankStmtLines[bankIdx] {
 			if result.UnmatchedBankStatementLines[bankStmtLine.BankName] == nil {
 				result.UnmatchedBankStatementLines[bankStmtLine.BankName] = []models.BankStatementLine{}
 			}
@@ -164,6 +395,24 @@ func (s *ReconciliationService) performReconciliation(
 	return result
 }
 
+// strategiesByBank lists the passes that apply to the lines of each bank
+func strategiesByBank(bankStmtLines []models.BankStatementLine, passes []scheduledPass) map[string][]string {
+	strategies := make(map[string][]string)
+	for _, bankStmtLine := range bankStmtLines {
+		if _, exists := strategies[bankStmtLine.BankName]; exists {
+			continue
+		}
+		names := []string{}
+		for _, pass := range passes {
+			if pass.inScope(bankStmtLine.BankName) {
+				names = append(names, pass.Name)
+			}
+		}
+		strategies[bankStmtLine.BankName] = names
+	}
+	return strategies
+}
+
 func (s *ReconciliationService) filterTransactionsByDateRange(transactions []models.Transaction, startDate, endDate time.Time) []models.Transaction {
 	var filtered []models.Transaction
 	for _, trx := range transactions {
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/firmannf/recon/internal/generator"
+)
+
+func BenchmarkPerformReconciliation(b *testing.B) {
+	for _, rows := range generator.BenchmarkSizes() {
+		systemTrxs, bankStmtLines, err := generator.Generate(generator.DefaultConfig(rows))
+		if err != nil {
+			b.Fatalf("Failed to generate data: %v", err)
+		}
+
+		for _, mode := range []AssignmentMode{AssignmentGreedy, AssignmentOptimal} {
+			b.Run(fmt.Sprintf("rows=%d/assignment=%s", rows, mode), func(b *testing.B) {
+				s := NewReconciliationService()
+				input := ReconciliationInput{MatchStrategy: NewExactMatchStrategy(), AssignmentMode: mode}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					s.performReconciliation(systemTrxs, bankStmtLines, input, noopProgressReporter{})
+				}
+				b.ReportMetric(float64(2*rows)*float64(b.N)/b.Elapsed().Seconds(), "rows/s")
+			})
+		}
+	}
+}
+
+func BenchmarkReconcile(b *testing.B) {
+	for _, rows := range generator.BenchmarkSizes() {
+		b.Run(fmt.Sprintf("rows=%d", rows), func(b *testing.B) {
+			cfg := generator.DefaultConfig(rows)
+			systemFile, bankFiles, err := generator.WriteFiles(cfg, b.TempDir(), "bench")
+			if err != nil {
+				b.Fatalf("Failed to generate data: %v", err)
+			}
+
+			s := NewReconciliationService()
+			input := ReconciliationInput{
+				SystemTransactionFile: systemFile,
+				BankStatementFiles:    bankFiles,
+				StartDate:             cfg.StartDate,
+				EndDate:               cfg.StartDate.AddDate(0, 0, cfg.DateSpanDays),
+				MatchStrategy:         NewExactMatchStrategy(),
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.Reconcile(input); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(2*rows)*float64(b.N)/b.Elapsed().Seconds(), "rows/s")
+		})
+	}
+}
@@ -178,6 +178,40 @@ BANK-001,1000.00,2024-01-15,extra`), 0644)
 	}
 }
 
+func TestReconciliation_StrictAmounts(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,1000.005,CREDIT,2024-01-15 10:30:00`)
+
+	bankCSV := filepath.Join(tmpDir, "bank.csv")
+	writeTestFile(t, bankCSV, `unique_identifier,amount,date
+BANK-001,1000.005,2024-01-15`)
+
+	input := service.ReconciliationInput{
+		SystemTransactionFile: systemCSV,
+		BankStatementFiles:    []string{bankCSV},
+		StartDate:             mustParseTime("2024-01-01 00:00:00"),
+		EndDate:               mustParseTime("2024-12-31 23:59:59"),
+		MatchStrategy:         service.NewExactMatchStrategy(),
+	}
+
+	reconService := service.NewReconciliationService()
+	result, err := reconService.Reconcile(input)
+	if err != nil {
+		t.Fatalf("Expected amounts with 3 decimal places to load without strict amounts, got: %v", err)
+	}
+	if result.TotalMatchedTransactions != 1 {
+		t.Errorf("Expected 1 matched transaction, got %d", result.TotalMatchedTransactions)
+	}
+
+	input.StrictAmounts = true
+	if _, err := reconService.Reconcile(input); err == nil {
+		t.Error("Expected strict amounts to reject amounts with 3 decimal places")
+	}
+}
+
 func TestReconciliation_MatchingLogic(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -703,3 +737,11 @@ func mustParseTime(s string) time.Time {
 	}
 	return t
 }
+
+// writeTestFile writes an input file for a test and stops the test when it cannot be written
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
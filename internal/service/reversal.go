@@ -0,0 +1,146 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/firmannf/recon/internal/calendar"
+	"github.com/firmannf/recon/internal/models"
+)
+
+// ReversalConfig configures the detection of offsetting entries within the same source
+type ReversalConfig struct {
+	WindowDays int // The reversal may be booked up to WindowDays days after the original entry
+}
+
+// validate checks that the configuration can be used for reversal detection
+func (c ReversalConfig) validate() error {
+	if c.WindowDays < 0 {
+		return fmt.Errorf("reversal window must not be negative (got %d)", c.WindowDays)
+	}
+	return nil
+}
+
+// excludeReversals runs before the passes and pairs each entry with the first later entry of the
+// same source that has the same amount and the opposite type within the window. Only entries
+// without a possible counterpart on the other side are paired, so a genuine debit and credit of the
+// same amount are left to the passes. Both legs are marked as matched so that no pass pairs them
+// with the other side, and are listed as reversals in the result.
+func (m *matchState) excludeReversals(cfg ReversalConfig, passes []scheduledPass) {
+	// System transactions are a single source
+	var systemKeys []string
+	systemTrxsByAmount := make(map[string][]int)
+	for sysIdx, sysTrx := range m.systemTrxs {
+		key := sysTrx.Amount.String()
+		if _, exists := systemTrxsByAmount[key]; !exists {
+			systemKeys = append(systemKeys, key)
+		}
+		systemTrxsByAmount[key] = append(systemTrxsByAmount[key], sysIdx)
+	}
+
+	// Each bank statement file is its own source
+	var bankKeys []string
+	bankStmtLinesByAmount := make(map[string][]int)
+	for bankIdx, bankStmtLine := range m.bankStmtLines {
+		key := fmt.Sprintf("%s_%s", bankStmtLine.BankName, bankStmtLine.GetAbsoluteAmount())
+		if _, exists := bankStmtLinesByAmount[key]; !exists {
+			bankKeys = append(bankKeys, key)
+		}
+		bankStmtLinesByAmount[key] = append(bankStmtLinesByAmount[key], bankIdx)
+	}
+
+	systemOffsets := func(original, reversal int) bool {
+		return isReversal(m.systemTrxs[original].Type, m.systemTrxs[reversal].Type, calendar.DaysBetween(m.systemTrxs[original].TransactionTime, m.systemTrxs[reversal].TransactionTime), cfg)
+	}
+	bankOffsets := func(original, reversal int) bool {
+		return isReversal(m.bankStmtLines[original].Type, m.bankStmtLines[reversal].Type, calendar.DaysBetween(m.bankStmtLines[original].Date, m.bankStmtLines[reversal].Date), cfg)
+	}
+
+	// Only entries that may be paired need to be checked for a counterpart
+	systemCandidates := make([]bool, len(m.systemTrxs))
+	for _, key := range systemKeys {
+		markReversalCandidates(systemTrxsByAmount[key], systemCandidates, systemOffsets)
+	}
+	bankCandidates := make([]bool, len(m.bankStmtLines))
+	for _, key := range bankKeys {
+		markReversalCandidates(bankStmtLinesByAmount[key], bankCandidates, bankOffsets)
+	}
+	systemCounterparts, bankCounterparts := m.possibleCounterparts(passes, systemCandidates, bankCandidates)
+
+	for _, key := range systemKeys {
+		pairReversals(systemTrxsByAmount[key], m.matchedSystemTrxs, systemCounterparts, systemOffsets, func(original, reversal int) {
+			m.traceConsumed([]int{original, reversal}, nil, fmt.Sprintf("excluded as reversal pair %s and %s", m.systemTrxs[original].TrxID, m.systemTrxs[reversal].TrxID))
+			m.result.SystemReversals = append(m.result.SystemReversals, models.SystemReversal{
+				Original: m.systemTrxs[original],
+				Reversal: m.systemTrxs[reversal],
+			})
+		})
+	}
+	for _, key := range bankKeys {
+		pairReversals(bankStmtLinesByAmount[key], m.matchedBankStmtLines, bankCounterparts, bankOffsets, func(original, reversal int) {
+			m.traceConsumed(nil, []int{original, reversal}, fmt.Sprintf("excluded as reversal pair %s and %s", m.bankStmtLines[original].UniqueIdentifier, m.bankStmtLines[reversal].UniqueIdentifier))
+			m.result.BankReversals = append(m.result.BankReversals, models.BankReversal{
+				Original: m.bankStmtLines[original],
+				Reversal: m.bankStmtLines[reversal],
+			})
+		})
+	}
+}
+
+// markReversalCandidates marks every entry of one amount that offsets another entry of the same source
+func markReversalCandidates(idxs []int, candidates []bool, offsets func(original, reversal int) bool) {
+	for i, original := range idxs {
+		for _, reversal := range idxs[i+1:] {
+			if offsets(original, reversal) {
+				candidates[original] = true
+				candidates[reversal] = true
+			}
+		}
+	}
+}
+
+// possibleCounterparts reports which reversal candidates some pass could match with an entry on the
+// other side: the entry shares the pass key with it and passes validation. Strategies that report
+// failures are backed by an external process and are not asked, sharing the key is enough.
+func (m *matchState) possibleCounterparts(passes []scheduledPass, systemCandidates, bankCandidates []bool) (systemCounterparts, bankCounterparts []bool) {
+	systemCounterparts = make([]bool, len(m.systemTrxs))
+	bankCounterparts = make([]bool, len(m.bankStmtLines))
+	for _, pass := range passes {
+		m.startPass(pass)
+		_, external := pass.Strategy.(FailureReporter)
+		for sysIdx, sysTrx := range m.systemTrxs {
+			for _, bankIdx := range m.bankStmtLineIndex[m.systemKey(sysTrx)] {
+				needed := (systemCandidates[sysIdx] && !systemCounterparts[sysIdx]) || (bankCandidates[bankIdx] && !bankCounterparts[bankIdx])
+				if !needed || !(external || m.isMatch(sysTrx, m.bankStmtLines[bankIdx])) {
+					continue
+				}
+				systemCounterparts[sysIdx] = true
+				bankCounterparts[bankIdx] = true
+			}
+		}
+	}
+	return systemCounterparts, bankCounterparts
+}
+
+// pairReversals pairs entries of one amount in file order, marking both legs of every pair in matched.
+// Entries with a possible counterpart on the other side are left alone.
+func pairReversals(idxs []int, matched, counterparts []bool, offsets func(original, reversal int) bool, record func(original, reversal int)) {
+	for i, original := range idxs {
+		if matched[original] || counterparts[original] {
+			continue
+		}
+		for _, reversal := range idxs[i+1:] {
+			if matched[reversal] || counterparts[reversal] || !offsets(original, reversal) {
+				continue
+			}
+			matched[original] = true
+			matched[reversal] = true
+			record(original, reversal)
+			break
+		}
+	}
+}
+
+// isReversal reports whether two entries of the same amount, days apart, offset each other
+func isReversal(originalType, reversalType models.TransactionType, days int, cfg ReversalConfig) bool {
+	return originalType != reversalType && days >= 0 && days <= cfg.WindowDays
+}
@@ -0,0 +1,177 @@
+package service_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/firmannf/recon/internal/service"
+)
+
+func TestReconciliation_ReversalDetection(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,75000.00,CREDIT,2024-01-15 10:00:00
+TRX002,75000.00,DEBIT,2024-01-15 15:00:00
+TRX003,20000.00,CREDIT,2024-01-15 16:00:00
+TRX004,30000.00,CREDIT,2024-01-15 17:00:00
+TRX005,30000.00,DEBIT,2024-01-18 09:00:00`)
+
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date
+BCA-001,-500000.00,2024-01-15
+BCA-002,500000.00,2024-01-16
+BCA-003,20000.00,2024-01-15
+BCA-004,30000.00,2024-01-15`)
+
+	// The opposite leg in another bank is not a reversal
+	briCSV := filepath.Join(tmpDir, "bank_bri.csv")
+	writeTestFile(t, briCSV, `unique_identifier,amount,date
+BRI-001,-30000.00,2024-01-15`)
+
+	tests := []struct {
+		name                    string
+		reversalDetection       *service.ReversalConfig
+		expectedSystemReversals int
+		expectedBankReversals   int
+		expectedUnmatched       int
+	}{
+		{
+			name:              "disabled",
+			expectedUnmatched: 6,
+		},
+		{
+			name:                    "same day",
+			reversalDetection:       &service.ReversalConfig{},
+			expectedSystemReversals: 1,
+			expectedUnmatched:       4,
+		},
+		{
+			name:                    "within window",
+			reversalDetection:       &service.ReversalConfig{WindowDays: 1},
+			expectedSystemReversals: 1,
+			expectedBankReversals:   1,
+			expectedUnmatched:       2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconService := service.NewReconciliationService()
+			result, err := reconService.Reconcile(service.ReconciliationInput{
+				SystemTransactionFile: systemCSV,
+				BankStatementFiles:    []string{bcaCSV, briCSV},
+				StartDate:             mustParseTime("2024-01-01 00:00:00"),
+				EndDate:               mustParseTime("2024-12-31 23:59:59"),
+				MatchStrategy:         service.NewExactMatchStrategy(),
+				ReversalDetection:     tt.reversalDetection,
+			})
+			if err != nil {
+				t.Fatalf("Reconciliation failed: %v", err)
+			}
+
+			if len(result.SystemReversals) != tt.expectedSystemReversals {
+				t.Fatalf("Expected %d system reversals, got %d", tt.expectedSystemReversals, len(result.SystemReversals))
+			}
+			if len(result.BankReversals) != tt.expectedBankReversals {
+				t.Fatalf("Expected %d bank reversals, got %d", tt.expectedBankReversals, len(result.BankReversals))
+			}
+			if result.TotalUnmatchedTransactions != tt.expectedUnmatched {
+				t.Errorf("Expected %d unmatched, got %d", tt.expectedUnmatched, result.TotalUnmatchedTransactions)
+			}
+
+			// TRX004 still matches BCA-004, TRX005 is outside every window
+			if result.TotalMatchedTransactions != 2 {
+				t.Errorf("Expected 2 matches, got %d", result.TotalMatchedTransactions)
+			}
+
+			if tt.expectedSystemReversals > 0 {
+				reversal := result.SystemReversals[0]
+				if reversal.Original.TrxID != "TRX001" || reversal.Reversal.TrxID != "TRX002" {
+					t.Errorf("Expected TRX001 reversed by TRX002, got %s and %s", reversal.Original.TrxID, reversal.Reversal.TrxID)
+				}
+			}
+			if tt.expectedBankReversals > 0 {
+				reversal := result.BankReversals[0]
+				if reversal.Original.UniqueIdentifier != "BCA-001" || reversal.Reversal.UniqueIdentifier != "BCA-002" {
+					t.Errorf("Expected BCA-001 reversed by BCA-002, got %s and %s", reversal.Original.UniqueIdentifier, reversal.Reversal.UniqueIdentifier)
+				}
+			}
+		})
+	}
+}
+
+func TestReconciliation_ReversalDetectionKeepsMatchedLegs(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// A genuine payment and refund of the same amount on the same day, both booked by the bank
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,50000.00,CREDIT,2024-01-15 10:00:00
+TRX002,50000.00,DEBIT,2024-01-15 11:00:00`)
+
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date
+BCA-001,50000.00,2024-01-15
+BCA-002,-50000.00,2024-01-15`)
+
+	reconService := service.NewReconciliationService()
+	result, err := reconService.Reconcile(service.ReconciliationInput{
+		SystemTransactionFile: systemCSV,
+		BankStatementFiles:    []string{bcaCSV},
+		StartDate:             mustParseTime("2024-01-15 00:00:00"),
+		MatchStrategy:         service.NewExactMatchStrategy(),
+		ReversalDetection:     &service.ReversalConfig{},
+	})
+	if err != nil {
+		t.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	if len(result.SystemReversals) != 0 || len(result.BankReversals) != 0 {
+		t.Errorf("Expected no reversals, got %d system and %d bank", len(result.SystemReversals), len(result.BankReversals))
+	}
+	if result.TotalMatchedTransactions != 2 {
+		t.Errorf("Expected 2 matches, got %d", result.TotalMatchedTransactions)
+	}
+	if result.TotalUnmatchedTransactions != 0 {
+		t.Errorf("Expected 0 unmatched, got %d", result.TotalUnmatchedTransactions)
+	}
+}
+
+func TestReconciliation_ReversalDetectionBeforeMatching(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// TRX002 is a refund without a bank line. Matching first would pair TRX003 with BCA-001
+	// and leave TRX001 to be taken as the reversal of TRX002.
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX002,50000.00,DEBIT,2024-01-15 09:00:00
+TRX003,50000.00,CREDIT,2024-01-15 10:00:00
+TRX001,50000.00,CREDIT,2024-01-15 11:00:00`)
+
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date
+BCA-001,50000.00,2024-01-15`)
+
+	reconService := service.NewReconciliationService()
+	result, err := reconService.Reconcile(service.ReconciliationInput{
+		SystemTransactionFile: systemCSV,
+		BankStatementFiles:    []string{bcaCSV},
+		StartDate:             mustParseTime("2024-01-15 00:00:00"),
+		MatchStrategy:         service.NewExactMatchStrategy(),
+		ReversalDetection:     &service.ReversalConfig{},
+	})
+	if err != nil {
+		t.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	// Both credits may be the counterpart of BCA-001, so neither is taken as a reversal
+	if len(result.SystemReversals) != 0 {
+		t.Errorf("Expected no system reversals, got %+v", result.SystemReversals)
+	}
+	if result.TotalMatchedTransactions != 1 {
+		t.Errorf("Expected 1 match, got %d", result.TotalMatchedTransactions)
+	}
+	assertTrxIDs(t, "unmatched", result.UnmatchedSystemTransactions, []string{"TRX002", "TRX001"})
+}
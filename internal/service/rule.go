@@ -0,0 +1,212 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/firmannf/recon/internal/calendar"
+	"github.com/firmannf/recon/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// Key fields a match rule can index on
+const (
+	RuleKeyType      = "type"
+	RuleKeyAmount    = "amount"
+	RuleKeyDate      = "date"
+	RuleKeyReference = "reference"
+)
+
+// MatchRule declares a matching behaviour without code. Candidates share the values of KeyFields,
+// the fields left out of the key are then compared loosely: the bank date may be up to WindowDays
+// after the transaction date and the amounts may differ by up to Tolerance. A nil WindowDays or
+// Tolerance leaves the date or amount unchecked. The direction always has to agree.
+type MatchRule struct {
+	Name             string
+	KeyFields        []string
+	WindowDays       *int
+	Tolerance        *decimal.Decimal
+	ReferencePattern string   // Optional, regex finding references in bank lines, defaults to DefaultReferencePatterns
+	Banks            []string // Optional, bank names the rule applies to, defaults to all banks
+	Condition        string   // Optional, expression the pair must also satisfy, see MatchCondition
+	Priority         int      // Rules with a higher priority run first
+}
+
+// RuleMatchStrategy is the strategy compiled from a MatchRule
+type RuleMatchStrategy struct {
+	rule         MatchRule
+	keyType      bool
+	keyAmount    bool
+	keyDate      bool
+	keyReference bool
+	banks        map[string]bool
+	condition    *MatchCondition
+}
+
+// referenceRuleMatchStrategy is a rule keyed on references, which indexes bank lines by the references found in them
+type referenceRuleMatchStrategy struct {
+	*RuleMatchStrategy
+	references *ReferenceMatchStrategy
+}
+
+func (s *referenceRuleMatchStrategy) ExtractReferences(bankStmtLine models.BankStatementLine) []string {
+	return s.references.ExtractReferences(bankStmtLine)
+}
+
+// NewRuleMatchStrategy validates a rule and compiles it into a match strategy
+func NewRuleMatchStrategy(rule MatchRule) (MatchStrategy, error) {
+	if strings.TrimSpace(rule.Name) == "" {
+		return nil, fmt.Errorf("rule name is required")
+	}
+	if len(rule.KeyFields) == 0 {
+		return nil, fmt.Errorf("rule %q: at least one key field is required", rule.Name)
+	}
+
+	s := &RuleMatchStrategy{rule: rule}
+	seen := make(map[string]bool)
+	for _, field := range rule.KeyFields {
+		if seen[field] {
+			return nil, fmt.Errorf("rule %q: duplicate key field %q", rule.Name, field)
+		}
+		seen[field] = true
+
+		switch field {
+		case RuleKeyType:
+			s.keyType = true
+		case RuleKeyAmount:
+			s.keyAmount = true
+		case RuleKeyDate:
+			s.keyDate = true
+		case RuleKeyReference:
+			s.keyReference = true
+		default:
+			return nil, fmt.Errorf("rule %q: unknown key field %q, expected %s, %s, %s or %s", rule.Name, field, RuleKeyType, RuleKeyAmount, RuleKeyDate, RuleKeyReference)
+		}
+	}
+
+	if rule.WindowDays != nil {
+		if *rule.WindowDays < 0 {
+			return nil, fmt.Errorf("rule %q: window days must be >= 0, got %d", rule.Name, *rule.WindowDays)
+		}
+		if s.keyDate {
+			return nil, fmt.Errorf("rule %q: a date window cannot be combined with %s as a key field", rule.Name, RuleKeyDate)
+		}
+	}
+	if rule.Tolerance != nil {
+		if rule.Tolerance.IsNegative() {
+			return nil, fmt.Errorf("rule %q: tolerance must be >= 0, got %s", rule.Name, rule.Tolerance)
+		}
+		if s.keyAmount {
+			return nil, fmt.Errorf("rule %q: an amount tolerance cannot be combined with %s as a key field", rule.Name, RuleKeyAmount)
+		}
+	}
+	if rule.ReferencePattern != "" && !s.keyReference {
+		return nil, fmt.Errorf("rule %q: a reference pattern requires %s as a key field", rule.Name, RuleKeyReference)
+	}
+
+	if len(rule.Banks) > 0 {
+		s.banks = make(map[string]bool, len(rule.Banks))
+		for _, bank := range rule.Banks {
+			s.banks[bank] = true
+		}
+	}
+
+	if rule.Condition != "" {
+		condition, err := NewMatchCondition(rule.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: invalid condition: %w", rule.Name, err)
+		}
+		s.condition = condition
+	}
+
+	if !s.keyReference {
+		return s, nil
+	}
+
+	var patterns []string
+	if rule.ReferencePattern != "" {
+		patterns = []string{rule.ReferencePattern}
+	}
+	references, err := NewReferenceMatchStrategy(patterns, nil)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+	}
+	return &referenceRuleMatchStrategy{RuleMatchStrategy: s, references: references}, nil
+}
+
+// CompileMatchRules compiles the rules into a pipeline ordered by descending priority,
+// rules with the same priority keep their order
+func CompileMatchRules(rules []MatchRule) (MatchPipeline, error) {
+	ordered := make([]MatchRule, len(rules))
+	copy(ordered, rules)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Priority > ordered[j].Priority
+	})
+
+	var pipeline MatchPipeline
+	names := make(map[string]bool)
+	for _, rule := range ordered {
+		if names[rule.Name] {
+			return nil, fmt.Errorf("duplicate rule name %q", rule.Name)
+		}
+		names[rule.Name] = true
+
+		strategy, err := NewRuleMatchStrategy(rule)
+		if err != nil {
+			return nil, err
+		}
+		pipeline = append(pipeline, MatchPass{Name: rule.Name, Strategy: strategy})
+	}
+	return pipeline, nil
+}
+
+func (s *RuleMatchStrategy) Name() string {
+	return s.rule.Name
+}
+
+func (s *RuleMatchStrategy) BuildKey(trxType models.TransactionType, amount decimal.Decimal, date time.Time, id string) string {
+	var parts []string
+	if s.keyType {
+		parts = append(parts, string(trxType))
+	}
+	if s.keyAmount {
+		parts = append(parts, amount.String())
+	}
+	if s.keyDate {
+		parts = append(parts, date.Format("2006-01-02"))
+	}
+	if s.keyReference {
+		parts = append(parts, "REF_"+normalizeReference(id))
+	}
+	return strings.Join(parts, "_")
+}
+
+func (s *RuleMatchStrategy) IsMatch(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) bool {
+	return s.ExplainMismatch(sysTrx, bankStmtLine) == ""
+}
+
+// ExplainMismatch returns why the pair fails the rule, or an empty string when it matches
+func (s *RuleMatchStrategy) ExplainMismatch(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) string {
+	if s.banks != nil && !s.banks[bankStmtLine.BankName] {
+		return fmt.Sprintf("rule %s does not apply to %s", s.rule.Name, bankStmtLine.BankName)
+	}
+	if sysTrx.Type != bankStmtLine.Type {
+		return fmt.Sprintf("bank statement line is a %s, the transaction a %s", bankStmtLine.Type, sysTrx.Type)
+	}
+	if s.rule.WindowDays != nil {
+		if days := calendar.DaysBetween(sysTrx.TransactionTime, bankStmtLine.Date); days < 0 || days > *s.rule.WindowDays {
+			return fmt.Sprintf("bank date is %d days after the transaction date, expected 0 to %d", days, *s.rule.WindowDays)
+		}
+	}
+	if s.rule.Tolerance != nil {
+		if diff := sysTrx.Amount.Sub(bankStmtLine.GetAbsoluteAmount()).Abs(); diff.GreaterThan(*s.rule.Tolerance) {
+			return fmt.Sprintf("amount difference %s exceeds the tolerance of %s", diff, s.rule.Tolerance)
+		}
+	}
+	if s.condition != nil {
+		return s.condition.ExplainMismatch(sysTrx, bankStmtLine)
+	}
+	return ""
+}
@@ -0,0 +1,178 @@
+package service_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/firmannf/recon/internal/service"
+)
+
+func TestCompileMatchRules_Validation(t *testing.T) {
+	days := 1
+	negativeDays := -1
+	tolerance := decimal.NewFromInt(500)
+
+	tests := []struct {
+		name          string
+		rules         []service.MatchRule
+		expectedError string
+	}{
+		{
+			name:          "missing name",
+			rules:         []service.MatchRule{{KeyFields: []string{"type"}}},
+			expectedError: "rule name is required",
+		},
+		{
+			name:          "missing key fields",
+			rules:         []service.MatchRule{{Name: "empty"}},
+			expectedError: `rule "empty": at least one key field is required`,
+		},
+		{
+			name:          "unknown key field",
+			rules:         []service.MatchRule{{Name: "typo", KeyFields: []string{"type", "amout"}}},
+			expectedError: `rule "typo": unknown key field "amout"`,
+		},
+		{
+			name:          "duplicate key field",
+			rules:         []service.MatchRule{{Name: "twice", KeyFields: []string{"type", "type"}}},
+			expectedError: `rule "twice": duplicate key field "type"`,
+		},
+		{
+			name:          "window with date key",
+			rules:         []service.MatchRule{{Name: "window", KeyFields: []string{"type", "date"}, WindowDays: &days}},
+			expectedError: `rule "window": a date window cannot be combined with date as a key field`,
+		},
+		{
+			name:          "negative window",
+			rules:         []service.MatchRule{{Name: "window", KeyFields: []string{"type"}, WindowDays: &negativeDays}},
+			expectedError: `rule "window": window days must be >= 0`,
+		},
+		{
+			name:          "tolerance with amount key",
+			rules:         []service.MatchRule{{Name: "tolerance", KeyFields: []string{"amount"}, Tolerance: &tolerance}},
+			expectedError: `rule "tolerance": an amount tolerance cannot be combined with amount as a key field`,
+		},
+		{
+			name:          "reference pattern without reference key",
+			rules:         []service.MatchRule{{Name: "invoice", KeyFields: []string{"type"}, ReferencePattern: "INV-[0-9]+"}},
+			expectedError: `rule "invoice": a reference pattern requires reference as a key field`,
+		},
+		{
+			name:          "invalid reference pattern",
+			rules:         []service.MatchRule{{Name: "invoice", KeyFields: []string{"reference"}, ReferencePattern: "INV-[0-9"}},
+			expectedError: `rule "invoice": invalid reference pattern`,
+		},
+		{
+			name: "duplicate name",
+			rules: []service.MatchRule{
+				{Name: "exact", KeyFields: []string{"type", "amount", "date"}},
+				{Name: "exact", KeyFields: []string{"type", "amount"}},
+			},
+			expectedError: `duplicate rule name "exact"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CompileMatchRules(tt.rules)
+			if err == nil {
+				t.Fatal("Expected error but got nil")
+			}
+			if !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %q", tt.expectedError, err.Error())
+			}
+		})
+	}
+}
+
+func TestCompileMatchRules_Priority(t *testing.T) {
+	pipeline, err := service.CompileMatchRules([]service.MatchRule{
+		{Name: "first-low", KeyFields: []string{"type", "amount", "date"}},
+		{Name: "high", KeyFields: []string{"reference"}, Priority: 10},
+		{Name: "second-low", KeyFields: []string{"type", "amount"}},
+	})
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	expected := []string{"high", "first-low", "second-low"}
+	if len(pipeline) != len(expected) {
+		t.Fatalf("Expected %d passes, got %d", len(expected), len(pipeline))
+	}
+	for i, name := range expected {
+		if pipeline[i].Name != name {
+			t.Errorf("Expected pass %d to be %s, got %s", i+1, name, pipeline[i].Name)
+		}
+	}
+}
+
+func TestReconciliation_MatchRules(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+INV-001,100000.00,CREDIT,2024-01-15 10:00:00
+TRX002,50000.00,DEBIT,2024-01-15 11:00:00
+TRX003,75000.00,CREDIT,2024-01-15 12:00:00
+INV-004,20000.00,CREDIT,2024-01-15 13:00:00`)
+
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date,description
+BCA-001,99500.00,2024-01-17,PAYMENT INV-001
+BCA-002,-50000.00,2024-01-15,
+BCA-003,75000.00,2024-01-16,`)
+
+	// The invoice rule only applies to bank_bca
+	briCSV := filepath.Join(tmpDir, "bank_bri.csv")
+	writeTestFile(t, briCSV, `unique_identifier,amount,date,description
+BRI-001,20000.00,2024-01-20,PAYMENT INV-004`)
+
+	windowDays := 1
+	pipeline, err := service.CompileMatchRules([]service.MatchRule{
+		{Name: "same-day", KeyFields: []string{"type", "amount", "date"}},
+		{Name: "t+1", KeyFields: []string{"type", "amount"}, WindowDays: &windowDays},
+		{Name: "invoice", KeyFields: []string{"reference"}, ReferencePattern: "INV-[0-9]+", Banks: []string{"bank_bca"}, Priority: 10},
+	})
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	reconService := service.NewReconciliationService()
+	result, err := reconService.Reconcile(service.ReconciliationInput{
+		SystemTransactionFile: systemCSV,
+		BankStatementFiles:    []string{bcaCSV, briCSV},
+		StartDate:             mustParseTime("2024-01-01 00:00:00"),
+		EndDate:               mustParseTime("2024-12-31 23:59:59"),
+		MatchPipeline:         pipeline,
+	})
+	if err != nil {
+		t.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	expectedPasses := map[string]int{"invoice": 1, "same-day": 1, "t+1": 1}
+	if len(result.PassSummaries) != len(expectedPasses) {
+		t.Fatalf("Expected %d pass summaries, got %d", len(expectedPasses), len(result.PassSummaries))
+	}
+	if result.PassSummaries[0].Name != "invoice" {
+		t.Errorf("Expected the invoice rule to run first, got %s", result.PassSummaries[0].Name)
+	}
+	for _, pass := range result.PassSummaries {
+		if pass.Matched != expectedPasses[pass.Name] {
+			t.Errorf("Expected %d matches for %s, got %d", expectedPasses[pass.Name], pass.Name, pass.Matched)
+		}
+	}
+
+	if !result.TotalDiscrepancies.Equal(decimal.NewFromInt(500)) {
+		t.Errorf("Expected total discrepancies 500, got %s", result.TotalDiscrepancies)
+	}
+
+	if len(result.UnmatchedSystemTransactions) != 1 || result.UnmatchedSystemTransactions[0].TrxID != "INV-004" {
+		t.Errorf("Expected INV-004 unmatched, got %v", result.UnmatchedSystemTransactions)
+	}
+	if len(result.UnmatchedBankStatementLines["bank_bri"]) != 1 {
+		t.Errorf("Expected BRI-001 unmatched, got %v", result.UnmatchedBankStatementLines["bank_bri"])
+	}
+}
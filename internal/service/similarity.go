@@ -0,0 +1,171 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/firmannf/recon/internal/models"
+)
+
+// referenceSimilarity compares two references ignoring case and punctuation.
+// It returns 1 when one contains the other, otherwise the bigram Dice coefficient.
+func referenceSimilarity(a, b string) float64 {
+	a, b = normalizeReference(a), normalizeReference(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	if strings.Contains(a, b) || strings.Contains(b, a) {
+		return 1
+	}
+	return diceCoefficient(a, b)
+}
+
+// normalizeReference upper-cases and keeps only letters and digits
+func normalizeReference(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return sb.String()
+}
+
+// diceCoefficient returns the Sørensen-Dice similarity of the character bigrams of a and b
+func diceCoefficient(a, b string) float64 {
+	aRunes, bRunes := []rune(a), []rune(b)
+	if len(aRunes) < 2 || len(bRunes) < 2 {
+		if a == b {
+			return 1
+		}
+		return 0
+	}
+
+	bigrams := make(map[[2]rune]int)
+	for i := 0; i < len(aRunes)-1; i++ {
+		bigrams[[2]rune{aRunes[i], aRunes[i+1]}]++
+	}
+
+	overlap := 0
+	for i := 0; i < len(bRunes)-1; i++ {
+		bigram := [2]rune{bRunes[i], bRunes[i+1]}
+		if bigrams[bigram] > 0 {
+			bigrams[bigram]--
+			overlap++
+		}
+	}
+
+	return 2 * float64(overlap) / float64(len(aRunes)-1+len(bRunes)-1)
+}
+
+// counterpartyLegalForms are company forms that are dropped wherever they appear in a name
+var counterpartyLegalForms = map[string]bool{
+	"PT": true, "CV": true, "TBK": true, "UD": true, "PD": true, "FA": true, "KOPERASI": true, "YAYASAN": true,
+}
+
+// counterpartyHonorifics are Indonesian titles and salutations that are dropped from the start of a name
+var counterpartyHonorifics = map[string]bool{
+	"BAPAK": true, "BPK": true, "BP": true, "PAK": true, "IBU": true, "BU": true,
+	"SDR": true, "SDRI": true, "SAUDARA": true, "SAUDARI": true,
+	"TUAN": true, "TN": true, "NYONYA": true, "NY": true, "NONA": true, "NN": true,
+	"H": true, "HJ": true, "HAJI": true, "HAJJAH": true,
+	"DR": true, "DRS": true, "DRA": true, "IR": true, "PROF": true,
+}
+
+// normalizeCounterparty upper-cases a name, replaces punctuation with spaces and drops legal
+// forms anywhere and honorifics at the start, so "Bpk. H. Budi" and "BUDI" compare equal
+func normalizeCounterparty(name string) []string {
+	fields := strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if counterpartyLegalForms[field] {
+			continue
+		}
+		if len(tokens) == 0 && counterpartyHonorifics[field] {
+			continue
+		}
+		tokens = append(tokens, field)
+	}
+	return tokens
+}
+
+// counterpartySimilarity compares a name with the counterparty of a bank statement line, from 0 to 1.
+// Lines without a counterparty are compared against the best window of words of their description.
+func counterpartySimilarity(name string, bankStmtLine models.BankStatementLine) float64 {
+	nameTokens := normalizeCounterparty(name)
+	if len(nameTokens) == 0 {
+		return 0
+	}
+	target := strings.Join(nameTokens, " ")
+
+	if bankStmtLine.Counterparty != "" {
+		return jaroWinkler(target, strings.Join(normalizeCounterparty(bankStmtLine.Counterparty), " "))
+	}
+
+	// Narratives mix the name with references and transfer codes, so every run of words of about
+	// the same length as the name is a candidate
+	descTokens := normalizeCounterparty(bankStmtLine.Description)
+	best := 0.0
+	for size := max(1, len(nameTokens)-1); size <= len(nameTokens)+1; size++ {
+		for start := 0; start+size <= len(descTokens); start++ {
+			best = max(best, jaroWinkler(target, strings.Join(descTokens[start:start+size], " ")))
+		}
+	}
+	return best
+}
+
+// jaroWinkler returns the Jaro-Winkler similarity of a and b, boosting strings with a common prefix
+func jaroWinkler(a, b string) float64 {
+	aRunes, bRunes := []rune(a), []rune(b)
+	if len(aRunes) == 0 || len(bRunes) == 0 {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	matchDistance := max(0, max(len(aRunes), len(bRunes))/2-1)
+	aMatched := make([]bool, len(aRunes))
+	bMatched := make([]bool, len(bRunes))
+
+	matches := 0
+	for i := range aRunes {
+		for j := max(0, i-matchDistance); j < min(len(bRunes), i+matchDistance+1); j++ {
+			if bMatched[j] || aRunes[i] != bRunes[j] {
+				continue
+			}
+			aMatched[i], bMatched[j] = true, true
+			matches++
+			break
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, j := 0, 0
+	for i := range aRunes {
+		if !aMatched[i] {
+			continue
+		}
+		for !bMatched[j] {
+			j++
+		}
+		if aRunes[i] != bRunes[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(aRunes)) + m/float64(len(bRunes)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(aRunes), len(bRunes)) && aRunes[prefix] == bRunes[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
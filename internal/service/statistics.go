@@ -0,0 +1,129 @@
+package service
+
+import (
+	"runtime/metrics"
+	"sync"
+	"time"
+
+	"github.com/firmannf/recon/internal/models"
+)
+
+// heapSampleInterval is how often the heap is sampled while the reconciliation runs
+const heapSampleInterval = 5 * time.Millisecond
+
+// heapObjectsMetric is the memory occupied by live and not yet swept heap objects, like MemStats.HeapAlloc.
+// It is read without stopping the world, unlike runtime.ReadMemStats.
+const heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+
+// statisticsCollector records run statistics from progress events and
+// forwards every event to the wrapped reporter. The heap is sampled in the
+// background until stop, so the peak includes the middle of long phases.
+type statisticsCollector struct {
+	mu        sync.Mutex
+	next      ProgressReporter
+	startTime time.Time
+	stats     models.RunStatistics
+
+	heapSample []metrics.Sample
+	done       chan struct{}
+	stopOnce   sync.Once
+	sampler    sync.WaitGroup
+}
+
+func newStatisticsCollector(next ProgressReporter) *statisticsCollector {
+	c := &statisticsCollector{
+		next:      next,
+		startTime: time.Now(),
+		stats: models.RunStatistics{
+			ParseDurations: make(map[string]time.Duration),
+		},
+		heapSample: []metrics.Sample{{Name: heapObjectsMetric}},
+		done:       make(chan struct{}),
+	}
+	c.sampleHeap()
+
+	c.sampler.Add(1)
+	go func() {
+		defer c.sampler.Done()
+		ticker := time.NewTicker(heapSampleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.done:
+				return
+			case <-ticker.C:
+				c.mu.Lock()
+				c.sampleHeap()
+				c.mu.Unlock()
+			}
+		}
+	}()
+	return c
+}
+
+// stop ends the background heap sampling, it may be called more than once
+func (c *statisticsCollector) stop() {
+	c.stopOnce.Do(func() {
+		close(c.done)
+		c.sampler.Wait()
+	})
+}
+
+func (c *statisticsCollector) FileParsed(filePath string, rows int, elapsed time.Duration) {
+	c.mu.Lock()
+	c.stats.ParseDurations[filePath] = elapsed
+	c.stats.RowsParsed += rows
+	c.sampleHeap()
+	c.mu.Unlock()
+
+	c.next.FileParsed(filePath, rows, elapsed)
+}
+
+func (c *statisticsCollector) MatchProgress(done, total int) {
+	c.next.MatchProgress(done, total)
+}
+
+func (c *statisticsCollector) PhaseCompleted(phase Phase, elapsed time.Duration) {
+	c.mu.Lock()
+	switch phase {
+	case PhaseParse:
+		c.stats.ParseDuration = elapsed
+	case PhaseFilter:
+		c.stats.FilterDuration = elapsed
+	case PhaseIndex:
+		// Index and match run once per matching pass
+		c.stats.IndexBuildDuration += elapsed
+	case PhaseMatch:
+		c.stats.MatchDuration += elapsed
+	}
+	c.sampleHeap()
+	c.mu.Unlock()
+
+	c.next.PhaseCompleted(phase, elapsed)
+}
+
+// finish stores the completed statistics on the result, keeping the index counters set during matching
+func (c *statisticsCollector) finish(result *models.ReconciliationResult) {
+	c.stop()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sampleHeap()
+	c.stats.TotalDuration = time.Since(c.startTime)
+	if seconds := c.stats.ParseDuration.Seconds(); seconds > 0 {
+		c.stats.RowsPerSecond = float64(c.stats.RowsParsed) / seconds
+	}
+	c.stats.IndexKeys = result.Statistics.IndexKeys
+	c.stats.IndexCollisions = result.Statistics.IndexCollisions
+
+	result.Statistics = c.stats
+}
+
+// sampleHeap updates the peak heap usage, callers must hold the lock
+func (c *statisticsCollector) sampleHeap() {
+	metrics.Read(c.heapSample)
+	if c.heapSample[0].Value.Kind() == metrics.KindUint64 {
+		c.stats.PeakHeapBytes = max(c.stats.PeakHeapBytes, c.heapSample[0].Value.Uint64())
+	}
+}
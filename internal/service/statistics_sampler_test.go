@@ -0,0 +1,32 @@
+package service
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/firmannf/recon/internal/models"
+)
+
+// TestStatisticsCollector_SamplesHeapBetweenEvents makes sure the peak heap includes memory
+// allocated and released again between two progress events
+func TestStatisticsCollector_SamplesHeapBetweenEvents(t *testing.T) {
+	runtime.GC()
+	collector := newStatisticsCollector(noopProgressReporter{})
+
+	const size = 64 << 20
+	buffer := make([]byte, size)
+	for i := range buffer {
+		buffer[i] = 1
+	}
+	time.Sleep(20 * heapSampleInterval)
+	runtime.KeepAlive(buffer)
+	buffer = nil
+	runtime.GC()
+
+	result := &models.ReconciliationResult{}
+	collector.finish(result)
+	if result.Statistics.PeakHeapBytes < size {
+		t.Errorf("Expected a peak heap of at least %d bytes, got %d", size, result.Statistics.PeakHeapBytes)
+	}
+}
@@ -0,0 +1,70 @@
+package service_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/firmannf/recon/internal/service"
+)
+
+func TestReconciliation_RunStatistics(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,1000.00,CREDIT,2024-01-15 10:30:00
+TRX002,500.50,DEBIT,2024-01-16 14:22:00`)
+
+	bankCSV := filepath.Join(tmpDir, "bank.csv")
+	writeTestFile(t, bankCSV, `unique_identifier,amount,date
+BANK-001,1000.00,2024-01-15
+BANK-002,1000.00,2024-01-15
+BANK-003,-500.50,2024-01-16`)
+
+	input := service.ReconciliationInput{
+		SystemTransactionFile: systemCSV,
+		BankStatementFiles:    []string{bankCSV},
+		StartDate:             mustParseTime("2024-01-01 00:00:00"),
+		EndDate:               mustParseTime("2024-12-31 23:59:59"),
+		MatchStrategy:         service.NewExactMatchStrategy(),
+	}
+
+	// Without CollectStatistics nothing but the index counters is recorded
+	reconService := service.NewReconciliationService()
+	result, err := reconService.Reconcile(input)
+	if err != nil {
+		t.Fatalf("Reconciliation failed: %v", err)
+	}
+	if result.Statistics.ParseDurations != nil || result.Statistics.TotalDuration != 0 || result.Statistics.PeakHeapBytes != 0 {
+		t.Errorf("Expected no timings or heap statistics without CollectStatistics, got %+v", result.Statistics)
+	}
+
+	input.CollectStatistics = true
+	result, err = reconService.Reconcile(input)
+	if err != nil {
+		t.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	stats := result.Statistics
+	if len(stats.ParseDurations) != 2 {
+		t.Errorf("Expected parse durations for 2 files, got %d", len(stats.ParseDurations))
+	}
+	if _, exists := stats.ParseDurations[bankCSV]; !exists {
+		t.Errorf("Expected parse duration for %s", bankCSV)
+	}
+	if stats.RowsParsed != 5 {
+		t.Errorf("Expected 5 rows parsed, got %d", stats.RowsParsed)
+	}
+	if stats.IndexKeys != 2 {
+		t.Errorf("Expected 2 index keys, got %d", stats.IndexKeys)
+	}
+	if stats.IndexCollisions != 1 {
+		t.Errorf("Expected 1 index collision, got %d", stats.IndexCollisions)
+	}
+	if stats.TotalDuration <= 0 || stats.PeakHeapBytes == 0 || stats.RowsPerSecond <= 0 {
+		t.Errorf("Expected total duration, peak heap and throughput to be recorded, got %+v", stats)
+	}
+	if expected := float64(stats.RowsParsed) / stats.ParseDuration.Seconds(); stats.RowsPerSecond != expected {
+		t.Errorf("Expected %.0f rows per second of parsing, got %.0f", expected, stats.RowsPerSecond)
+	}
+}
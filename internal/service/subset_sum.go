@@ -0,0 +1,66 @@
+package service
+
+import "sort"
+
+// maxSubsetSearchSteps bounds the work spent looking for a single group so that
+// large candidate sets cannot stall the run, the search gives up once it is exhausted
+const maxSubsetSearchSteps = 100_000
+
+// findSubsetSum looks for between minSize and maxSize amounts whose sum is within
+// tolerance of target. Amounts and target are in the smallest currency unit and must
+// be positive. It returns the indices of the chosen amounts in ascending order, or nil.
+func findSubsetSum(amounts []int64, target, tolerance int64, minSize, maxSize int) []int {
+	// Trying large amounts first reaches the target with fewer steps
+	order := make([]int, len(amounts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return amounts[order[a]] > amounts[order[b]]
+	})
+
+	// remaining[i] is the sum of order[i:], used to prune branches that cannot reach the target
+	remaining := make([]int64, len(order)+1)
+	for i := len(order) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + amounts[order[i]]
+	}
+
+	var (
+		chosen []int
+		steps  int
+		search func(start int, sum int64) bool
+	)
+	search = func(start int, sum int64) bool {
+		if len(chosen) >= minSize && sum >= target-tolerance && sum <= target+tolerance {
+			return true
+		}
+		if len(chosen) == maxSize {
+			return false
+		}
+		for i := start; i < len(order); i++ {
+			if sum+remaining[i] < target-tolerance {
+				return false
+			}
+			amount := amounts[order[i]]
+			if sum+amount > target+tolerance {
+				continue
+			}
+			steps++
+			if steps > maxSubsetSearchSteps {
+				return false
+			}
+			chosen = append(chosen, order[i])
+			if search(i+1, sum+amount) {
+				return true
+			}
+			chosen = chosen[:len(chosen)-1]
+		}
+		return false
+	}
+
+	if !search(0, 0) {
+		return nil
+	}
+	sort.Ints(chosen)
+	return chosen
+}
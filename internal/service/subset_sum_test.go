@@ -0,0 +1,75 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindSubsetSum(t *testing.T) {
+	tests := []struct {
+		name      string
+		amounts   []int64
+		target    int64
+		tolerance int64
+		maxSize   int
+		expected  []int
+	}{
+		{
+			name:     "exact sum",
+			amounts:  []int64{500, 300, 200, 900},
+			target:   1000,
+			maxSize:  10,
+			expected: []int{0, 1, 2},
+		},
+		{
+			name:      "sum within tolerance",
+			amounts:   []int64{400, 590},
+			target:    1000,
+			tolerance: 10,
+			maxSize:   10,
+			expected:  []int{0, 1},
+		},
+		{
+			name:     "single amount is not a group",
+			amounts:  []int64{1000, 1},
+			target:   1000,
+			maxSize:  10,
+			expected: nil,
+		},
+		{
+			name:     "group larger than max size",
+			amounts:  []int64{250, 250, 250, 250},
+			target:   1000,
+			maxSize:  3,
+			expected: nil,
+		},
+		{
+			name:     "no combination",
+			amounts:  []int64{300, 300, 300},
+			target:   1000,
+			maxSize:  10,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findSubsetSum(tt.amounts, tt.target, tt.tolerance, 2, tt.maxSize)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFindSubsetSum_BoundedSearch(t *testing.T) {
+	// Even amounts can never sum to an odd target, an unbounded search would try every subset
+	amounts := make([]int64, 60)
+	for i := range amounts {
+		amounts[i] = int64(2 * (i + 1))
+	}
+
+	if got := findSubsetSum(amounts, 1001, 0, 2, len(amounts)); got != nil {
+		t.Errorf("Expected no subset, got %v", got)
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/firmannf/recon/internal/models"
+)
+
+// TimeProximityConfig makes the matcher prefer, among the candidates of a system transaction,
+// the bank line booked closest in time instead of the first one in file order.
+// Only bank lines with a time take part, date-only lines rank after them in file order.
+// Greedy assignment picks for each system transaction in file order, so an earlier transaction
+// may take the line a later one is closer to; optimal assignment weighs the time gaps of the
+// whole key bucket together with the match score.
+type TimeProximityConfig struct {
+	MaxGap time.Duration // Optional, bank lines with a time further from the transaction are rejected, 0 means no limit
+}
+
+// validate checks that the configuration can be used for candidate ordering
+func (c TimeProximityConfig) validate() error {
+	if c.MaxGap < 0 {
+		return fmt.Errorf("maximum time gap must not be negative (got %s)", c.MaxGap)
+	}
+	return nil
+}
+
+// exceedsMaxGap reports whether the bank line has a time further from the transaction than MaxGap
+func (c TimeProximityConfig) exceedsMaxGap(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) bool {
+	gap, hasTime := timeGap(sysTrx, bankStmtLine)
+	return hasTime && c.MaxGap > 0 && gap > c.MaxGap
+}
+
+// timeGap returns the absolute time between the transaction and the bank line, or false when
+// the bank line has only a date
+func timeGap(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) (time.Duration, bool) {
+	if !hasBookingTime(bankStmtLine) {
+		return 0, false
+	}
+	return sysTrx.TransactionTime.Sub(bankStmtLine.Date).Abs(), true
+}
+
+// hasBookingTime reports whether the bank line date includes a time. Lines built without the
+// parser count as timed when their date is not at midnight.
+func hasBookingTime(bankStmtLine models.BankStatementLine) bool {
+	return bankStmtLine.HasTime || !isDateOnly(bankStmtLine.Date)
+}
+
+// timeCloseness rates the time gap of a pair for optimal assignment, from 1 for the same instant
+// down towards 0 as the gap grows. Date-only lines score 0 so that they rank after timed ones.
+func timeCloseness(sysTrx models.Transaction, bankStmtLine models.BankStatementLine) float64 {
+	gap, hasTime := timeGap(sysTrx, bankStmtLine)
+	if !hasTime {
+		return 0
+	}
+	return 1 / (1 + gap.Hours())
+}
+
+// candidateRank orders the candidates of a system transaction, see better
+type candidateRank struct {
+	score   float64
+	gap     time.Duration
+	hasTime bool
+}
+
+// better reports whether r ranks before other: a higher strategy score first, then, when time
+// proximity is enabled, a timed line before a date-only one and the smaller time gap.
+// Equal ranks keep file order.
+func (r candidateRank) better(other candidateRank, byTime bool) bool {
+	if r.score != other.score {
+		return r.score > other.score
+	}
+	if !byTime || r.hasTime != other.hasTime {
+		return byTime && r.hasTime
+	}
+	return r.hasTime && r.gap < other.gap
+}
@@ -0,0 +1,200 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/firmannf/recon/internal/models"
+	"github.com/firmannf/recon/internal/service"
+)
+
+func TestReconciliation_TimeProximity(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,50000.00,CREDIT,2024-01-15 15:00:00
+TRX002,50000.00,CREDIT,2024-01-15 09:00:00`)
+
+	// Same amount twice on the same day, the bank export has the booking time in its own column
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date,time
+BCA-001,50000.00,2024-01-15,09:01:10
+BCA-002,50000.00,2024-01-15,15:00:30`)
+
+	tests := []struct {
+		name              string
+		timeProximity     *service.TimeProximityConfig
+		expectedPairs     map[string]string
+		expectedUnmatched []string
+	}{
+		{
+			name:          "file order without time proximity",
+			expectedPairs: map[string]string{"TRX001": "BCA-001", "TRX002": "BCA-002"},
+		},
+		{
+			name:          "closest time wins",
+			timeProximity: &service.TimeProximityConfig{},
+			expectedPairs: map[string]string{"TRX001": "BCA-002", "TRX002": "BCA-001"},
+		},
+		{
+			name:              "maximum gap rejects distant lines",
+			timeProximity:     &service.TimeProximityConfig{MaxGap: time.Minute},
+			expectedPairs:     map[string]string{"TRX001": "BCA-002"},
+			expectedUnmatched: []string{"TRX002"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconService := service.NewReconciliationService()
+			result, err := reconService.Reconcile(service.ReconciliationInput{
+				SystemTransactionFile: systemCSV,
+				BankStatementFiles:    []string{bcaCSV},
+				StartDate:             mustParseTime("2024-01-01 00:00:00"),
+				EndDate:               mustParseTime("2024-12-31 23:59:59"),
+				MatchStrategy:         service.NewDateWindowMatchStrategy(0),
+				TimeProximity:         tt.timeProximity,
+			})
+			if err != nil {
+				t.Fatalf("Reconciliation failed: %v", err)
+			}
+
+			if len(result.Matches) != len(tt.expectedPairs) {
+				t.Fatalf("Expected %d matches, got %d", len(tt.expectedPairs), len(result.Matches))
+			}
+			for _, match := range result.Matches {
+				expected := tt.expectedPairs[match.SystemTransaction.TrxID]
+				if match.BankStatementLine.UniqueIdentifier != expected {
+					t.Errorf("Expected %s paired with %s, got %s", match.SystemTransaction.TrxID, expected, match.BankStatementLine.UniqueIdentifier)
+				}
+			}
+			if len(result.UnmatchedSystemTransactions) != len(tt.expectedUnmatched) {
+				t.Fatalf("Expected %d unmatched system transactions, got %d", len(tt.expectedUnmatched), len(result.UnmatchedSystemTransactions))
+			}
+			for i, trxID := range tt.expectedUnmatched {
+				if result.UnmatchedSystemTransactions[i].TrxID != trxID {
+					t.Errorf("Expected %s unmatched, got %s", trxID, result.UnmatchedSystemTransactions[i].TrxID)
+				}
+			}
+		})
+	}
+}
+
+func TestReconciliation_TimeProximityDateOnlyLines(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,50000.00,CREDIT,2024-01-15 15:00:00`)
+
+	// Date-only lines are never rejected by the gap but rank after lines with a time
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date
+BCA-001,50000.00,2024-01-15
+BCA-002,50000.00,2024-01-15 15:10:00`)
+
+	reconService := service.NewReconciliationService()
+	input := service.ReconciliationInput{
+		SystemTransactionFile: systemCSV,
+		BankStatementFiles:    []string{bcaCSV},
+		StartDate:             mustParseTime("2024-01-01 00:00:00"),
+		EndDate:               mustParseTime("2024-12-31 23:59:59"),
+		MatchStrategy:         service.NewExactMatchStrategy(),
+		TimeProximity:         &service.TimeProximityConfig{MaxGap: time.Hour},
+	}
+	result, err := reconService.Reconcile(input)
+	if err != nil {
+		t.Fatalf("Reconciliation failed: %v", err)
+	}
+	if len(result.Matches) != 1 || result.Matches[0].BankStatementLine.UniqueIdentifier != "BCA-002" {
+		t.Errorf("Expected TRX001 paired with the timed line BCA-002, got %v", result.Matches)
+	}
+
+	input.TimeProximity.MaxGap = time.Minute
+	result, err = reconService.Reconcile(input)
+	if err != nil {
+		t.Fatalf("Reconciliation failed: %v", err)
+	}
+	if len(result.Matches) != 1 || result.Matches[0].BankStatementLine.UniqueIdentifier != "BCA-001" {
+		t.Errorf("Expected TRX001 paired with the date-only line BCA-001, got %v", result.Matches)
+	}
+
+	input.TimeProximity.MaxGap = -time.Minute
+	if _, err := reconService.Reconcile(input); err == nil {
+		t.Error("Expected error for a negative maximum gap but got nil")
+	}
+}
+
+// constantScorer rates every pair the same, so that only time proximity orders the candidates
+type constantScorer struct{}
+
+func (constantScorer) Score(models.Transaction, models.BankStatementLine) float64 {
+	return 1
+}
+
+func TestReconciliation_TimeProximityAssignment(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	if err := os.WriteFile(systemCSV, []byte(`trxID,amount,type,transactionTime
+TRX-A,50000.00,CREDIT,2024-01-15 10:00:00
+TRX-B,50000.00,CREDIT,2024-01-15 10:05:00`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	if err := os.WriteFile(bcaCSV, []byte(`unique_identifier,amount,date,time
+BCA-1004,50000.00,2024-01-15,10:04:00
+BCA-0900,50000.00,2024-01-15,09:00:00`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		assignmentMode service.AssignmentMode
+		expectedPairs  map[string]string
+	}{
+		{
+			// A picks first and takes the 10:04 line, leaving B with the 09:00 one
+			name:           "greedy picks in file order",
+			assignmentMode: service.AssignmentGreedy,
+			expectedPairs:  map[string]string{"TRX-A": "BCA-1004", "TRX-B": "BCA-0900"},
+		},
+		{
+			name:           "optimal weighs the gaps of the bucket",
+			assignmentMode: service.AssignmentOptimal,
+			expectedPairs:  map[string]string{"TRX-A": "BCA-0900", "TRX-B": "BCA-1004"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconService := service.NewReconciliationService()
+			result, err := reconService.Reconcile(service.ReconciliationInput{
+				SystemTransactionFile: systemCSV,
+				BankStatementFiles:    []string{bcaCSV},
+				StartDate:             mustParseTime("2024-01-15 00:00:00"),
+				MatchStrategy:         service.NewExactMatchStrategy(),
+				AssignmentMode:        tt.assignmentMode,
+				MatchScorer:           constantScorer{},
+				TimeProximity:         &service.TimeProximityConfig{},
+			})
+			if err != nil {
+				t.Fatalf("Reconciliation failed: %v", err)
+			}
+
+			if len(result.Matches) != len(tt.expectedPairs) {
+				t.Fatalf("Expected %d matches, got %d", len(tt.expectedPairs), len(result.Matches))
+			}
+			for _, match := range result.Matches {
+				expected := tt.expectedPairs[match.SystemTransaction.TrxID]
+				if match.BankStatementLine.UniqueIdentifier != expected {
+					t.Errorf("Expected %s paired with %s, got %s", match.SystemTransaction.TrxID, expected, match.BankStatementLine.UniqueIdentifier)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/firmannf/recon/internal/calendar"
+	"github.com/firmannf/recon/internal/models"
+)
+
+// TransferConfig configures the detection of transfers between our own bank accounts
+type TransferConfig struct {
+	WindowDays int // The credit may be booked up to WindowDays days after the debit
+}
+
+// validate checks that the configuration can be used for transfer detection
+func (c TransferConfig) validate() error {
+	if c.WindowDays < 0 {
+		return fmt.Errorf("transfer window must not be negative (got %d)", c.WindowDays)
+	}
+	return nil
+}
+
+// pairInternalTransfers pairs each unmatched debit with the first unmatched credit of the same amount
+// booked by another bank within the window. Such pairs move funds between our own accounts and have
+// no system transaction, so both lines are marked as matched and listed as internal transfers.
+func (m *matchState) pairInternalTransfers(cfg TransferConfig) {
+	creditsByAmount := make(map[string][]int)
+	for bankIdx, bankStmtLine := range m.bankStmtLines {
+		if !m.matchedBankStmtLines[bankIdx] && bankStmtLine.Type == models.TransactionTypeCredit {
+			key := bankStmtLine.GetAbsoluteAmount().String()
+			creditsByAmount[key] = append(creditsByAmount[key], bankIdx)
+		}
+	}
+
+	for debitIdx, debit := range m.bankStmtLines {
+		if m.matchedBankStmtLines[debitIdx] || debit.Type != models.TransactionTypeDebit {
+			continue
+		}
+
+		for _, creditIdx := range creditsByAmount[debit.GetAbsoluteAmount().String()] {
+			credit := m.bankStmtLines[creditIdx]
+			if m.matchedBankStmtLines[creditIdx] || credit.BankName == debit.BankName {
+				continue
+			}
+			if days := calendar.DaysBetween(debit.Date, credit.Date); days < 0 || days > cfg.WindowDays {
+				continue
+			}
+
+			m.matchedBankStmtLines[debitIdx] = true
+			m.matchedBankStmtLines[creditIdx] = true
+			m.traceConsumed(nil, []int{debitIdx, creditIdx}, fmt.Sprintf("paired as internal transfer %s to %s", debit.UniqueIdentifier, credit.UniqueIdentifier))
+			m.result.InternalTransfers = append(m.result.InternalTransfers, models.InternalTransfer{
+				Debit:  debit,
+				Credit: credit,
+			})
+			break
+		}
+	}
+}
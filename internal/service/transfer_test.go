@@ -0,0 +1,85 @@
+package service_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/firmannf/recon/internal/service"
+)
+
+func TestReconciliation_TransferDetection(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	systemCSV := filepath.Join(tmpDir, "transactions.csv")
+	writeTestFile(t, systemCSV, `trxID,amount,type,transactionTime
+TRX001,250000.00,CREDIT,2024-01-15 10:00:00`)
+
+	bcaCSV := filepath.Join(tmpDir, "bank_bca.csv")
+	writeTestFile(t, bcaCSV, `unique_identifier,amount,date
+BCA-001,-10000000.00,2024-01-15
+BCA-002,-400000.00,2024-01-15
+BCA-003,400000.00,2024-01-15`)
+
+	mandiriCSV := filepath.Join(tmpDir, "bank_mandiri.csv")
+	writeTestFile(t, mandiriCSV, `unique_identifier,amount,date
+MDR-001,10000000.00,2024-01-16
+MDR-002,250000.00,2024-01-15`)
+
+	tests := []struct {
+		name              string
+		transferDetection *service.TransferConfig
+		expectedTransfers int
+		expectedUnmatched int
+	}{
+		{
+			name:              "disabled",
+			expectedUnmatched: 4,
+		},
+		{
+			name:              "credit outside window",
+			transferDetection: &service.TransferConfig{},
+			expectedUnmatched: 4,
+		},
+		{
+			name:              "credit within window",
+			transferDetection: &service.TransferConfig{WindowDays: 1},
+			expectedTransfers: 1,
+			expectedUnmatched: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconService := service.NewReconciliationService()
+			result, err := reconService.Reconcile(service.ReconciliationInput{
+				SystemTransactionFile: systemCSV,
+				BankStatementFiles:    []string{bcaCSV, mandiriCSV},
+				StartDate:             mustParseTime("2024-01-01 00:00:00"),
+				EndDate:               mustParseTime("2024-12-31 23:59:59"),
+				MatchStrategy:         service.NewExactMatchStrategy(),
+				TransferDetection:     tt.transferDetection,
+			})
+			if err != nil {
+				t.Fatalf("Reconciliation failed: %v", err)
+			}
+
+			if len(result.InternalTransfers) != tt.expectedTransfers {
+				t.Fatalf("Expected %d internal transfers, got %d", tt.expectedTransfers, len(result.InternalTransfers))
+			}
+			if result.TotalUnmatchedTransactions != tt.expectedUnmatched {
+				t.Errorf("Expected %d unmatched, got %d", tt.expectedUnmatched, result.TotalUnmatchedTransactions)
+			}
+			if result.TotalMatchedTransactions != 1 {
+				t.Errorf("Expected 1 match, got %d", result.TotalMatchedTransactions)
+			}
+
+			// BCA-002 and BCA-003 offset each other within the same bank, which is not a transfer
+			if tt.expectedTransfers > 0 {
+				transfer := result.InternalTransfers[0]
+				if transfer.Debit.UniqueIdentifier != "BCA-001" || transfer.Credit.UniqueIdentifier != "MDR-001" {
+					t.Errorf("Expected transfer from BCA-001 to MDR-001, got %s to %s", transfer.Debit.UniqueIdentifier, transfer.Credit.UniqueIdentifier)
+				}
+			}
+		})
+	}
+}